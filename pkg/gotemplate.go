@@ -2,38 +2,111 @@ package gotemplate
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"io/fs"
+	"slices"
 	"sync"
+	"sync/atomic"
+	"text/template"
+	"time"
 
+	"github.com/go-logr/logr"
 	"github.com/k8s-manifest-kit/engine/pkg/pipeline"
 	"github.com/k8s-manifest-kit/engine/pkg/types"
 	"github.com/k8s-manifest-kit/pkg/util"
-	"github.com/k8s-manifest-kit/pkg/util/cache"
 	"github.com/k8s-manifest-kit/pkg/util/k8s"
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"go.opentelemetry.io/otel/trace"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 )
 
 const rendererType = "gotemplate"
 
 // Source represents the input for a GoTemplate rendering operation.
 type Source struct {
+	// Name identifies the Source in error messages and is mixed into render cache keys, which
+	// distinguishes Sources sharing a pattern over different filesystems. Optional: when empty,
+	// Sources are identified by their patterns alone.
+	Name string
+
 	// FS is the filesystem containing template files.
 	// Supports embedded filesystems via embed.FS or testing via fstest.MapFS.
 	FS fs.FS
 
 	// Path specifies the glob pattern to match template files.
 	// Examples: "templates/*.tpl", "**/*.yaml.gotmpl"
+	//
+	// Single-level patterns follow path.Match semantics and name templates by file base name.
+	// Patterns containing "**" match any number of directories and name templates by their
+	// path relative to the FS root, so nested files with the same base name stay distinct.
 	Path string
 
+	// Paths specifies additional glob patterns, parsed into the same template set as Path.
+	// When both are set, Path is matched first, followed by Paths in order. Each pattern must
+	// match at least one file, and files from different patterns must resolve to distinct
+	// template names. The patterns joined with "," identify the Source in errors, annotations
+	// and cache keys.
+	Paths []string
+
+	// ValuesFiles lists YAML or JSON files on FS whose contents are deep merged, in order,
+	// underneath Values. Files are read on every render, so updates are picked up
+	// without re-creating the renderer.
+	ValuesFiles []string
+
 	// Values provides data to be substituted into templates during rendering.
 	// Function is called during rendering to obtain dynamic values.
 	// Accessible within templates via dot notation (e.g., {{ .FieldName }}).
 	Values func(context.Context) (map[string]any, error)
+
+	// ValuesMode controls how the Source values (ValuesFiles and Values) combine with the
+	// renderer-wide values (WithDefaultValues, WithValuesFunc and render-time values).
+	// Default: ValuesModeMerge.
+	ValuesMode ValuesMode
+
+	// Order positions the output of the Source: Sources render, and their objects and documents
+	// are emitted, in ascending Order, e.g. a negative Order puts CRDs before the resources using
+	// them. Sources with equal Order keep the order they were passed to New. Default: 0.
+	Order int
+
+	// Labels tag the Source, so RenderSelected can render a subset of the Sources, e.g.
+	// {"kind": "crds"}. Optional.
+	Labels map[string]string
+
+	// Fallback supplies the files missing from FS, templates included: Source patterns match
+	// the files of FS and of the fallback alike, and each file is read from FS when it exists
+	// there, from the fallback otherwise, e.g. shared defaults under environment-specific
+	// templates. The Fallback of the fallback extends the chain; other fields of fallbacks
+	// are ignored, except Name, which identifies them in logs. Optional.
+	Fallback *Source
+
+	// Cache overrides the render caching of the Source: false renders it on every call even
+	// when WithCache is set, e.g. for output depending on more than the values, while true
+	// caches it as the renderer does. nil = the renderer default.
+	Cache *bool
 }
 
+// ValuesMode controls how Source values combine with renderer-wide values.
+// All merges are deep merges with the same semantics as WithDefaultValues.
+type ValuesMode string
+
+const (
+	// ValuesModeMerge layers Source values over default values and under render-time values.
+	ValuesModeMerge ValuesMode = ""
+
+	// ValuesModeOverlay layers Source values over both default and render-time values,
+	// so the Source wins on conflicting keys.
+	ValuesModeOverlay ValuesMode = "overlay"
+
+	// ValuesModeReplace uses the Source values only, ignoring default and render-time values.
+	ValuesModeReplace ValuesMode = "replace"
+)
+
 // Renderer handles Go template rendering operations.
 // It implements types.Renderer.
 //
@@ -41,188 +114,1339 @@ type Source struct {
 // may call Process() concurrently on the same Renderer instance. Template parsing
 // is protected by per-Source mutexes to ensure thread-safe lazy initialization.
 type Renderer struct {
-	inputs []*sourceHolder
-	opts   RendererOptions
-	cache  cache.Interface[[]unstructured.Unstructured]
+	inputs   []*sourceHolder
+	sources  []Source
+	options  []RendererOption
+	opts     RendererOptions
+	cache    *renderCache
+	schema   *jsonschema.Schema
+	set      map[string]any
+	reserved []valuesLayer
+	watcher  *watcher
+	tracer   trace.Tracer
+	metrics  *metrics
+	closed   atomic.Bool
 }
 
 // New creates a new GoTemplate Renderer with the given inputs and options.
 func New(inputs []Source, opts ...RendererOption) (*Renderer, error) {
 	rendererOpts := RendererOptions{
-		Filters:      make([]types.Filter, 0),
-		Transformers: make([]types.Transformer, 0),
+		Filters:        make([]types.Filter, 0),
+		Transformers:   make([]types.Transformer, 0),
+		MissingKeyMode: MissingKeyError,
+		OutputFormat:   FormatYAML,
+		Clock:          time.Now,
+		Logger:         logr.Discard(),
 	}
 
 	for _, opt := range opts {
 		opt.ApplyTo(&rendererOpts)
 	}
 
+	if err := rendererOpts.validate(); err != nil {
+		return nil, fmt.Errorf("invalid renderer options: %w", err)
+	}
+
+	funcs := newFuncMap(rendererOpts)
+	randomFuncs := seededRandomFuncs(rendererOpts)
+	_, userChecksumOf := rendererOpts.FuncMap["checksumOf"]
+	checksums := rendererOpts.Checksums && !userChecksumOf
+
+	var imageResolver ImageResolver
+	if _, userImageDigest := rendererOpts.FuncMap["imageDigest"]; !userImageDigest {
+		imageResolver = rendererOpts.ImageResolver
+	}
+
 	// Wrap sources in holders and validate
 	holders := make([]*sourceHolder, len(inputs))
 	for i := range inputs {
 		holders[i] = &sourceHolder{
-			Source: inputs[i],
-			mu:     &sync.RWMutex{},
+			Source:     inputs[i],
+			mu:         &sync.RWMutex{},
+			funcs:      funcs,
+			missingKey: rendererOpts.MissingKeyMode,
+			layout:     rendererOpts.Layout,
+			ttl:        rendererOpts.CacheTTL,
+			now:        rendererOpts.Clock,
+			noCache:    rendererOpts.NoCache,
+			normalize:  !rendererOpts.KeepLineEndings,
+
+			passthrough:   rendererOpts.PassthroughPaths,
+			maxDepth:      cmp.Or(rendererOpts.MaxIncludeDepth, defaultMaxIncludeDepth),
+			randomFuncs:   randomFuncs,
+			checksums:     checksums,
+			imageResolver: imageResolver,
+		}
+		holders[i].log = holders[i].logger(rendererOpts.Logger)
+		if d := rendererOpts.Delimiters; d != nil {
+			holders[i].leftDelim = d.Left
+			holders[i].rightDelim = d.Right
 		}
 		if err := holders[i].Validate(); err != nil {
-			return nil, fmt.Errorf("validation failed for source with path %q: %w", inputs[i].Path, err)
+			return nil, fmt.Errorf("validation failed for source %s: %w", holders[i].describe(), err)
+		}
+
+		chain, err := fallbackChain(&holders[i].Source)
+		if err != nil {
+			return nil, fmt.Errorf("validation failed for source %s: %w", holders[i].describe(), err)
+		}
+
+		holders[i].fallbacks = chain
+	}
+
+	assignFSIdentities(holders, rendererOpts.FSIdentity)
+
+	// Fallbacks resolve the files missing from the Source FS, before any overlay
+	for _, h := range holders {
+		if len(h.fallbacks) > 0 {
+			h.FS = newFallbackFS(h.FS, h.fallbacks, h.log)
 		}
 	}
 
+	// The overlay applies to every Source alike, so FS identities are those of the base FSes
+	if rendererOpts.OverlayFS != nil {
+		for _, h := range holders {
+			h.FS = newOverlayFS(h.FS, rendererOpts.OverlayFS, h.log)
+		}
+	}
+
+	// Emit Sources by ascending Order, keeping the input order of equal ones
+	slices.SortStableFunc(holders, func(a, b *sourceHolder) int {
+		return cmp.Compare(a.Order, b.Order)
+	})
+
 	r := &Renderer{
-		inputs: holders,
-		opts:   rendererOpts,
-		cache:  newCache(rendererOpts.CacheOptions),
+		inputs:  holders,
+		sources: slices.Clone(inputs),
+		options: slices.Clone(opts),
+		opts:    rendererOpts,
+	}
+
+	// WithNoCache bypasses the render cache even when WithCache is set
+	if !rendererOpts.NoCache {
+		r.cache = newCache(
+			rendererOpts.CacheOptions,
+			rendererOpts.CacheMaxEntries,
+			rendererOpts.CacheSalt,
+			rendererOpts.Clock,
+		)
+	}
+
+	if rendererOpts.ValuesSchema != nil {
+		schema, err := compileValuesSchema(rendererOpts.ValuesSchema)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renderer options: %w", err)
+		}
+
+		r.schema = schema
+	}
+
+	if len(rendererOpts.SetValues) > 0 {
+		set, err := parseSetValues(rendererOpts.SetValues)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renderer options: %w", err)
+		}
+
+		r.set = set
+	}
+
+	reserved, err := reservedLayers(rendererOpts)
+	if err != nil {
+		return nil, fmt.Errorf("invalid renderer options: %w", err)
+	}
+
+	r.reserved = reserved
+
+	// Fail fast on patterns matching no files, unless they may appear later
+	if !rendererOpts.LazyValidation {
+		for _, h := range holders {
+			if err := h.checkPatterns(); err != nil {
+				return nil, fmt.Errorf("validation failed for source %s: %w", h.describe(), err)
+			}
+		}
+	}
+
+	if rendererOpts.MetricsRegisterer != nil {
+		m, err := newMetrics(rendererOpts.MetricsRegisterer)
+		if err != nil {
+			return nil, fmt.Errorf("invalid renderer options: %w", err)
+		}
+
+		r.metrics = m
+	}
+
+	if rendererOpts.TracerProvider != nil {
+		r.tracer = rendererOpts.TracerProvider.Tracer(tracerName)
+	}
+
+	if rendererOpts.WatchInterval > 0 {
+		r.startWatch(rendererOpts.WatchInterval)
 	}
 
 	return r, nil
 }
 
+// NewFromString creates a Renderer for a single template called name with the given content,
+// served from memory, so quick tests and dynamic snippets need no fs.FS. The template behaves
+// exactly like a file-based one: options such as WithCache and WithFuncMap apply, and values
+// are given through WithDefaultValues or at render time. name must be a valid unrooted fs path,
+// e.g. "pod.yaml", and names the template as a file would; glob metacharacters are literal.
+func NewFromString(name string, content string, opts ...RendererOption) (*Renderer, error) {
+	if name == "." || !fs.ValidPath(name) {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidTemplateName, name)
+	}
+
+	return New(
+		[]Source{
+			{
+				FS:   memFS{name: []byte(content)},
+				Path: escapeGlob(name),
+			},
+		},
+		opts...,
+	)
+}
+
 // Process executes the rendering logic for all configured inputs.
+// With WithParallelism, Sources are rendered concurrently; output order always follows Source order.
+// With WithContinueOnError, every Source is attempted and the objects of the successful ones are
+// returned together with the joined errors of the failed ones.
+// The WithObjectsTransformer transformers then run over the objects of all Sources.
 // This method is safe for concurrent use.
 func (r *Renderer) Process(ctx context.Context, renderTimeValues map[string]any) ([]unstructured.Unstructured, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	return r.process(ctx, r.inputs, renderTimeValues)
+}
+
+// RenderSelected renders like Process the Sources whose Labels match selector, e.g. only the
+// CRDs of a renderer holding a whole application, in Source order. A nil or empty selector
+// selects every Source, rendering exactly as Process; a selector matching no Source renders
+// nothing. Objects are only checked for duplicates (WithDetectDuplicates) among the selected
+// Sources, and the WithObjectsTransformer transformers run over their objects only.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderSelected(
+	ctx context.Context,
+	selector labels.Selector,
+	renderTimeValues map[string]any,
+) ([]unstructured.Unstructured, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	if selector == nil || selector.Empty() {
+		return r.process(ctx, r.inputs, renderTimeValues)
+	}
+
+	selected := make([]*sourceHolder, 0, len(r.inputs))
+	for _, holder := range r.inputs {
+		if selector.Matches(labels.Set(holder.Labels)) {
+			selected = append(selected, holder)
+		}
+	}
+
+	return r.process(ctx, selected, renderTimeValues)
+}
+
+// process renders holders, checks the objects for duplicates and runs the objects transformers.
+func (r *Renderer) process(
+	ctx context.Context,
+	holders []*sourceHolder,
+	renderTimeValues map[string]any,
+) ([]unstructured.Unstructured, error) {
+	var objects []unstructured.Unstructured
+	var err error
+
+	ids := r.newIdentities()
+
+	if r.opts.Parallelism > 1 && len(holders) > 1 {
+		objects, err = r.processParallel(ctx, holders, renderTimeValues, ids)
+	} else {
+		objects, err = r.processSequential(ctx, holders, renderTimeValues, ids)
+	}
+
+	if objects == nil {
+		return nil, err
+	}
+
+	if dupErr := ids.Err(); dupErr != nil {
+		if !r.opts.ContinueOnError {
+			return nil, dupErr
+		}
+
+		err = errors.Join(err, dupErr)
+	}
+
+	transformed, transformErr := r.transformValues(ctx, objects)
+	if transformErr != nil {
+		return nil, errors.Join(err, transformErr)
+	}
+
+	return transformed, err
+}
+
+// processSequential renders holders one at a time, stopping at the first error
+// unless WithContinueOnError is set, and records the identities of the objects in ids.
+func (r *Renderer) processSequential(
+	ctx context.Context,
+	holders []*sourceHolder,
+	renderTimeValues map[string]any,
+	ids *identities,
+) ([]unstructured.Unstructured, error) {
 	allObjects := make([]unstructured.Unstructured, 0)
+	errs := make([]error, 0)
 
-	for i := range r.inputs {
-		objects, err := r.renderSingle(ctx, r.inputs[i], renderTimeValues)
+	for _, holder := range holders {
+		objects, _, err := r.processSource(ctx, holder, renderTimeValues)
 		if err != nil {
-			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", r.inputs[i].Path, err)
+			if !r.opts.ContinueOnError {
+				return nil, err
+			}
+
+			errs = append(errs, err)
+
+			continue
 		}
 
-		// Apply renderer-level filters and transformers per-source for better error context
-		transformed, err := pipeline.Apply(ctx, objects, r.opts.Filters, r.opts.Transformers)
-		if err != nil {
-			return nil, fmt.Errorf(
-				"error applying filters/transformers to gotemplate pattern %s: %w",
-				r.inputs[i].Path,
-				err,
-			)
+		for i := range objects {
+			ids.add(holder, &objects[i])
 		}
 
-		allObjects = append(allObjects, transformed...)
+		allObjects = append(allObjects, objects...)
+	}
+
+	if len(errs) > 0 {
+		return allObjects, errors.Join(errs...)
 	}
 
 	return allObjects, nil
 }
 
-// Name returns the renderer type identifier.
-func (r *Renderer) Name() string {
-	return rendererType
+// processParallel renders holders on a pool of Parallelism workers. The first failure cancels
+// the remaining work (unless WithContinueOnError is set); all failures are returned joined, in
+// Source order, while Sources that only observed that internal cancellation are not reported.
+// Results are collected in Source order, recording the identities of the objects in ids.
+func (r *Renderer) processParallel(
+	ctx context.Context,
+	holders []*sourceHolder,
+	renderTimeValues map[string]any,
+	ids *identities,
+) ([]unstructured.Unstructured, error) {
+	type result struct {
+		objects []unstructured.Unstructured
+		err     error
+	}
+
+	workerCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	results := make([]result, len(holders))
+	indices := make(chan int)
+
+	var wg sync.WaitGroup
+
+	for range min(r.opts.Parallelism, len(holders)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for idx := range indices {
+				objects, _, err := r.processSource(workerCtx, holders[idx], renderTimeValues)
+				if err != nil && !r.opts.ContinueOnError {
+					cancel()
+				}
+
+				results[idx] = result{
+					objects: objects,
+					err:     err,
+				}
+			}
+		}()
+	}
+
+	for i := range holders {
+		indices <- i
+	}
+
+	close(indices)
+	wg.Wait()
+
+	// Collect results in original Source order
+	allObjects := make([]unstructured.Unstructured, 0)
+	errs := make([]error, 0)
+
+	for idx, res := range results {
+		switch {
+		case res.err == nil:
+			for i := range res.objects {
+				ids.add(holders[idx], &res.objects[i])
+			}
+
+			allObjects = append(allObjects, res.objects...)
+		case ctx.Err() == nil && errors.Is(res.err, context.Canceled):
+			// Cancelled because another Source failed
+		default:
+			errs = append(errs, res.err)
+		}
+	}
+
+	if len(errs) > 0 {
+		if r.opts.ContinueOnError {
+			return allObjects, errors.Join(errs...)
+		}
+
+		return nil, errors.Join(errs...)
+	}
+
+	return allObjects, nil
 }
 
-func (r *Renderer) values(
+// processSource renders a single input and applies renderer-level filters and transformers,
+// reporting whether the objects came from the render cache.
+func (r *Renderer) processSource(
 	ctx context.Context,
 	holder *sourceHolder,
 	renderTimeValues map[string]any,
-) (map[string]any, error) {
-	sourceValues := map[string]any{}
+) ([]unstructured.Unstructured, bool, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, false, fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
+	}
 
-	if holder.Values != nil {
-		v, err := holder.Values(ctx)
+	objects, cached, err := r.renderSingle(ctx, holder, renderTimeValues)
+	if err != nil {
+		return nil, false, sourceError("error rendering", holder, err)
+	}
+
+	// Apply renderer-level filters and transformers per-source for better error context
+	transformed, err := pipeline.Apply(ctx, objects, r.opts.Filters, r.opts.Transformers)
+	if err != nil {
+		return nil, false, fmt.Errorf(
+			"error applying filters/transformers to gotemplate pattern %s: %w",
+			holder.describe(),
+			err,
+		)
+	}
+
+	return transformed, cached, nil
+}
+
+// Validate runs the full rendering pipeline for every configured input (template parsing,
+// value merging and validation, execution, YAML decoding, filters and transformers) and discards
+// the output. The render result cache is neither consulted nor populated, so a previously cached
+// result cannot mask a problem. All Sources are checked; failures are returned joined. The
+// WithObjectsTransformer transformers run once every Source passes.
+// This method is safe for concurrent use.
+func (r *Renderer) Validate(ctx context.Context, values map[string]any) error {
+	if err := r.checkOpen(); err != nil {
+		return err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	errs := make([]error, 0)
+	allObjects := make([]unstructured.Unstructured, 0)
+
+	for _, holder := range r.inputs {
+		objects, err := r.validateSource(ctx, holder, values)
 		if err != nil {
-			return nil, fmt.Errorf(
-				"failed to get values for template pattern %q: %w",
-				holder.Path,
-				err,
-			)
+			errs = append(errs, sourceError("error validating", holder, err))
+
+			continue
 		}
 
-		sourceValues = v
+		allObjects = append(allObjects, objects...)
+	}
+
+	if len(errs) > 0 {
+		return errors.Join(errs...)
+	}
+
+	if _, err := r.transformValues(ctx, allObjects); err != nil {
+		return err
 	}
 
-	// Deep merge with render-time values taking precedence
-	return util.DeepMerge(sourceValues, renderTimeValues), nil
+	return nil
 }
 
-// renderSingle performs the rendering for a single template input.
-func (r *Renderer) renderSingle(
+func (r *Renderer) validateSource(
 	ctx context.Context,
 	holder *sourceHolder,
 	renderTimeValues map[string]any,
 ) ([]unstructured.Unstructured, error) {
-	// Parse templates if not already parsed (thread-safe lazy loading)
+	if err := ctx.Err(); err != nil {
+		return nil, fmt.Errorf("validation cancelled: %w", err)
+	}
+
 	templates, err := holder.LoadTemplates()
 	if err != nil {
 		return nil, err
 	}
 
-	// Get values dynamically (includes render-time values)
 	values, err := r.values(ctx, holder, renderTimeValues)
 	if err != nil {
-		return nil, fmt.Errorf(
-			"failed to get values for pattern %q: %w",
-			holder.Path,
-			err,
-		)
+		return nil, err
 	}
 
-	spec := TemplateSpec{
-		Path:   holder.Path,
-		Values: values,
+	objects, err := r.execute(ctx, holder, templates, values, nil)
+	if err != nil {
+		return nil, err
 	}
 
-	// Check cache (if enabled)
-	if r.cache != nil {
-		// ensure objects are evicted
-		r.cache.Sync()
+	transformed, err := pipeline.Apply(ctx, objects, r.opts.Filters, r.opts.Transformers)
+	if err != nil {
+		return nil, fmt.Errorf("error applying filters/transformers: %w", err)
+	}
 
-		if cached, found := r.cache.Get(spec); found {
-			return cached, nil
-		}
+	return transformed, nil
+}
+
+// RenderTemplate executes the single template called name, either a template file or a
+// {{ define }} block, and returns its raw output. Sources are searched in order and the
+// first one whose template set defines name is used; its Values are merged with values
+// exactly as in Process. Parsed templates are shared with Process, while the render result
+// cache is not consulted since the output is not decoded into objects.
+// Returns a *TemplateNotFoundError listing the available names if no Source defines name.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderTemplate(ctx context.Context, name string, values map[string]any) ([]byte, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
 	}
 
-	result := make([]unstructured.Unstructured, 0)
+	ctx = r.withOutputBudget(ctx)
 
-	var buf bytes.Buffer
+	available := make([]string, 0)
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		t := templates.Lookup(name)
+		if t == nil || name == "" {
+			available = append(available, templateNames(templates)...)
 
-	// Execute each template
-	for _, t := range templates.Templates() {
-		// Skip the root template
-		if t.Name() == "" {
 			continue
 		}
 
-		// Check for context cancellation
+		merged, err := r.values(ctx, holder, values)
+		if err != nil {
+			return nil, err
+		}
+
 		if err := ctx.Err(); err != nil {
-			return nil, fmt.Errorf("template rendering cancelled: %w", err)
+			return nil, fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), err)
 		}
 
-		buf.Reset()
+		var buf bytes.Buffer
+		if err := r.executeTemplate(ctx, holder, t, &buf, merged); err != nil {
+			return nil, err
+		}
 
-		// Execute the template
-		if err := t.Execute(&buf, values); err != nil {
-			return nil, fmt.Errorf("failed to execute template %s: %w", t.Name(), err)
+		return buf.Bytes(), nil
+	}
+
+	slices.Sort(available)
+
+	return nil, &TemplateNotFoundError{
+		Name:      name,
+		Available: slices.Compact(available),
+	}
+}
+
+// RenderDocuments executes all templates of every configured input and returns the rendered
+// output split into individual YAML documents, in Source order and then template name order.
+// Documents are split on "---" and "..." markers at the start of a line, so separators inside
+// indented block scalars are preserved; documents containing only whitespace or comments are
+// dropped. Source Values are used as-is, and filters, transformers and the render result cache
+// do not apply since the output is not decoded into objects. With WithOutputFormat(FormatJSON)
+// every document is returned as a JSON object, with WithCanonicalize in canonical form.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderDocuments(ctx context.Context) ([][]byte, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	documents := make([][]byte, 0)
+
+	var buf bytes.Buffer
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
 		}
 
-		// Decode the rendered output into unstructured objects
-		objs, err := k8s.DecodeYAML(buf.Bytes())
+		templates, err := holder.LoadTemplates()
 		if err != nil {
-			return nil, fmt.Errorf("failed to decode YAML from template %s: %w", t.Name(), err)
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		values, err := r.values(ctx, holder, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := holder.entryTemplates(templates)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
 		}
 
-		// Add source annotations if enabled
-		if r.opts.SourceAnnotations {
-			for i := range objs {
-				annotations := objs[i].GetAnnotations()
-				if annotations == nil {
-					annotations = make(map[string]string)
+		index := 0
+
+		for _, t := range entries {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), err)
+			}
+
+			buf.Reset()
+
+			if err := r.executeTemplate(ctx, holder, t, &buf, values); err != nil {
+				return nil, err
+			}
+
+			for _, doc := range splitDocuments(buf.Bytes(), r.opts.KeepEmptyDocuments) {
+				if err := r.checkDocument(holder, t.Name(), index, doc); err != nil {
+					return nil, err
+				}
+
+				encoded, keep, err := r.encodeDocument(holder, t.Name(), index, doc)
+				if err != nil {
+					return nil, err
 				}
 
-				annotations[types.AnnotationSourceType] = rendererType
-				annotations[types.AnnotationSourcePath] = holder.Path
-				annotations[types.AnnotationSourceFile] = t.Name()
+				if keep {
+					documents = append(documents, encoded)
+				}
 
-				objs[i].SetAnnotations(annotations)
+				index++
 			}
 		}
-
-		result = append(result, objs...)
 	}
 
-	// Cache result (if enabled)
+	return documents, nil
+}
+
+// RenderObjects executes all templates of every configured input, splits the output into YAML
+// documents as RenderDocuments does and decodes each one, in Source order and then template name
+// order. values are merged with Source values as in Process, and objects carry the configured
+// namespace, common metadata and source annotations. Filters, transformers and the render result
+// cache do not apply, except for the WithObjectsTransformer transformers. Decode errors identify
+// the Source pattern and the index of the document within the Source output.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderObjects(ctx context.Context, values map[string]any) ([]*unstructured.Unstructured, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	result := make([]*unstructured.Unstructured, 0)
+	ids := r.newIdentities()
+
+	var buf bytes.Buffer
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		merged, err := r.values(ctx, holder, values)
+		if err != nil {
+			return nil, err
+		}
+
+		entries, err := holder.entryTemplates(templates)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		index := 0
+
+		for _, t := range entries {
+			if err := ctx.Err(); err != nil {
+				return nil, fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), err)
+			}
+
+			buf.Reset()
+
+			if err := r.executeTemplate(ctx, holder, t, &buf, merged); err != nil {
+				return nil, err
+			}
+
+			for _, doc := range splitDocuments(buf.Bytes(), r.opts.KeepEmptyDocuments) {
+				objects, err := r.decodeDocument(holder, t.Name(), index, doc)
+				if err != nil {
+					return nil, err
+				}
+
+				ids.add(holder, objects...)
+				result = append(result, objects...)
+				index++
+			}
+		}
+	}
+
+	if err := ids.Err(); err != nil {
+		return nil, err
+	}
+
+	return r.transformObjects(ctx, result)
+}
+
+// decodeDocument decodes the document at index of the output of a Source into decorated objects.
+func (r *Renderer) decodeDocument(
+	holder *sourceHolder,
+	name string,
+	index int,
+	doc []byte,
+) ([]*unstructured.Unstructured, error) {
+	if err := r.checkDocument(holder, name, index, doc); err != nil {
+		return nil, err
+	}
+
+	objs, err := k8s.DecodeYAML(doc)
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to decode document %d of gotemplate pattern %s (template %s): %w",
+			index,
+			holder.describe(),
+			name,
+			err,
+		)
+	}
+
+	result := make([]*unstructured.Unstructured, 0, len(objs))
+	for i := range objs {
+		if err := r.decorate(holder, name, &objs[i]); err != nil {
+			return nil, fmt.Errorf("failed to decorate objects from template %s: %w", name, err)
+		}
+
+		result = append(result, &objs[i])
+	}
+
+	return result, nil
+}
+
+// RenderTo executes all templates of every configured input directly into w, without
+// buffering the output, in Source order and then template name order. Template outputs are
+// separated by a YAML document separator. values are merged with Source values as in Process.
+// With WithOutputFormat(FormatJSON) the output of each template is split into documents and
+// written as JSON objects, one per line; with WithCanonicalize it is split into documents
+// written in canonical form. With WithKRMOutput the Sources are rendered into objects like
+// Process and written as a single KRM ResourceList.
+// Cancellation is checked before each template so a cancelled render stops promptly.
+// On error, bytes already written to w are not rolled back.
+// This method is safe for concurrent use, provided w is not shared.
+func (r *Renderer) RenderTo(ctx context.Context, w io.Writer, values map[string]any) error {
+	if err := r.checkOpen(); err != nil {
+		return err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	return r.renderTo(ctx, w, values, nil)
+}
+
+// renderTo implements RenderTo, adding the provenance of the values of every Source to
+// report when not nil.
+func (r *Renderer) renderTo(ctx context.Context, w io.Writer, values map[string]any, report *ProvenanceReport) error {
+	if r.opts.KRMOutput {
+		return r.renderResourceList(ctx, w, values, report)
+	}
+
+	out := &documentWriter{w: w, sep: r.opts.DocumentSeparator}
+	if r.opts.OutputFormat == FormatJSON && out.sep == nil {
+		// JSON Lines: every encoded document already ends with a newline
+		lines := ""
+		out.sep = &lines
+	}
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		layers, err := r.valuesLayers(ctx, holder, values)
+		if err != nil {
+			return err
+		}
+
+		if report != nil {
+			report.add(holder, layers)
+		}
+
+		merged, err := r.mergeValues(holder, layers)
+		if err != nil {
+			return err
+		}
+
+		entries, err := holder.entryTemplates(templates)
+		if err != nil {
+			return fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		index := 0
+
+		for _, t := range entries {
+			if err := ctx.Err(); err != nil {
+				return fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), err)
+			}
+
+			if r.opts.OutputFormat == FormatJSON || r.opts.Canonicalize {
+				if index, err = r.writeDocuments(ctx, out, holder, t, merged, index); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			if err := out.StartDocument(); err != nil {
+				return fmt.Errorf("failed to write document separator: %w", err)
+			}
+
+			if err := r.executeTemplate(ctx, holder, t, out, merged); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// Warm parses the templates of every Source up front, without executing them, so the first
+// render reuses them instead of paying the parse cost, e.g. during controller startup. Sources
+// whose templates are already parsed are left as they are. Cancellation is checked before each
+// Source; parse failures of all Sources are returned joined. With WithNoCache parsed templates
+// are not kept, so Warm only checks that they parse.
+// This method is safe for concurrent use.
+func (r *Renderer) Warm(ctx context.Context) error {
+	if err := r.checkOpen(); err != nil {
+		return err
+	}
+
+	errs := make([]error, 0)
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			errs = append(errs, fmt.Errorf("warming cancelled before gotemplate pattern %s: %w", holder.describe(), err))
+
+			break
+		}
+
+		if _, err := r.loadTemplates(ctx, holder); err != nil {
+			errs = append(errs, fmt.Errorf("error warming gotemplate pattern %s: %w", holder.describe(), err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Templates returns a clone of the parsed template set of the first Source called sourceName,
+// for callers that need to introspect or execute templates directly. Templates are loaded as for
+// rendering, so the FuncMap, delimiters and missingkey option are already applied, and include
+// and tpl resolve names within the returned set. Changes to the clone, such as parsing additional
+// templates, do not affect the renderer. Returns ErrSourceNotFound if no Source has that name.
+// This method is safe for concurrent use.
+func (r *Renderer) Templates(sourceName string) (*template.Template, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	for _, holder := range r.inputs {
+		if holder.Name != sourceName {
+			continue
+		}
+
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("error loading gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		clone, err := templates.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone templates of gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		// Rebind the set functions to the clone; user functions keep precedence
+		return clone.Funcs(setFuncMap(clone, holder.funcs, holder.rootNesting())).Funcs(holder.funcs), nil
+	}
+
+	return nil, fmt.Errorf("%w: %q", ErrSourceNotFound, sourceName)
+}
+
+// Close releases the renderer resources: it stops watching Sources (see WithWatch) and clears
+// the render cache. Afterwards every rendering method fails with ErrRendererClosed, while renders
+// already in flight complete normally. Close is idempotent and safe for concurrent use.
+func (r *Renderer) Close() error {
+	if r.closed.Swap(true) {
+		return nil
+	}
+
+	if r.watcher != nil {
+		r.watcher.Stop()
+	}
+
 	if r.cache != nil {
-		r.cache.Set(spec, result)
+		r.cache.Clear()
+	}
+
+	return nil
+}
+
+// checkOpen returns ErrRendererClosed once Close has been called.
+func (r *Renderer) checkOpen() error {
+	if r.closed.Load() {
+		return ErrRendererClosed
+	}
+
+	return nil
+}
+
+// Name returns the renderer type identifier.
+func (r *Renderer) Name() string {
+	return rendererType
+}
+
+// Stats returns a snapshot of render cache statistics.
+// Counters are updated atomically and are accurate under concurrent Process calls.
+// Returns zero stats when caching is disabled.
+func (r *Renderer) Stats() CacheStats {
+	if r.cache == nil {
+		return CacheStats{}
+	}
+
+	return r.cache.Stats()
+}
+
+// InvalidateCache discards all cached render results and parsed templates,
+// forcing templates to be re-parsed from their FS on the next Process call.
+// Safe to call concurrently with Process.
+func (r *Renderer) InvalidateCache() {
+	if r.cache != nil {
+		r.cache.Clear()
+	}
+
+	for _, h := range r.inputs {
+		h.Reset()
+	}
+}
+
+// CacheKey returns the key under which the render result for spec is cached: the string produced
+// by the configured cache KeyFunc, prefixed with the WithCacheSalt salt if set. A spec without FS
+// uses the FS identity of the first Source with the same Name and Path.
+// Returns "" when caching is disabled.
+func (r *Renderer) CacheKey(spec TemplateSpec) string {
+	if r.cache == nil {
+		return ""
+	}
+
+	if spec.FS == "" {
+		for _, h := range r.inputs {
+			if h.Name == spec.Name && h.pathPattern() == spec.Path {
+				spec.FS = h.fsID
+
+				break
+			}
+		}
+	}
+
+	return r.cache.Key(spec)
+}
+
+// InvalidateKey discards the cached render result stored under key, as returned by CacheKey.
+// Parsed templates of the Source that produced the entry are discarded as well.
+// Safe to call concurrently with Process.
+func (r *Renderer) InvalidateKey(key string) {
+	if r.cache == nil {
+		return
+	}
+
+	if path, ok := r.cache.Delete(key); ok {
+		r.resetPath(path)
+	}
+}
+
+// InvalidatePath discards all cached render results and parsed templates for
+// Sources whose patterns match path (the TemplateSpec.Path used for caching,
+// i.e. Path and Paths joined with ",").
+// Safe to call concurrently with Process.
+func (r *Renderer) InvalidatePath(path string) {
+	if r.cache != nil {
+		r.cache.DeletePath(path)
+	}
+
+	r.resetPath(path)
+}
+
+func (r *Renderer) resetPath(path string) {
+	for _, h := range r.inputs {
+		if h.pathPattern() == path {
+			h.Reset()
+		}
+	}
+}
+
+func (r *Renderer) values(
+	ctx context.Context,
+	holder *sourceHolder,
+	renderTimeValues map[string]any,
+) (map[string]any, error) {
+	layers, err := r.valuesLayers(ctx, holder, renderTimeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return r.mergeValues(holder, layers)
+}
+
+// valuesLayers returns the value maps of a Source in increasing precedence, as arranged
+// by its ValuesMode, followed by the reserved release metadata (WithReleaseInfo) and cluster
+// capabilities (WithCapabilities) in every mode.
+func (r *Renderer) valuesLayers(
+	ctx context.Context,
+	holder *sourceHolder,
+	renderTimeValues map[string]any,
+) ([]valuesLayer, error) {
+	layers, err := r.modeLayers(ctx, holder, renderTimeValues)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(layers, r.reserved...), nil
+}
+
+// modeLayers returns the value maps of a Source in increasing precedence, as arranged by its
+// ValuesMode.
+func (r *Renderer) modeLayers(
+	ctx context.Context,
+	holder *sourceHolder,
+	renderTimeValues map[string]any,
+) ([]valuesLayer, error) {
+	sourceLayers, err := holder.LoadValuesFiles()
+	if err != nil {
+		return nil, fmt.Errorf(
+			"failed to get values for template pattern %q: %w",
+			holder.pathPattern(),
+			err,
+		)
+	}
+
+	if holder.Values != nil {
+		v, err := holder.Values(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to get values for template pattern %q: %w",
+				holder.pathPattern(),
+				err,
+			)
+		}
+
+		sourceLayers = append(sourceLayers, valuesLayer{layer: LayerSource, values: v})
+	}
+
+	if holder.ValuesMode == ValuesModeReplace {
+		return sourceLayers, nil
+	}
+
+	defaults := []valuesLayer{{layer: LayerDefaults, values: r.opts.DefaultValues}}
+
+	if r.opts.EnvAllow != nil {
+		defaults = append(defaults, valuesLayer{layer: LayerEnv, values: r.envValues()})
+	}
+
+	if r.opts.ValuesFunc != nil {
+		v, err := r.opts.ValuesFunc(ctx)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to get renderer values for template pattern %q: %w",
+				holder.pathPattern(),
+				err,
+			)
+		}
+
+		defaults = append(defaults, valuesLayer{layer: LayerValuesFunc, values: v})
+	}
+
+	renderTime := valuesLayer{layer: LayerRenderTime, values: renderTimeValues}
+
+	var layers []valuesLayer
+	if holder.ValuesMode == ValuesModeOverlay {
+		layers = append(append(defaults, renderTime), sourceLayers...)
+	} else {
+		// Render-time values take precedence over source values,
+		// which in turn take precedence over renderer defaults
+		layers = append(append(defaults, sourceLayers...), renderTime)
+	}
+
+	if r.set != nil {
+		layers = append(layers, valuesLayer{layer: LayerSet, values: r.set, set: r.opts.SetValues})
+	}
+
+	return layers, nil
+}
+
+// mergeValues deep merges the value layers of a Source, then templates and validates the result.
+// The reserved layers (Release, Capabilities) are merged last, after templating and schema
+// validation, so values schemas only describe the user values: a schema rejecting additional
+// properties keeps working with WithReleaseInfo and WithCapabilities.
+func (r *Renderer) mergeValues(holder *sourceHolder, layers []valuesLayer) (map[string]any, error) {
+	values := map[string]any{}
+	reserved := make([]valuesLayer, 0, len(r.reserved))
+
+	for _, l := range layers {
+		if l.layer == LayerRelease || l.layer == LayerCapabilities {
+			reserved = append(reserved, l)
+
+			continue
+		}
+
+		values = mergeLayer(values, l)
+	}
+
+	if r.opts.ValuesTemplating {
+		var err error
+
+		values, err = holder.templateValues(values)
+		if err != nil {
+			return nil, fmt.Errorf(
+				"failed to template values for template pattern %q: %w",
+				holder.pathPattern(),
+				err,
+			)
+		}
+	}
+
+	if r.schema != nil {
+		if err := validateValues(r.schema, values); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, l := range reserved {
+		values = mergeLayer(values, l)
+	}
+
+	return values, nil
+}
+
+// mergeLayer deep merges the values of l over values. The WithSetValues pairs are instead
+// applied to a copy of values, like Helm does, so indexed pairs update list elements in place,
+// and the keys they set to null are unset.
+func mergeLayer(values map[string]any, l valuesLayer) map[string]any {
+	if l.layer != LayerSet {
+		return util.DeepMerge(values, l.values)
+	}
+
+	merged := copyValues(values)
+	for _, pair := range l.set {
+		// The pairs were parsed by New, so they cannot fail here
+		_ = applySetPair(merged, pair)
+	}
+
+	unsetNullValues(merged, l.values)
+
+	return merged
+}
+
+// renderSingle performs the rendering for a single template input, reporting whether the
+// result came from the render cache.
+func (r *Renderer) renderSingle(
+	ctx context.Context,
+	holder *sourceHolder,
+	renderTimeValues map[string]any,
+) (_ []unstructured.Unstructured, cached bool, err error) {
+	start := r.opts.Clock()
+
+	ctx, span := r.startSpan(ctx, SpanRender, holder)
+	defer func() {
+		endSpan(span, err)
+		r.metrics.observeRender(holder, r.opts.Clock().Sub(start), err)
+	}()
+
+	spec := TemplateSpec{
+		Name: holder.Name,
+		Path: holder.pathPattern(),
+		FS:   holder.fsID,
+	}
+
+	// Parse templates if not already parsed (thread-safe lazy loading)
+	templates, err := r.loadTemplates(ctx, holder)
+	if err != nil {
+		return nil, false, r.failRender(ctx, spec, err)
+	}
+
+	// Get values dynamically (includes render-time values)
+	values, err := r.values(ctx, holder, renderTimeValues)
+	if err != nil {
+		return nil, false, r.failRender(ctx, spec, fmt.Errorf(
+			"failed to get values for pattern %q: %w",
+			holder.pathPattern(),
+			err,
+		))
+	}
+
+	spec.Values = values
+
+	hooks := r.startRender(ctx, spec)
+	defer func() { hooks.Finish(ctx, cached, err) }()
+
+	// Check cache (if enabled for the Source)
+	cache := r.sourceCache(holder)
+	if cache != nil {
+		// ensure objects are evicted
+		cache.Sync()
+
+		hit, found := cache.Get(spec)
+		setCacheHit(span, found)
+		r.metrics.observeCache(holder, found)
+
+		if found {
+			holder.log.V(1).Info("render cache hit", "objects", len(hit))
+			return hit, true, nil
+		}
+
+		holder.log.V(1).Info("render cache miss")
+	}
+
+	result, err := r.execute(ctx, holder, templates, values, hooks.Output())
+	if err != nil {
+		return nil, false, err
+	}
+
+	// Values may carry secrets, so they are only logged when explicitly enabled
+	log := holder.log
+	if r.opts.VerboseValueLogging {
+		log = log.WithValues("values", values)
+	}
+
+	log.V(1).Info("rendered source", "objects", len(result), "duration", r.opts.Clock().Sub(start))
+
+	// Cache result (if enabled), unless the renderer was closed meanwhile
+	if cache != nil && !r.closed.Load() {
+		cache.Set(spec, result)
+	}
+
+	return result, false, nil
+}
+
+// sourceCache returns the render cache used for the input, nil when caching is disabled for
+// the renderer or, through Source.Cache, for the input.
+func (r *Renderer) sourceCache(holder *sourceHolder) *renderCache {
+	if holder.Cache != nil && !*holder.Cache {
+		return nil
+	}
+
+	return r.cache
+}
+
+// execute runs every template of a parsed set and decodes the output into decorated objects.
+// The output of the templates is also appended to raw, if not nil.
+func (r *Renderer) execute(
+	ctx context.Context,
+	holder *sourceHolder,
+	templates *template.Template,
+	values map[string]any,
+	raw *bytes.Buffer,
+) ([]unstructured.Unstructured, error) {
+	entries, err := holder.entryTemplates(templates)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]unstructured.Unstructured, 0)
+	index := 0
+
+	var buf bytes.Buffer
+
+	// Execute each template
+	for _, t := range entries {
+		// Check for context cancellation
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("template rendering cancelled: %w", err)
+		}
+
+		buf.Reset()
+
+		// Execute the template
+		if err := r.executeTemplate(ctx, holder, t, &buf, values); err != nil {
+			return nil, err
+		}
+
+		if raw != nil {
+			raw.Write(buf.Bytes())
+		}
+
+		// Validate the rendered documents when checks are enabled
+		if r.checksDocuments() {
+			for _, doc := range splitDocuments(buf.Bytes(), r.opts.KeepEmptyDocuments) {
+				if err := r.checkDocument(holder, t.Name(), index, doc); err != nil {
+					return nil, err
+				}
+
+				index++
+			}
+		}
+
+		// Decode the rendered output into unstructured objects
+		objs, err := k8s.DecodeYAML(buf.Bytes())
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode YAML from template %s: %w", t.Name(), err)
+		}
+
+		for i := range objs {
+			if err := r.decorate(holder, t.Name(), &objs[i]); err != nil {
+				return nil, fmt.Errorf("failed to decorate objects from template %s: %w", t.Name(), err)
+			}
+		}
+
+		result = append(result, objs...)
 	}
 
 	return result, nil
 }
+
+// decorate applies the renderer-level metadata to an object rendered by the template called name:
+// the configured namespace, common labels and annotations, and source annotations if enabled.
+func (r *Renderer) decorate(holder *sourceHolder, name string, obj *unstructured.Unstructured) error {
+	r.opts.setNamespace(obj)
+
+	if err := r.opts.setCommonMetadata(obj); err != nil {
+		return err
+	}
+
+	if r.opts.SourceAnnotations {
+		annotations := obj.GetAnnotations()
+		if annotations == nil {
+			annotations = make(map[string]string)
+		}
+
+		annotations[types.AnnotationSourceType] = rendererType
+		annotations[types.AnnotationSourcePath] = holder.pathPattern()
+		annotations[types.AnnotationSourceFile] = name
+
+		obj.SetAnnotations(annotations)
+	}
+
+	return nil
+}
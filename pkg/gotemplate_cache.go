@@ -1,29 +1,386 @@
 package gotemplate
 
 import (
+	"container/list"
+	"io/fs"
+	"maps"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
 	"github.com/k8s-manifest-kit/pkg/util/cache"
+	utilk8s "github.com/k8s-manifest-kit/pkg/util/k8s"
 
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
+const defaultCacheTTL = 5 * time.Minute
+
 // TemplateSpec contains the data used to generate cache keys for rendered templates.
 type TemplateSpec struct {
-	Path   string
+	// Name is the Source name, empty for unnamed Sources.
+	Name string
+
+	// Path is the Source patterns joined with ",".
+	Path string
+
+	// FS identifies the Source FS, so Sources sharing a path but reading different files never
+	// share results: the WithFSIdentity identity, or by default the position of the FS among the
+	// distinct FSes of the renderer Sources. Empty in specs passed to CacheKey = the FS of the
+	// first Source with the same Name and Path.
+	FS string
+
+	// Values are the merged values the templates are executed with.
 	Values any
 }
 
-// newCache creates a cache instance with GoTemplate-specific default KeyFunc.
-func newCache(opts *cache.Options) cache.Interface[[]unstructured.Unstructured] {
+// CacheStats is a point-in-time snapshot of render cache effectiveness.
+type CacheStats struct {
+	// Hits is the number of lookups served from the cache.
+	Hits uint64
+
+	// Misses is the number of lookups that found no valid entry, including expired ones.
+	Misses uint64
+
+	// Entries is the number of entries currently stored, including expired ones not yet evicted.
+	Entries int
+}
+
+// renderCache is a TTL-based render result cache with optional LRU bounding.
+// It implements cache.Interface and deep clones entries on get/set to prevent cache pollution.
+type renderCache struct {
+	// mu guards entries and lru. Get takes the write lock since it updates recency.
+	mu      sync.RWMutex
+	entries map[string]*list.Element
+	lru     *list.List
+
+	ttl        time.Duration
+	maxEntries int
+	keyFunc    func(any) string
+	now        func() time.Time
+
+	hits   atomic.Uint64
+	misses atomic.Uint64
+}
+
+type renderCacheEntry struct {
+	key        string
+	path       string
+	value      []unstructured.Unstructured
+	expiration time.Time
+}
+
+var _ cache.Interface[[]unstructured.Unstructured] = (*renderCache)(nil)
+
+// newCache creates a cache instance with GoTemplate-specific defaults.
+// Returns nil when caching is disabled.
+func newCache(
+	opts *cache.Options,
+	maxEntries int,
+	salt string,
+	now func() time.Time,
+) *renderCache {
 	if opts == nil {
 		return nil
 	}
 
-	co := *opts
+	c := &renderCache{
+		entries:    make(map[string]*list.Element),
+		lru:        list.New(),
+		ttl:        opts.TTL,
+		maxEntries: maxEntries,
+		keyFunc:    opts.KeyFunc,
+		now:        now,
+	}
+
+	if c.ttl <= 0 {
+		c.ttl = defaultCacheTTL
+	}
 
 	// Inject default KeyFunc for GoTemplate
-	if co.KeyFunc == nil {
-		co.KeyFunc = cache.DefaultKeyFunc
+	if c.keyFunc == nil {
+		c.keyFunc = cache.DefaultKeyFunc
+	}
+
+	if salt != "" {
+		c.keyFunc = saltKeyFunc(salt, c.keyFunc)
+	}
+
+	return c
+}
+
+// saltKeyFunc prefixes the keys produced by keyFunc with the quoted salt. Quoting keeps the
+// prefix unambiguous, so different salts never produce the same key.
+func saltKeyFunc(salt string, keyFunc func(any) string) func(any) string {
+	prefix := strconv.Quote(salt) + ":"
+
+	return func(key any) string {
+		return prefix + keyFunc(key)
+	}
+}
+
+// RedactedCacheKey returns a cache KeyFunc that keys render results like the default one, but
+// with the values at sensitivePaths (dotted paths into nested maps, e.g. "db.password") left
+// out of the TemplateSpec values, so secrets never end up in cache keys (CacheKey) while
+// renders differing in any other value are still cached apart:
+//
+//	gotemplate.WithCache(cache.WithKeyFunc(gotemplate.RedactedCacheKey("db.password")))
+//
+// The tradeoff is that renders differing only in redacted values share a cache entry, so a
+// changed secret is picked up once the entry expires (WithCacheTTL) or is invalidated
+// (InvalidatePath). Paths crossing non-map values are ignored.
+func RedactedCacheKey(sensitivePaths ...string) func(any) string {
+	paths := make([][]string, 0, len(sensitivePaths))
+	for _, p := range sensitivePaths {
+		paths = append(paths, strings.Split(p, "."))
+	}
+
+	return func(key any) string {
+		if spec, ok := key.(TemplateSpec); ok {
+			if values, ok := spec.Values.(map[string]any); ok {
+				for _, path := range paths {
+					values = redactPath(values, path)
+				}
+
+				spec.Values = values
+			}
+
+			key = spec
+		}
+
+		return cache.DefaultKeyFunc(key)
+	}
+}
+
+// redactPath returns values without the value at path, copying the maps along the path
+// rather than modifying the values being rendered.
+func redactPath(values map[string]any, path []string) map[string]any {
+	value, ok := values[path[0]]
+	if !ok {
+		return values
+	}
+
+	if len(path) == 1 {
+		result := maps.Clone(values)
+		delete(result, path[0])
+
+		return result
+	}
+
+	nested, ok := value.(map[string]any)
+	if !ok {
+		return values
+	}
+
+	result := maps.Clone(values)
+	result[path[0]] = redactPath(nested, path[1:])
+
+	return result
+}
+
+// Key returns the cache key for key, as used by Get and Set.
+func (c *renderCache) Key(key any) string {
+	return c.keyFunc(key)
+}
+
+// Get returns a deep clone of the cached result for key, if present and not expired.
+func (c *renderCache) Get(key any) ([]unstructured.Unstructured, bool) {
+	strKey := c.keyFunc(key)
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[strKey]
+	if !ok {
+		c.misses.Add(1)
+
+		return nil, false
+	}
+
+	e, _ := elem.Value.(*renderCacheEntry)
+	if c.now().After(e.expiration) {
+		c.misses.Add(1)
+
+		return nil, false
 	}
 
-	return cache.NewRenderCache(co)
+	c.lru.MoveToFront(elem)
+	c.hits.Add(1)
+
+	return utilk8s.DeepCloneUnstructuredSlice(e.value), true
+}
+
+// Set stores a deep clone of value for key, evicting the least recently used
+// entry when the cache is bounded and full.
+func (c *renderCache) Set(key any, value []unstructured.Unstructured) {
+	strKey := c.keyFunc(key)
+	entry := &renderCacheEntry{
+		key:        strKey,
+		value:      utilk8s.DeepCloneUnstructuredSlice(value),
+		expiration: c.now().Add(c.ttl),
+	}
+
+	// Remember the source path so entries can be invalidated per path
+	if spec, ok := key.(TemplateSpec); ok {
+		entry.path = spec.Path
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if elem, ok := c.entries[strKey]; ok {
+		elem.Value = entry
+		c.lru.MoveToFront(elem)
+
+		return
+	}
+
+	c.entries[strKey] = c.lru.PushFront(entry)
+
+	for c.maxEntries > 0 && c.lru.Len() > c.maxEntries {
+		c.removeElement(c.lru.Back())
+	}
+}
+
+// Sync removes all expired entries from the cache.
+func (c *renderCache) Sync() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := c.now()
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if e, _ := elem.Value.(*renderCacheEntry); now.After(e.expiration) {
+			c.removeElement(elem)
+		}
+		elem = prev
+	}
+}
+
+// Stats returns a snapshot of the cache counters.
+func (c *renderCache) Stats() CacheStats {
+	c.mu.RLock()
+	entries := len(c.entries)
+	c.mu.RUnlock()
+
+	return CacheStats{
+		Hits:    c.hits.Load(),
+		Misses:  c.misses.Load(),
+		Entries: entries,
+	}
+}
+
+// Clear removes all entries from the cache.
+func (c *renderCache) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.lru.Init()
+}
+
+// Delete removes the entry stored under the given (already computed) key.
+// Returns the source path of the removed entry and whether an entry was found.
+func (c *renderCache) Delete(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+
+	e, _ := elem.Value.(*renderCacheEntry)
+	c.removeElement(elem)
+
+	return e.path, true
+}
+
+// DeletePath removes all entries rendered from the given source path.
+func (c *renderCache) DeletePath(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for elem := c.lru.Back(); elem != nil; {
+		prev := elem.Prev()
+		if e, _ := elem.Value.(*renderCacheEntry); e.path == path {
+			c.removeElement(elem)
+		}
+		elem = prev
+	}
+}
+
+// removeElement drops elem from both the index and the recency list.
+// Must be called with mu held for writing.
+func (c *renderCache) removeElement(elem *list.Element) {
+	e, _ := elem.Value.(*renderCacheEntry)
+	delete(c.entries, e.key)
+	c.lru.Remove(elem)
+}
+
+// assignFSIdentities sets the FS identity of every holder: identity(FS) when set and not empty,
+// otherwise the position of the FS among the distinct FSes of the holders, so that the identities
+// are stable across restarts for the same Sources. FSes are compared by pointer for reference
+// types (maps, pointers, ...) and by value for other comparable types; FSes of incomparable types
+// are all considered distinct. The identities of the Source.Fallback FSes, if any, are appended,
+// since they supply files as well.
+func assignFSIdentities(holders []*sourceHolder, identity func(fs.FS) string) {
+	seen := make(map[any]string)
+
+	fsID := func(fsys fs.FS, owner any) string {
+		if identity != nil {
+			if id := identity(fsys); id != "" {
+				return "id:" + id
+			}
+		}
+
+		key := fsKey(fsys)
+		if key == nil {
+			key = owner
+		}
+
+		if _, ok := seen[key]; !ok {
+			seen[key] = strconv.Itoa(len(seen))
+		}
+
+		return seen[key]
+	}
+
+	for _, h := range holders {
+		ids := []string{fsID(h.FS, h)}
+		for _, f := range h.fallbacks {
+			ids = append(ids, fsID(f.FS, f))
+		}
+
+		h.fsID = strings.Join(ids, "+")
+	}
+}
+
+// fsKeyRef identifies an FS of a reference type by its type and pointer.
+type fsKeyRef struct {
+	t reflect.Type
+	p uintptr
+}
+
+// fsKey returns a comparable key identifying fsys, or nil if it cannot be compared.
+func fsKey(fsys fs.FS) any {
+	if fsys == nil {
+		return fsKeyRef{}
+	}
+
+	v := reflect.ValueOf(fsys)
+
+	switch v.Kind() {
+	case reflect.Map, reflect.Pointer, reflect.Slice, reflect.Func, reflect.Chan, reflect.UnsafePointer:
+		return fsKeyRef{t: v.Type(), p: v.Pointer()}
+	default:
+		if !v.Type().Comparable() {
+			return nil
+		}
+
+		return fsys
+	}
 }
@@ -0,0 +1,707 @@
+package gotemplate_test
+
+import (
+	"fmt"
+	"io/fs"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	"github.com/k8s-manifest-kit/pkg/util/cache"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const countingTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ count }}{{ .name }}
+`
+
+// newCountingRenderer creates a renderer whose template increments the returned
+// counter every time it is executed, making cache misses observable.
+func newCountingRenderer(t *testing.T, opts ...gotemplate.RendererOption) (*gotemplate.Renderer, *atomic.Int64) {
+	t.Helper()
+
+	executions := &atomic.Int64{}
+
+	opts = append(opts, gotemplate.WithFuncMap(template.FuncMap{
+		"count": func() string {
+			executions.Add(1)
+
+			return ""
+		},
+	}))
+
+	renderer, err := gotemplate.New(
+		[]gotemplate.Source{
+			{
+				FS: fstest.MapFS{
+					"template.yaml": &fstest.MapFile{Data: []byte(countingTemplate)},
+				},
+				Path: "*.yaml",
+			},
+		},
+		opts...,
+	)
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	return renderer, executions
+}
+
+func TestCacheMaxEntries(t *testing.T) {
+
+	t.Run("should evict least recently used entry on overflow", func(t *testing.T) {
+		g := NewWithT(t)
+		const maxEntries = 3
+
+		renderer, executions := newCountingRenderer(t,
+			gotemplate.WithCache(),
+			gotemplate.WithCacheMaxEntries(maxEntries),
+		)
+
+		// Insert n+1 distinct keys
+		for i := range maxEntries + 1 {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": fmt.Sprintf("app-%d", i)})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+		g.Expect(executions.Load()).To(Equal(int64(maxEntries + 1)))
+
+		// Most recent entries are still cached
+		for i := 1; i <= maxEntries; i++ {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": fmt.Sprintf("app-%d", i)})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+		g.Expect(executions.Load()).To(Equal(int64(maxEntries + 1)))
+
+		// Oldest entry has been evicted and must be rendered again
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "app-0"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("app-0"))
+		g.Expect(executions.Load()).To(Equal(int64(maxEntries + 2)))
+	})
+
+	t.Run("should refresh recency on cache hit", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, executions := newCountingRenderer(t,
+			gotemplate.WithCache(),
+			gotemplate.WithCacheMaxEntries(2),
+		)
+
+		render := func(name string) {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": name})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		render("a")
+		render("b")
+		render("a") // hit, "b" becomes least recently used
+		render("c") // evicts "b"
+		g.Expect(executions.Load()).To(Equal(int64(3)))
+
+		render("a")
+		g.Expect(executions.Load()).To(Equal(int64(3)))
+
+		render("b")
+		g.Expect(executions.Load()).To(Equal(int64(4)))
+	})
+
+	t.Run("should be unbounded by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, executions := newCountingRenderer(t, gotemplate.WithCache())
+
+		for range 2 {
+			for i := range 100 {
+				_, err := renderer.Process(t.Context(), map[string]any{"name": fmt.Sprintf("app-%d", i)})
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		}
+
+		g.Expect(executions.Load()).To(Equal(int64(100)))
+	})
+}
+
+func TestCacheStats(t *testing.T) {
+
+	t.Run("should report a miss followed by a hit for the same spec", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, gotemplate.WithCache())
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{Hits: 0, Misses: 1, Entries: 1}))
+
+		_, err = renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{Hits: 1, Misses: 1, Entries: 1}))
+	})
+
+	t.Run("should reflect key function choice", func(t *testing.T) {
+		g := NewWithT(t)
+
+		valuesKey, _ := newCountingRenderer(t, gotemplate.WithCache())
+		pathKey, _ := newCountingRenderer(t, gotemplate.WithCache(
+			cache.WithKeyFunc(func(key any) string {
+				return key.(gotemplate.TemplateSpec).Path
+			}),
+		))
+
+		for _, renderer := range []*gotemplate.Renderer{valuesKey, pathKey} {
+			for _, name := range []string{"a", "b", "c"} {
+				_, err := renderer.Process(t.Context(), map[string]any{"name": name})
+				g.Expect(err).ToNot(HaveOccurred())
+			}
+		}
+
+		g.Expect(valuesKey.Stats()).To(Equal(gotemplate.CacheStats{Hits: 0, Misses: 3, Entries: 3}))
+		g.Expect(pathKey.Stats()).To(Equal(gotemplate.CacheStats{Hits: 2, Misses: 1, Entries: 1}))
+	})
+
+	t.Run("should be accurate under concurrent renders", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, gotemplate.WithCache())
+
+		const workers = 8
+		const renders = 25
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for range renders {
+					_, _ = renderer.Process(t.Context(), map[string]any{"name": "app"})
+				}
+			}()
+		}
+		wg.Wait()
+
+		stats := renderer.Stats()
+		g.Expect(stats.Hits + stats.Misses).To(Equal(uint64(workers * renders)))
+		g.Expect(stats.Entries).To(Equal(1))
+	})
+
+	t.Run("should return zero stats when caching is disabled", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t)
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{}))
+	})
+}
+
+func TestCacheInvalidation(t *testing.T) {
+
+	configMap := func(value string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+data:
+  value: "` + value + `"`)}
+	}
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) (*gotemplate.Renderer, fstest.MapFS) {
+		t.Helper()
+
+		templateFS := fstest.MapFS{"template.yaml": configMap("v1")}
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: templateFS, Path: "*.yaml"},
+				{FS: fstest.MapFS{"other.yaml": configMap("other")}, Path: "other.yaml"},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer, templateFS
+	}
+
+	values := func(g Gomega, renderer *gotemplate.Renderer) []string {
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.Object["data"].(map[string]any)["value"].(string))
+		}
+
+		return result
+	}
+
+	t.Run("should re-parse templates after InvalidateCache", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, templateFS := newRenderer(t, gotemplate.WithCache())
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v1", "other"}))
+
+		templateFS["template.yaml"] = configMap("v2")
+		g.Expect(values(g, renderer)).To(Equal([]string{"v1", "other"}))
+
+		renderer.InvalidateCache()
+		g.Expect(renderer.Stats().Entries).To(Equal(0))
+		g.Expect(values(g, renderer)).To(Equal([]string{"v2", "other"}))
+	})
+
+	t.Run("should re-parse templates after InvalidateCache without caching", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, templateFS := newRenderer(t)
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v1", "other"}))
+
+		templateFS["template.yaml"] = configMap("v2")
+		renderer.InvalidateCache()
+		g.Expect(values(g, renderer)).To(Equal([]string{"v2", "other"}))
+	})
+
+	t.Run("should drop a single entry with InvalidateKey", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, templateFS := newRenderer(t, gotemplate.WithCache(
+			cache.WithKeyFunc(func(key any) string {
+				return key.(gotemplate.TemplateSpec).Path
+			}),
+		))
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v1", "other"}))
+		g.Expect(renderer.Stats().Entries).To(Equal(2))
+
+		templateFS["template.yaml"] = configMap("v2")
+		renderer.InvalidateKey("*.yaml")
+		g.Expect(renderer.Stats().Entries).To(Equal(1))
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v2", "other"}))
+		g.Expect(renderer.Stats().Hits).To(Equal(uint64(1)))
+	})
+
+	t.Run("should drop entries for a path with InvalidatePath", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, templateFS := newRenderer(t, gotemplate.WithCache())
+
+		_, err := renderer.Process(t.Context(), map[string]any{"unused": "a"})
+		g.Expect(err).ToNot(HaveOccurred())
+		_, err = renderer.Process(t.Context(), map[string]any{"unused": "b"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats().Entries).To(Equal(4))
+
+		templateFS["template.yaml"] = configMap("v2")
+		renderer.InvalidatePath("*.yaml")
+		g.Expect(renderer.Stats().Entries).To(Equal(2))
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v2", "other"}))
+	})
+
+	t.Run("should be safe to invalidate concurrently with renders", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newRenderer(t, gotemplate.WithCache())
+
+		var wg sync.WaitGroup
+		for i := range 4 {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				for range 20 {
+					_, _ = renderer.Process(t.Context(), map[string]any{"worker": i})
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				for range 20 {
+					renderer.InvalidatePath("*.yaml")
+					renderer.InvalidateCache()
+				}
+			}()
+		}
+		wg.Wait()
+
+		g.Expect(values(g, renderer)).To(Equal([]string{"v1", "other"}))
+	})
+}
+
+func TestCacheSalt(t *testing.T) {
+
+	spec := gotemplate.TemplateSpec{Path: "*.yaml", Values: map[string]any{"name": "app"}}
+
+	t.Run("should produce different keys for identical specs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		unsalted, _ := newCountingRenderer(t, gotemplate.WithCache())
+		tenantA, _ := newCountingRenderer(t, gotemplate.WithCache(), gotemplate.WithCacheSalt("tenant-a"))
+		tenantB, _ := newCountingRenderer(t, gotemplate.WithCache(), gotemplate.WithCacheSalt("tenant-b"))
+
+		keys := []string{unsalted.CacheKey(spec), tenantA.CacheKey(spec), tenantB.CacheKey(spec)}
+		g.Expect(keys).To(HaveEach(Not(BeEmpty())))
+		g.Expect(keys[0]).ToNot(Equal(keys[1]))
+		g.Expect(keys[0]).ToNot(Equal(keys[2]))
+		g.Expect(keys[1]).ToNot(Equal(keys[2]))
+	})
+
+	t.Run("should be deterministic", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first, _ := newCountingRenderer(t, gotemplate.WithCache(), gotemplate.WithCacheSalt("tenant-a"))
+		second, _ := newCountingRenderer(t, gotemplate.WithCache(), gotemplate.WithCacheSalt("tenant-a"))
+
+		g.Expect(first.CacheKey(spec)).To(Equal(second.CacheKey(spec)))
+	})
+
+	t.Run("should apply to custom key functions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		pathKey := cache.WithKeyFunc(func(key any) string {
+			return key.(gotemplate.TemplateSpec).Path
+		})
+
+		tenantA, _ := newCountingRenderer(t, gotemplate.WithCache(pathKey), gotemplate.WithCacheSalt("a:"))
+		tenantB, _ := newCountingRenderer(t, gotemplate.WithCache(pathKey), gotemplate.WithCacheSalt("a"))
+
+		g.Expect(tenantA.CacheKey(spec)).ToNot(Equal(tenantB.CacheKey(spec)))
+		g.Expect(tenantA.CacheKey(spec)).To(HaveSuffix("*.yaml"))
+	})
+
+	t.Run("should cache and invalidate under the salted key", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, executions := newCountingRenderer(t, gotemplate.WithCache(), gotemplate.WithCacheSalt("tenant-a"))
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": "app"})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+		g.Expect(executions.Load()).To(Equal(int64(1)))
+
+		renderer.InvalidateKey(renderer.CacheKey(spec))
+		g.Expect(renderer.Stats().Entries).To(Equal(0))
+	})
+
+	t.Run("should return an empty key when caching is disabled", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, gotemplate.WithCacheSalt("tenant-a"))
+
+		g.Expect(renderer.CacheKey(spec)).To(BeEmpty())
+	})
+}
+
+// countingFS counts how many times each file is opened.
+type countingFS struct {
+	fs.FS
+
+	mu    sync.Mutex
+	opens map[string]int
+}
+
+func (c *countingFS) Open(name string) (fs.File, error) {
+	c.mu.Lock()
+	c.opens[name]++
+	c.mu.Unlock()
+
+	return c.FS.Open(name)
+}
+
+func (c *countingFS) count(name string) int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.opens[name]
+}
+
+func TestRedactedCacheKey(t *testing.T) {
+
+	redacted := gotemplate.WithCache(cache.WithKeyFunc(gotemplate.RedactedCacheKey("db.password", "token")))
+
+	spec := func(password string, host string) gotemplate.TemplateSpec {
+		return gotemplate.TemplateSpec{Path: "*.yaml", Values: map[string]any{
+			"name":  "app",
+			"token": password,
+			"db":    map[string]any{"host": host, "password": password},
+		}}
+	}
+
+	t.Run("should ignore redacted values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, redacted)
+
+		key := renderer.CacheKey(spec("s3cr3t", "db-1"))
+		g.Expect(key).ToNot(BeEmpty())
+		g.Expect(key).ToNot(ContainSubstring("s3cr3t"))
+		g.Expect(renderer.CacheKey(spec("rotated", "db-1"))).To(Equal(key))
+	})
+
+	t.Run("should key on other values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, redacted)
+
+		g.Expect(renderer.CacheKey(spec("s3cr3t", "db-1"))).ToNot(Equal(renderer.CacheKey(spec("s3cr3t", "db-2"))))
+	})
+
+	t.Run("should not modify the rendered values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, _ := newCountingRenderer(t, redacted)
+
+		s := spec("s3cr3t", "db-1")
+		_ = renderer.CacheKey(s)
+		g.Expect(s.Values).To(HaveKeyWithValue("db", HaveKeyWithValue("password", "s3cr3t")))
+		g.Expect(s.Values).To(HaveKeyWithValue("token", "s3cr3t"))
+	})
+
+	t.Run("should serve renders differing in redacted values from the cache", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, executions := newCountingRenderer(t, redacted)
+
+		for _, token := range []string{"a", "b"} {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": "app", "token": token})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": "web", "token": "a"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(executions.Load()).To(Equal(int64(2)))
+	})
+}
+
+func TestNoCache(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) (*gotemplate.Renderer, *countingFS) {
+		t.Helper()
+
+		fsys := &countingFS{
+			FS: fstest.MapFS{
+				"template.yaml": &fstest.MapFile{Data: []byte(countingTemplate)},
+			},
+			opens: make(map[string]int),
+		}
+
+		opts = append(opts, gotemplate.WithFuncMap(template.FuncMap{"count": func() string { return "" }}))
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fsys,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer, fsys
+	}
+
+	t.Run("should parse templates once by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, fsys := newRenderer(t)
+
+		for range 3 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(fsys.count("template.yaml")).To(Equal(1))
+	})
+
+	t.Run("should re-parse templates on every render", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, fsys := newRenderer(t, gotemplate.WithNoCache())
+
+		for range 3 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(fsys.count("template.yaml")).To(Equal(3))
+	})
+
+	t.Run("should bypass the render cache", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, fsys := newRenderer(t, gotemplate.WithCache(), gotemplate.WithNoCache())
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(fsys.count("template.yaml")).To(Equal(2))
+		g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{}))
+		g.Expect(renderer.CacheKey(gotemplate.TemplateSpec{Path: "*.yaml"})).To(BeEmpty())
+	})
+
+	t.Run("should be safe for concurrent renders", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, fsys := newRenderer(t, gotemplate.WithNoCache())
+
+		const workers = 8
+
+		var wg sync.WaitGroup
+		errs := make(chan error, workers)
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := renderer.Process(t.Context(), nil); err != nil {
+					errs <- err
+				}
+			}()
+		}
+		wg.Wait()
+		close(errs)
+
+		g.Expect(errs).To(BeEmpty())
+		g.Expect(fsys.count("template.yaml")).To(Equal(workers))
+	})
+}
+
+func TestSourceCache(t *testing.T) {
+	g := NewWithT(t)
+
+	const namedTemplate = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ count .name }}{{ .name }}\n"
+
+	executions := make(map[string]int)
+	var mu sync.Mutex
+
+	source := func(name string, enabled *bool) gotemplate.Source {
+		return gotemplate.Source{
+			Name: name,
+			FS: fstest.MapFS{
+				"template.yaml": &fstest.MapFile{Data: []byte(namedTemplate)},
+			},
+			Path:   "*.yaml",
+			Values: gotemplate.Values(map[string]any{"name": name}),
+			Cache:  enabled,
+		}
+	}
+
+	disabled := false
+
+	renderer, err := gotemplate.New(
+		[]gotemplate.Source{source("static", nil), source("dynamic", &disabled)},
+		gotemplate.WithCache(),
+		gotemplate.WithFuncMap(template.FuncMap{
+			"count": func(name string) string {
+				mu.Lock()
+				defer mu.Unlock()
+
+				executions[name]++
+
+				return ""
+			},
+		}),
+	)
+	g.Expect(err).ToNot(HaveOccurred())
+
+	for range 3 {
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	}
+
+	g.Expect(executions).To(Equal(map[string]int{"static": 1, "dynamic": 3}))
+
+	g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{Hits: 2, Misses: 1, Entries: 1}))
+}
+
+func TestFSIdentity(t *testing.T) {
+
+	templateFS := func(kind string) fstest.MapFS {
+		return fstest.MapFS{
+			"app.tmpl": &fstest.MapFile{
+				Data: []byte("apiVersion: v1\nkind: " + kind + "\nmetadata:\n  name: {{ .name }}\n"),
+			},
+		}
+	}
+
+	kinds := func(g Gomega, renderer *gotemplate.Renderer) []string {
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.GetKind())
+		}
+
+		return result
+	}
+
+	t.Run("should not share results across FSes with the same path", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: templateFS("ConfigMap"), Path: "*.tmpl"},
+				{FS: templateFS("Secret"), Path: "*.tmpl"},
+			},
+			gotemplate.WithCache(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			g.Expect(kinds(g, renderer)).To(Equal([]string{"ConfigMap", "Secret"}))
+		}
+
+		g.Expect(renderer.Stats().Entries).To(Equal(2))
+	})
+
+	t.Run("should share results for the same FS", func(t *testing.T) {
+		g := NewWithT(t)
+		fsys := templateFS("ConfigMap")
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: fsys, Path: "*.tmpl"},
+				{FS: fsys, Path: "*.tmpl"},
+			},
+			gotemplate.WithCache(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(kinds(g, renderer)).To(Equal([]string{"ConfigMap", "ConfigMap"}))
+		g.Expect(renderer.Stats().Entries).To(Equal(1))
+	})
+
+	t.Run("should identify FSes with a custom identity", func(t *testing.T) {
+		g := NewWithT(t)
+		first := templateFS("ConfigMap")
+		second := templateFS("Secret")
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: first, Path: "*.tmpl"},
+				{FS: second, Path: "*.tmpl"},
+			},
+			gotemplate.WithCache(),
+			gotemplate.WithFSIdentity(func(fs.FS) string { return "bundle" }),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// Equal identities promise the same files, so the second Source reuses the first result
+		g.Expect(kinds(g, renderer)).To(Equal([]string{"ConfigMap", "ConfigMap"}))
+		g.Expect(renderer.CacheKey(gotemplate.TemplateSpec{Path: "*.tmpl", Values: map[string]any{"name": "app"}})).
+			To(Equal(renderer.CacheKey(gotemplate.TemplateSpec{
+				Path:   "*.tmpl",
+				FS:     "id:bundle",
+				Values: map[string]any{"name": "app"},
+			})))
+	})
+
+	t.Run("should keep keys stable across renderers", func(t *testing.T) {
+		g := NewWithT(t)
+		spec := gotemplate.TemplateSpec{Path: "*.tmpl", Values: map[string]any{"name": "app"}}
+
+		keys := make([]string, 0, 2)
+		for _, kind := range []string{"ConfigMap", "Secret"} {
+			renderer, err := gotemplate.New(
+				[]gotemplate.Source{{FS: templateFS(kind), Path: "*.tmpl"}},
+				gotemplate.WithCache(),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			keys = append(keys, renderer.CacheKey(spec))
+		}
+
+		g.Expect(keys[0]).To(Equal(keys[1]))
+	})
+}
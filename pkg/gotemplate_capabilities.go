@@ -0,0 +1,83 @@
+package gotemplate
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// CapabilitiesInfo describes the target cluster, exposed to templates as .Capabilities
+// (WithCapabilities), mirroring the Helm capabilities object.
+type CapabilitiesInfo struct {
+	// KubeVersion is the Kubernetes version, e.g. "v1.29.3" or "1.29". Empty = unknown.
+	KubeVersion string
+
+	// APIVersions lists the API versions the cluster serves, e.g. "batch/v1".
+	APIVersions []string
+}
+
+// KubeVersion is the Kubernetes version exposed to templates as .Capabilities.KubeVersion.
+type KubeVersion struct {
+	// Version is the normalized version, e.g. "v1.29.3", for semverCompare.
+	Version string
+
+	// Major is the major version, e.g. "1".
+	Major string
+
+	// Minor is the minor version, e.g. "29".
+	Minor string
+
+	// GitVersion is the version as reported by the cluster, alias of Version.
+	GitVersion string
+}
+
+// String returns the normalized version, so {{ .Capabilities.KubeVersion }} prints it.
+func (kv KubeVersion) String() string {
+	return kv.Version
+}
+
+// VersionSet is the API versions exposed to templates as .Capabilities.APIVersions.
+type VersionSet []string
+
+// Has reports whether the set contains apiVersion: {{ if .Capabilities.APIVersions.Has "batch/v1" }}.
+func (vs VersionSet) Has(apiVersion string) bool {
+	return slices.Contains(vs, apiVersion)
+}
+
+// parseKubeVersion parses and normalizes a Kubernetes version; an empty version is unknown.
+func parseKubeVersion(version string) (KubeVersion, error) {
+	if version == "" {
+		return KubeVersion{}, nil
+	}
+
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return KubeVersion{}, fmt.Errorf("%w %q: %w", ErrInvalidKubeVersion, version, err)
+	}
+
+	normalized := "v" + v.String()
+
+	return KubeVersion{
+		Version:    normalized,
+		Major:      strconv.FormatUint(v.Major(), 10),
+		Minor:      strconv.FormatUint(v.Minor(), 10),
+		GitVersion: normalized,
+	}, nil
+}
+
+// values returns the values exposing the capabilities under the Capabilities key.
+func (ci CapabilitiesInfo) values() (map[string]any, error) {
+	kubeVersion, err := parseKubeVersion(ci.KubeVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]any{
+		"Capabilities": map[string]any{
+			"KubeVersion": kubeVersion,
+			"APIVersions": VersionSet(slices.Clone(ci.APIVersions)),
+		},
+	}, nil
+}
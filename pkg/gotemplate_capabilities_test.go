@@ -0,0 +1,256 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const capabilitiesTemplate = `{{- if .Capabilities.APIVersions.Has "policy/v1" }}
+apiVersion: policy/v1
+{{- else }}
+apiVersion: policy/v1beta1
+{{- end }}
+kind: PodDisruptionBudget
+metadata:
+  name: web
+  annotations:
+    kube-version: {{ .Capabilities.KubeVersion | quote }}
+    kube-minor: {{ .Capabilities.KubeVersion.Minor | quote }}
+spec:
+  {{- if semverCompare ">=1.27-0" .Capabilities.KubeVersion.Version }}
+  unhealthyPodEvictionPolicy: AlwaysAllow
+  {{- end }}
+  minAvailable: 1
+`
+
+func TestCapabilities(t *testing.T) {
+
+	render := func(t *testing.T, info gotemplate.CapabilitiesInfo) (map[string]any, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:   fstest.MapFS{"pdb.yaml": &fstest.MapFile{Data: []byte(capabilitiesTemplate)}},
+					Path: "*.yaml",
+				},
+			},
+			gotemplate.WithCapabilities(info),
+		)
+		if err != nil {
+			return nil, err
+		}
+
+		objects, err := renderer.Process(t.Context(), nil)
+		if err != nil {
+			return nil, err
+		}
+
+		NewWithT(t).Expect(objects).To(HaveLen(1))
+
+		return objects[0].Object, nil
+	}
+
+	t.Run("should branch on the cluster capabilities", func(t *testing.T) {
+		g := NewWithT(t)
+
+		object, err := render(t, gotemplate.CapabilitiesInfo{
+			KubeVersion: "v1.29.3-gke.1200",
+			APIVersions: []string{"v1", "policy/v1"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(object).To(jqmatcher.Match(`.apiVersion == "policy/v1"`))
+		g.Expect(object).To(jqmatcher.Match(`.spec.unhealthyPodEvictionPolicy == "AlwaysAllow"`))
+		g.Expect(object).To(jqmatcher.Match(`.metadata.annotations["kube-version"] == "v1.29.3-gke.1200"`))
+		g.Expect(object).To(jqmatcher.Match(`.metadata.annotations["kube-minor"] == "29"`))
+	})
+
+	t.Run("should render for older clusters", func(t *testing.T) {
+		g := NewWithT(t)
+
+		object, err := render(t, gotemplate.CapabilitiesInfo{
+			KubeVersion: "1.20",
+			APIVersions: []string{"v1", "policy/v1beta1"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(object).To(jqmatcher.Match(`.apiVersion == "policy/v1beta1"`))
+		g.Expect(object).To(jqmatcher.Match(`.spec | has("unhealthyPodEvictionPolicy") | not`))
+		g.Expect(object).To(jqmatcher.Match(`.metadata.annotations["kube-version"] == "v1.20.0"`))
+	})
+
+	t.Run("should reject invalid Kubernetes versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, gotemplate.CapabilitiesInfo{KubeVersion: "latest"})
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidKubeVersion))
+	})
+
+	t.Run("should keep capabilities out of schema validation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:   fstest.MapFS{"pdb.yaml": &fstest.MapFile{Data: []byte(capabilitiesTemplate)}},
+					Path: "*.yaml",
+				},
+			},
+			gotemplate.WithCapabilities(gotemplate.CapabilitiesInfo{KubeVersion: "1.29", APIVersions: []string{"policy/v1"}}),
+			gotemplate.WithReleaseInfo(gotemplate.ReleaseInfo{Name: "web"}),
+			gotemplate.WithValuesSchema([]byte(`{"type": "object", "additionalProperties": false}`)),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.apiVersion == "policy/v1"`))
+	})
+
+	t.Run("should not report capabilities as unused values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fstest.MapFS{"cm.yaml": &fstest.MapFile{Data: []byte("name: {{ .name }}\n")}},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "web"}),
+				},
+			},
+			gotemplate.WithCapabilities(gotemplate.CapabilitiesInfo{KubeVersion: "1.29"}),
+			gotemplate.WithReleaseInfo(gotemplate.ReleaseInfo{Name: "web"}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		report, err := renderer.Lint(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(report.HasFindings()).To(BeFalse())
+	})
+}
+
+func TestSemverCompareFunction(t *testing.T) {
+
+	render := func(t *testing.T, constraint string, version string) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(`{{ semverCompare .constraint .version }}`)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(map[string]any{"constraint": constraint, "version": version}),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should evaluate constraints", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cases := []struct {
+			constraint string
+			version    string
+			expected   string
+		}{
+			{">=1.28", "v1.29.3", "true"},
+			{">= 1.28", "1.27.9", "false"},
+			{"<1.25.0", "1.24.17", "true"},
+			{"1.29", "1.29.8", "true"},
+			{"=1.29.0", "1.29.1", "false"},
+			{"!=1.29", "1.30.0", "true"},
+			{">1.28", "1.28.5", "false"},
+			{"<=1.28", "1.28.5", "true"},
+			{"1.x", "1.31.0", "true"},
+			{"~1.28.2", "1.28.9", "true"},
+			{"~1.28.2", "1.29.0", "false"},
+			{"^1.2", "1.9.0", "true"},
+			{"^0.2.3", "0.3.0", "false"},
+			{">=1.20, <1.25", "1.22.0", "true"},
+			{">=1.20 <1.25", "1.25.0", "false"},
+			{"<1.20 || >=1.28", "1.28.0", "true"},
+			{">=1.28", "v1.29.3-gke.1200", "false"},
+			{">=1.28-0", "v1.29.3-gke.1200", "true"},
+			{">=1.29.0-rc.2", "1.29.0-rc.10", "true"},
+		}
+
+		for _, c := range cases {
+			out, err := render(t, c.constraint, c.version)
+			g.Expect(err).ToNot(HaveOccurred(), c.constraint)
+			g.Expect(out).To(Equal(c.expected), "%s %s", c.constraint, c.version)
+		}
+	})
+
+	t.Run("should fail the render on invalid input", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, ">=1.28", "latest")
+		g.Expect(err).To(MatchError(ContainSubstring(`invalid semantic version "latest"`)))
+
+		for _, constraint := range []string{">=", "<>1.2", "1.2.3.4"} {
+			_, err := render(t, constraint, "1.28.0")
+			g.Expect(err).To(MatchError(ContainSubstring(`invalid constraint %q`, constraint)), constraint)
+		}
+	})
+}
+
+func TestSemverFunction(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, version string) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fstest.MapFS{"template.tpl": &fstest.MapFile{Data: []byte(tmpl)}},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(map[string]any{"version": version}),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should expose the version parts", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t,
+			`{{ with semver .version }}{{ .Major }} {{ .Minor }} {{ .Patch }} {{ .Prerelease }} {{ . }}{{ end }}`,
+			"v1.29.3-gke.1200",
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("1 29 3 gke.1200 1.29.3-gke.1200"))
+	})
+
+	t.Run("should compare versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ (semver .version).LessThan (semver "1.30") }}`, "1.29")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("true"))
+	})
+
+	t.Run("should fail the render on invalid versions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ semver .version }}`, "1.x")
+		g.Expect(err).To(MatchError(ContainSubstring(`invalid semantic version "1.x"`)))
+	})
+}
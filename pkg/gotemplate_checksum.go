@@ -0,0 +1,73 @@
+package gotemplate
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// checksums renders the templates referenced by checksumOf during one template execution,
+// with the data of that execution, and remembers their checksums.
+type checksums struct {
+	holder    *sourceHolder
+	budget    *outputBudget
+	templates *template.Template
+	data      any
+
+	// Functions bound to every execution, checksumOf included
+	funcs template.FuncMap
+
+	// Checksums of the templates rendered so far, by template name
+	sums map[string]string
+
+	// Templates being rendered, outermost first, to detect cyclic references
+	pending []string
+}
+
+// checksumOf returns the SHA-256 of the output of the named template of the Source, rendered
+// with the values of the current execution; the same as {{ include name . | sha256sum }},
+// but available from templates executed with a different context and failing with
+// ErrChecksumCycle on templates referencing each other's checksum.
+func (c *checksums) checksumOf(name string) (string, error) {
+	if sum, ok := c.sums[name]; ok {
+		return sum, nil
+	}
+
+	if slices.Contains(c.pending, name) {
+		return "", fmt.Errorf("%w: %s", ErrChecksumCycle, strings.Join(append(slices.Clone(c.pending), name), " -> "))
+	}
+
+	t := c.templates.Lookup(name)
+	if t == nil || name == "" {
+		return "", &TemplateNotFoundError{
+			Name:      name,
+			Available: templateNames(c.templates),
+		}
+	}
+
+	c.pending = append(c.pending, name)
+	defer func() { c.pending = c.pending[:len(c.pending)-1] }()
+
+	bound, err := c.holder.bind(t, c.funcs, c.budget)
+	if err != nil {
+		return "", err
+	}
+
+	var buf bytes.Buffer
+	if err := bound.Execute(c.budget.writer(&buf), c.data); err != nil {
+		return "", fmt.Errorf("failed to render template %s for checksumOf: %w", name, err)
+	}
+
+	sum := sha256sum(buf.String())
+	c.sums[name] = sum
+
+	return sum, nil
+}
+
+// unboundChecksumOf is the checksumOf function attached at parse time. Executions rebind it
+// (see executionTemplate), so it is only reached from values templating and Templates.
+func unboundChecksumOf(name string) (string, error) {
+	return "", fmt.Errorf("%w: checksumOf %q", ErrChecksumUnavailable, name)
+}
@@ -0,0 +1,153 @@
+package gotemplate_test
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const checksumConfigMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}-config
+data:
+  level: {{ .level }}
+`
+
+const checksumDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+spec:
+  template:
+    metadata:
+      annotations:
+        checksum/config: {{ checksumOf "configmap.yaml" }}
+`
+
+func TestChecksums(t *testing.T) {
+
+	newRenderer := func(t *testing.T, files fstest.MapFS) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "web", "level": "info"}),
+				},
+			},
+			gotemplate.WithChecksums(),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	checksum := func(s string) string {
+		sum := sha256.Sum256([]byte(s))
+
+		return hex.EncodeToString(sum[:])
+	}
+
+	files := fstest.MapFS{
+		"configmap.yaml":  &fstest.MapFile{Data: []byte(checksumConfigMapTemplate)},
+		"deployment.yaml": &fstest.MapFile{Data: []byte(checksumDeploymentTemplate)},
+	}
+
+	t.Run("should annotate the checksum of a sibling ConfigMap", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, files).Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		expected := checksum("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web-config\ndata:\n  level: info\n")
+		g.Expect(objects[1].Object).To(jqmatcher.Match(
+			`.spec.template.metadata.annotations["checksum/config"] == "%s"`,
+			expected,
+		))
+	})
+
+	t.Run("should change the checksum with the ConfigMap values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, files)
+
+		before, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		after, err := renderer.Process(t.Context(), map[string]any{"level": "debug"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(after[1].GetName()).To(Equal(before[1].GetName()))
+		g.Expect(after[1].Object).ToNot(Equal(before[1].Object))
+	})
+
+	t.Run("should reject cyclic checksum references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cyclic := fstest.MapFS{
+			"a.yaml": &fstest.MapFile{Data: []byte(`# {{ checksumOf "b.yaml" }}`)},
+			"b.yaml": &fstest.MapFile{Data: []byte(`# {{ checksumOf "a.yaml" }}`)},
+		}
+
+		_, err := newRenderer(t, cyclic).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrChecksumCycle))
+		g.Expect(err).To(MatchError(ContainSubstring("a.yaml -> b.yaml -> a.yaml")))
+	})
+
+	t.Run("should reject self references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		self := fstest.MapFS{
+			"a.yaml": &fstest.MapFile{Data: []byte(`# {{ checksumOf "a.yaml" }}`)},
+		}
+
+		_, err := newRenderer(t, self).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrChecksumCycle))
+	})
+
+	t.Run("should fail for unknown templates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		missing := fstest.MapFS{
+			"a.yaml": &fstest.MapFile{Data: []byte(`# {{ checksumOf "missing.yaml" }}`)},
+		}
+
+		_, err := newRenderer(t, missing).Process(t.Context(), nil)
+
+		var notFound *gotemplate.TemplateNotFoundError
+		g.Expect(errors.As(err, &notFound)).To(BeTrue())
+		g.Expect(notFound.Name).To(Equal("missing.yaml"))
+	})
+
+	t.Run("should fail in templated values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": `{{ checksumOf "configmap.yaml" }}`, "level": "info"}),
+				},
+			},
+			gotemplate.WithChecksums(),
+			gotemplate.WithValuesTemplating(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrChecksumUnavailable))
+	})
+}
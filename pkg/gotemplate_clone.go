@@ -0,0 +1,85 @@
+package gotemplate
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Clone returns a new Renderer for the same Sources, configured with the options of r followed
+// by opts, e.g. to derive variants of a base renderer differing in namespace or default values.
+// Later options win as in New. The clone is independent of r: it has its own render cache,
+// watcher and lifecycle, so neither rendering with nor closing one affects the other.
+//
+// Templates already parsed by r are shared with the clone when opts leave parsing unchanged,
+// so the clone does not re-parse them; parsed template sets are never modified, which keeps
+// sharing them safe. Options affecting parsing (WithDelimiters, WithFuncMap, WithSprigFunctions,
+// WithChecksums, WithNormalizeLineEndings, WithPassthrough, WithOverlayFS, WithLookupFunc,
+// WithImageResolver, WithMissingKeyMode, WithMaxIncludeDepth, WithLayout and WithNoCache) make
+// the clone parse its templates afresh, and so does watching (WithWatch), as the watcher of the
+// clone only detects changes made after Clone. All other options are cache-safe. Templates
+// parsed by either renderer after Clone are not shared.
+func (r *Renderer) Clone(opts ...RendererOption) (*Renderer, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	clone, err := New(r.sources, append(slices.Clone(r.options), opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone renderer: %w", err)
+	}
+
+	delta := RendererOptions{}
+	for _, opt := range opts {
+		opt.ApplyTo(&delta)
+	}
+
+	// A watching clone only detects changes made after New, so it must not start from older parses
+	if !sharesParsing(r.opts, clone.opts, delta) || clone.opts.WatchInterval > 0 {
+		return clone, nil
+	}
+
+	// The shared templates bind the namespace function to the namespace of r
+	_, userNamespace := clone.opts.FuncMap["namespace"]
+	rebindNamespace := clone.opts.Namespace != r.opts.Namespace && !userNamespace
+
+	// New orders holders the same way for the same Sources
+	for i, h := range r.inputs {
+		h.mu.RLock()
+		clone.inputs[i].templates = h.templates
+		clone.inputs[i].loadedAt = h.loadedAt
+		h.mu.RUnlock()
+
+		if rebindNamespace {
+			clone.inputs[i].namespace = clone.opts.Namespace
+		}
+	}
+
+	return clone, nil
+}
+
+// sharesParsing reports whether templates parsed with the base options can be used with the
+// derived ones, obtained by applying the delta options over them. Functions are bound to the
+// template sets at parse time and cannot be compared, so any function option in the delta
+// prevents sharing, and so does an overlay FS.
+func sharesParsing(base RendererOptions, derived RendererOptions, delta RendererOptions) bool {
+	switch {
+	case len(delta.FuncMap) > 0, delta.LookupFunc != nil, delta.ImageResolver != nil, delta.OverlayFS != nil:
+		return false
+	case base.SprigFunctions != derived.SprigFunctions, base.Checksums != derived.Checksums,
+		base.KeepLineEndings != derived.KeepLineEndings:
+		return false
+	case !slices.Equal(base.PassthroughPaths, derived.PassthroughPaths):
+		return false
+	case base.MissingKeyMode != derived.MissingKeyMode, base.Layout != derived.Layout,
+		base.MaxIncludeDepth != derived.MaxIncludeDepth:
+		return false
+	case base.NoCache || derived.NoCache:
+		return false
+	}
+
+	if base.Delimiters == nil || derived.Delimiters == nil {
+		return base.Delimiters == derived.Delimiters
+	}
+
+	return *base.Delimiters == *derived.Delimiters
+}
@@ -0,0 +1,117 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestClone(t *testing.T) {
+
+	newRenderer := func(t *testing.T, fsys fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fsys,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	configMapFS := func() fstest.MapFS {
+		return fstest.MapFS{
+			"configmap.yaml": &fstest.MapFile{Data: []byte(remoteTemplate)},
+		}
+	}
+
+	t.Run("should not affect the original renderer", func(t *testing.T) {
+		g := NewWithT(t)
+		base := newRenderer(t, configMapFS(), gotemplate.WithNamespace("base"), gotemplate.WithCache())
+
+		clone, err := base.Clone(gotemplate.WithNamespace("variant"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := clone.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.namespace == "variant"`))
+
+		objects, err = base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.namespace == "base"`))
+
+		g.Expect(clone.Close()).To(Succeed())
+
+		_, err = base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should share parsed templates when parsing is unchanged", func(t *testing.T) {
+		g := NewWithT(t)
+		fsys := configMapFS()
+		base := newRenderer(t, fsys)
+
+		_, err := base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		// Parsed templates are reused, so the change is only seen by fresh parses
+		fsys["configmap.yaml"] = &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: x\n")}
+
+		shared, err := base.Clone(gotemplate.WithNamespace("variant"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := shared.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetKind()).To(Equal("ConfigMap"))
+
+		fresh, err := base.Clone(gotemplate.WithMissingKeyMode(gotemplate.MissingKeyZero))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err = fresh.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetKind()).To(Equal("Secret"))
+	})
+
+	t.Run("should parse afresh with different delimiters", func(t *testing.T) {
+		g := NewWithT(t)
+		base := newRenderer(t, fstest.MapFS{
+			"configmap.yaml": &fstest.MapFile{
+				Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: '[[ .name ]]'\n"),
+			},
+		})
+
+		objects, err := base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetName()).To(Equal("[[ .name ]]"))
+
+		clone, err := base.Clone(gotemplate.WithDelimiters("[[", "]]"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err = clone.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].GetName()).To(Equal("app"))
+	})
+
+	t.Run("should fail on closed renderers", func(t *testing.T) {
+		g := NewWithT(t)
+		base := newRenderer(t, configMapFS())
+		g.Expect(base.Close()).To(Succeed())
+
+		_, err := base.Clone()
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+	})
+}
@@ -0,0 +1,87 @@
+package gotemplate
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// resourceIdentity identifies a rendered object as applied to a cluster.
+type resourceIdentity struct {
+	apiVersion string
+	kind       string
+	namespace  string
+	name       string
+}
+
+// identities records the Sources rendering every resource identity, for WithDetectDuplicates.
+// A nil *identities records nothing, so callers need not check whether detection is enabled.
+type identities struct {
+	order   []resourceIdentity
+	sources map[resourceIdentity][]string
+}
+
+// newIdentities returns the identities recorder of a render, nil unless WithDetectDuplicates is set.
+func (r *Renderer) newIdentities() *identities {
+	if !r.opts.DetectDuplicates {
+		return nil
+	}
+
+	return &identities{
+		order:   make([]resourceIdentity, 0),
+		sources: make(map[resourceIdentity][]string),
+	}
+}
+
+// add records the identities of objects rendered by a Source. Objects without apiVersion, kind
+// or name are not manifests and are ignored.
+func (d *identities) add(holder *sourceHolder, objects ...*unstructured.Unstructured) {
+	if d == nil {
+		return
+	}
+
+	for _, obj := range objects {
+		id := resourceIdentity{
+			apiVersion: obj.GetAPIVersion(),
+			kind:       obj.GetKind(),
+			namespace:  obj.GetNamespace(),
+			name:       obj.GetName(),
+		}
+
+		if id.apiVersion == "" || id.kind == "" || id.name == "" {
+			continue
+		}
+
+		if _, seen := d.sources[id]; !seen {
+			d.order = append(d.order, id)
+		}
+
+		d.sources[id] = append(d.sources[id], holder.describe())
+	}
+}
+
+// Err returns a *DuplicateError listing the identities rendered more than once, in the order
+// they were first rendered, or nil if there are none.
+func (d *identities) Err() error {
+	if d == nil {
+		return nil
+	}
+
+	duplicates := make([]DuplicateResource, 0)
+
+	for _, id := range d.order {
+		if sources := d.sources[id]; len(sources) > 1 {
+			duplicates = append(duplicates, DuplicateResource{
+				APIVersion: id.apiVersion,
+				Kind:       id.kind,
+				Namespace:  id.namespace,
+				Name:       id.name,
+				Sources:    sources,
+			})
+		}
+	}
+
+	if len(duplicates) == 0 {
+		return nil
+	}
+
+	return &DuplicateError{Duplicates: duplicates}
+}
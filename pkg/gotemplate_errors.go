@@ -0,0 +1,280 @@
+package gotemplate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrRendererClosed is returned by rendering methods called after Close.
+	ErrRendererClosed = errors.New("renderer is closed")
+
+	// ErrInvalidDelimiters is returned when custom template delimiters are empty or identical.
+	ErrInvalidDelimiters = errors.New("template delimiters must be non-empty and distinct")
+
+	// ErrInvalidMissingKeyMode is returned when an unsupported MissingKeyMode is configured.
+	ErrInvalidMissingKeyMode = errors.New("invalid missing key mode")
+
+	// ErrInvalidOutputFormat is returned when an unsupported OutputFormat is configured.
+	ErrInvalidOutputFormat = errors.New("invalid output format")
+
+	// ErrInvalidValuesMode is returned when a Source has an unsupported ValuesMode.
+	ErrInvalidValuesMode = errors.New("invalid values mode")
+
+	// ErrProvenanceDisabled is returned by RenderWithProvenance without WithValueProvenance.
+	ErrProvenanceDisabled = errors.New("value provenance tracking is disabled")
+
+	// ErrSourceNotFound is returned by Templates when no Source has the requested name.
+	ErrSourceNotFound = errors.New("source not found")
+
+	// ErrInvalidTemplateName is returned by NewFromString when the template name is not a valid path.
+	ErrInvalidTemplateName = errors.New("invalid template name")
+
+	// ErrNoMatchingTemplates is returned when a Source pattern matches no files.
+	ErrNoMatchingTemplates = errors.New("pattern matches no files")
+
+	// ErrDuplicateTemplate is returned when two templates resolve to the same name.
+	ErrDuplicateTemplate = errors.New("duplicate template name")
+
+	// ErrRequiredValue is returned by the required template function when its value is nil or empty.
+	ErrRequiredValue = errors.New("required value missing")
+
+	// ErrRenderTimeout is returned when a template execution exceeds the WithRenderTimeout duration.
+	ErrRenderTimeout = errors.New("render timeout exceeded")
+
+	// ErrOutputTooLarge is returned when a render produces more than WithMaxOutputBytes bytes.
+	ErrOutputTooLarge = errors.New("rendered output too large")
+
+	// ErrInvalidYAML is returned by WithStrictYAML for rendered documents that are not strictly valid YAML.
+	ErrInvalidYAML = errors.New("invalid rendered YAML")
+
+	// ErrMissingGVK is returned by WithRequireGVK for rendered documents without apiVersion or kind.
+	ErrMissingGVK = errors.New("rendered document is not a Kubernetes manifest")
+
+	// ErrValuesCycle is returned by WithValuesTemplating when values reference each other in a cycle.
+	ErrValuesCycle = errors.New("cyclic values reference")
+
+	// ErrChecksumCycle is returned by the checksumOf template function when templates reference
+	// each other's checksum in a cycle.
+	ErrChecksumCycle = errors.New("cyclic checksum reference")
+
+	// ErrChecksumUnavailable is returned by the checksumOf template function outside of template
+	// executions, e.g. in templated values.
+	ErrChecksumUnavailable = errors.New("checksum is only available in templates")
+
+	// ErrMaxDepthExceeded is returned when nested template evaluation exceeds the allowed depth.
+	ErrMaxDepthExceeded = errors.New("maximum template nesting depth exceeded")
+
+	// ErrInvalidFilePath is returned by the file reading template functions for absolute paths
+	// and paths escaping the Source FS.
+	ErrInvalidFilePath = errors.New("invalid file path")
+
+	// ErrBinaryFile is returned by the filesAsMap template function for files that are not valid UTF-8.
+	ErrBinaryFile = errors.New("binary file")
+
+	// ErrDuplicateFileName is returned by the filesAsMap template function when matched files in
+	// different directories share a name.
+	ErrDuplicateFileName = errors.New("duplicate file name")
+
+	// ErrNotADirectory is returned by SubFS when the requested subtree is not a directory.
+	ErrNotADirectory = errors.New("not a directory")
+
+	// ErrInvalidSourceURL is returned by HTTPSource when the URL cannot reference a remote template.
+	ErrInvalidSourceURL = errors.New("invalid source URL")
+
+	// ErrUnexpectedHTTPStatus is returned by HTTPSource when the server does not answer with a 2xx status.
+	ErrUnexpectedHTTPStatus = errors.New("unexpected HTTP status")
+
+	// ErrTemplateTooLarge is returned by HTTPSource when a template exceeds its maximum size.
+	ErrTemplateTooLarge = errors.New("template too large")
+
+	// ErrInvalidOCIReference is returned by OCISource when the artifact reference cannot be parsed.
+	ErrInvalidOCIReference = errors.New("invalid OCI reference")
+
+	// ErrDigestMismatch is returned by OCISource when the pulled manifest does not match the requested digest.
+	ErrDigestMismatch = errors.New("artifact digest mismatch")
+
+	// ErrInvalidArtifact is returned by OCISource and TarGzSource when an artifact contains files
+	// that cannot be served, e.g. paths escaping the artifact root.
+	ErrInvalidArtifact = errors.New("invalid artifact")
+
+	// ErrArchiveTooLarge is returned by TarGzSource and OCISource when an archive exceeds its maximum
+	// uncompressed size.
+	ErrArchiveTooLarge = errors.New("archive too large")
+
+	// ErrInvalidValuesSchema is returned by New when the WithValuesSchema document cannot be compiled.
+	ErrInvalidValuesSchema = errors.New("invalid values schema")
+
+	// ErrInvalidSetValue is returned by New when a WithSetValues pair cannot be parsed.
+	ErrInvalidSetValue = errors.New("invalid set value")
+
+	// ErrInvalidKubeVersion is returned by New when the WithCapabilities Kubernetes version is not
+	// a semantic version.
+	ErrInvalidKubeVersion = errors.New("invalid Kubernetes version")
+)
+
+// TemplateNotFoundError is returned by RenderTemplate and the include template function
+// when the requested template is not defined.
+type TemplateNotFoundError struct {
+	// Name is the requested template name.
+	Name string
+
+	// Available lists the template names defined across all Sources, sorted.
+	Available []string
+}
+
+func (e *TemplateNotFoundError) Error() string {
+	return fmt.Sprintf("template %q not found (available: %s)", e.Name, strings.Join(e.Available, ", "))
+}
+
+// MaxDepthError is returned when include and tpl calls nest deeper than WithMaxIncludeDepth
+// allows. It wraps ErrMaxDepthExceeded.
+type MaxDepthError struct {
+	// Limit is the maximum nesting depth.
+	Limit int
+
+	// Chain lists the nested calls, outermost first: the included template names, and "tpl"
+	// for tpl calls.
+	Chain []string
+}
+
+func (e *MaxDepthError) Error() string {
+	return fmt.Sprintf("%s: include and tpl calls nested more than %d levels: %s",
+		ErrMaxDepthExceeded, e.Limit, strings.Join(e.Chain, " -> "))
+}
+
+func (e *MaxDepthError) Unwrap() error {
+	return ErrMaxDepthExceeded
+}
+
+// ValuesViolation describes a single values schema violation.
+type ValuesViolation struct {
+	// Path is the dotted path of the offending field, "(root)" for the values root.
+	Path string
+
+	// Message describes the violation.
+	Message string
+}
+
+// ValuesValidationError is returned when merged values do not satisfy the WithValuesSchema schema.
+// It aggregates all violations so they can be fixed in one pass.
+type ValuesValidationError struct {
+	Violations []ValuesViolation
+}
+
+func (e *ValuesValidationError) Error() string {
+	violations := make([]string, 0, len(e.Violations))
+	for _, v := range e.Violations {
+		violations = append(violations, v.Path+": "+v.Message)
+	}
+
+	return "values do not match schema: " + strings.Join(violations, "; ")
+}
+
+// DuplicateResource is a resource identity rendered more than once.
+type DuplicateResource struct {
+	APIVersion string
+	Kind       string
+
+	// Namespace is empty for cluster-scoped resources and resources rendered without namespace.
+	Namespace string
+	Name      string
+
+	// Sources describes the Source rendering each occurrence, once per occurrence, so a Source
+	// rendering the same resource twice appears twice.
+	Sources []string
+}
+
+// DuplicateError is returned by WithDetectDuplicates when several rendered objects share the
+// same apiVersion, kind, namespace and name, which would conflict when applied.
+type DuplicateError struct {
+	// Duplicates lists the colliding identities, in the order they were first rendered.
+	Duplicates []DuplicateResource
+}
+
+func (e *DuplicateError) Error() string {
+	duplicates := make([]string, 0, len(e.Duplicates))
+	for _, d := range e.Duplicates {
+		name := d.Name
+		if d.Namespace != "" {
+			name = d.Namespace + "/" + d.Name
+		}
+
+		duplicates = append(duplicates, fmt.Sprintf(
+			"%s %s %s (sources: %s)",
+			d.APIVersion,
+			d.Kind,
+			name,
+			strings.Join(d.Sources, ", "),
+		))
+	}
+
+	return "duplicate resources: " + strings.Join(duplicates, "; ")
+}
+
+// RenderError is returned when executing a template fails.
+type RenderError struct {
+	// Name is the Source name, empty for unnamed Sources.
+	Name string
+
+	// Path identifies the Source, as its patterns joined with ",".
+	Path string
+
+	// TemplateName is the name of the executed template.
+	TemplateName string
+
+	// Line is the line reported by text/template for the failing action, 0 if unknown.
+	// It refers to the file containing the action, which may be a template included
+	// from TemplateName.
+	Line int
+
+	// Err is the underlying execution error.
+	Err error
+}
+
+// Error names the Source and the failing template once: rendering methods return RenderErrors
+// without wrapping them again. Execution errors of text/template already start with the
+// "template: <name>:<line>:<col>: " location, which is kept as is when it names TemplateName.
+func (e *RenderError) Error() string {
+	source := "gotemplate pattern " + e.Path
+	if e.Name != "" {
+		source += " (source " + e.Name + ")"
+	}
+
+	msg := e.Err.Error()
+	if strings.HasPrefix(msg, "template: "+e.TemplateName+":") {
+		return source + ": " + msg
+	}
+
+	location := e.TemplateName
+	if e.Line > 0 && !execErrorLocation.MatchString(msg) {
+		location += ":" + strconv.Itoa(e.Line)
+	}
+
+	return fmt.Sprintf("%s: template %s: %s", source, location, msg)
+}
+
+func (e *RenderError) Unwrap() error {
+	return e.Err
+}
+
+// execErrorLocation matches the "template: <name>:<line>:<col>: " prefix of text/template execution errors.
+var execErrorLocation = regexp.MustCompile(`^template: .*?:(\d+)(?::\d+)?: `)
+
+func newRenderError(holder *sourceHolder, name string, err error) *RenderError {
+	result := &RenderError{
+		Name:         holder.Name,
+		Path:         holder.pathPattern(),
+		TemplateName: name,
+		Err:          err,
+	}
+
+	if m := execErrorLocation.FindStringSubmatch(err.Error()); m != nil {
+		result.Line, _ = strconv.Atoi(m[1])
+	}
+
+	return result
+}
@@ -0,0 +1,213 @@
+package gotemplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"maps"
+	"sync/atomic"
+	"text/template"
+	"time"
+)
+
+// executeTemplate executes t with data into w. Failures are returned as *RenderError.
+// With WithRandomSeed, WithChecksums or WithImageResolver, t runs from a clone of its set (see
+// executionTemplate).
+//
+// With WithMaxOutputBytes the output is charged to the output budget of the render carried by
+// ctx, or to a budget of its own outside renders (see withOutputBudget). With WithRenderTimeout the
+// template runs on its own goroutine, writing into a private buffer copied to w once it
+// completes. On timeout or context cancellation the goroutine is abandoned: text/template
+// cannot be interrupted, so it runs to completion in the background, but it only reads data
+// and never touches w again.
+func (r *Renderer) executeTemplate(
+	ctx context.Context,
+	holder *sourceHolder,
+	t *template.Template,
+	w io.Writer,
+	data any,
+) error {
+	if outputBudgetFrom(ctx) == nil {
+		ctx = r.withOutputBudget(ctx)
+	}
+
+	t, err := holder.executionTemplate(ctx, t, data)
+	if err != nil {
+		return err
+	}
+
+	if r.opts.RenderTimeout <= 0 {
+		if err := t.Execute(outputBudgetFrom(ctx).writer(w), data); err != nil {
+			return newRenderError(holder, t.Name(), err)
+		}
+
+		return nil
+	}
+
+	var buf bytes.Buffer
+
+	out := outputBudgetFrom(ctx).writer(&buf)
+	done := make(chan error, 1)
+	go func() {
+		done <- t.Execute(out, data)
+	}()
+
+	timer := time.NewTimer(r.opts.RenderTimeout)
+	defer timer.Stop()
+
+	select {
+	case err := <-done:
+		if err == nil {
+			_, err = w.Write(buf.Bytes())
+		}
+		if err != nil {
+			return newRenderError(holder, t.Name(), err)
+		}
+
+		return nil
+	case <-timer.C:
+		return newRenderError(holder, t.Name(), fmt.Errorf(
+			"%w: did not render within %s",
+			ErrRenderTimeout,
+			r.opts.RenderTimeout,
+		))
+	case <-ctx.Done():
+		return fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), ctx.Err())
+	}
+}
+
+// executionTemplate returns t ready for one execution with data. Under WithRandomSeed,
+// WithChecksums, WithImageResolver and WithMaxOutputBytes, and for templates shared by Clone
+// across namespaces, some functions depend on the execution, so t is taken from a clone of its
+// set with them rebound; cloning leaves the parsed set untouched, keeping concurrent renders
+// independent. Otherwise t is returned as is.
+func (h *sourceHolder) executionTemplate(
+	ctx context.Context,
+	t *template.Template,
+	data any,
+) (*template.Template, error) {
+	extra := template.FuncMap{}
+	budget := outputBudgetFrom(ctx)
+
+	if h.imageResolver != nil {
+		extra["imageDigest"] = imageDigestFunc(ctx, h.imageResolver)
+	}
+
+	if h.namespace != "" {
+		extra["namespace"] = namespaceFunc(h.namespace)
+	}
+
+	if h.checksums {
+		sums := &checksums{
+			holder:    h,
+			budget:    budget,
+			templates: t,
+			data:      data,
+			funcs:     extra,
+			sums:      make(map[string]string),
+			pending:   []string{t.Name()},
+		}
+		extra["checksumOf"] = sums.checksumOf
+	}
+
+	if h.randomFuncs == nil && len(extra) == 0 && budget == nil {
+		return t, nil
+	}
+
+	return h.bind(t, extra, budget)
+}
+
+// bind returns t from a clone of its set with the execution functions bound: the random
+// functions seeded for t, the extra functions of the execution (checksumOf, imageDigest,
+// namespace), and include and tpl charging their results to budget when not nil.
+func (h *sourceHolder) bind(
+	t *template.Template,
+	extra template.FuncMap,
+	budget *outputBudget,
+) (*template.Template, error) {
+	clone, err := t.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone templates of gotemplate pattern %s: %w", h.describe(), err)
+	}
+
+	funcs := h.executionFuncs(t.Name())
+	if len(extra) > 0 {
+		funcs = maps.Clone(funcs)
+		maps.Copy(funcs, extra)
+	}
+
+	n := h.rootNesting()
+	n.budget = budget
+
+	return clone.Funcs(setFuncMap(clone, funcs, n)).Funcs(funcs), nil
+}
+
+// outputBudgetKey is the context key of the output budget of a render.
+type outputBudgetKey struct{}
+
+// outputBudget is what a render may still produce under WithMaxOutputBytes: every template
+// execution of the render draws from it, include and tpl results included, so the cap holds
+// for the render as a whole however many templates and Sources it runs.
+type outputBudget struct {
+	limit     int64
+	remaining atomic.Int64
+}
+
+// withOutputBudget returns ctx carrying a new output budget if WithMaxOutputBytes is set.
+// Rendering methods call it once, so the templates of all their Sources share the budget.
+func (r *Renderer) withOutputBudget(ctx context.Context) context.Context {
+	if r.opts.MaxOutputBytes <= 0 {
+		return ctx
+	}
+
+	budget := &outputBudget{limit: r.opts.MaxOutputBytes}
+	budget.remaining.Store(r.opts.MaxOutputBytes)
+
+	return context.WithValue(ctx, outputBudgetKey{}, budget)
+}
+
+// outputBudgetFrom returns the output budget carried by ctx, nil when the output is not capped.
+func outputBudgetFrom(ctx context.Context) *outputBudget {
+	budget, _ := ctx.Value(outputBudgetKey{}).(*outputBudget)
+
+	return budget
+}
+
+// take charges n bytes to b, failing with ErrOutputTooLarge once the cap is exceeded.
+// A nil budget accepts everything.
+func (b *outputBudget) take(n int) error {
+	if b == nil {
+		return nil
+	}
+
+	if b.remaining.Add(-int64(n)) < 0 {
+		return fmt.Errorf("%w: more than %d bytes", ErrOutputTooLarge, b.limit)
+	}
+
+	return nil
+}
+
+// writer returns w with its writes charged to b, w itself for a nil budget.
+func (b *outputBudget) writer(w io.Writer) io.Writer {
+	if b == nil {
+		return w
+	}
+
+	return &limitWriter{w: w, budget: b}
+}
+
+// limitWriter forwards writes to w while its budget allows, then fails with
+// ErrOutputTooLarge, which text/template returns from Execute unchanged.
+type limitWriter struct {
+	w      io.Writer
+	budget *outputBudget
+}
+
+func (l *limitWriter) Write(p []byte) (int, error) {
+	if err := l.budget.take(len(p)); err != nil {
+		return 0, err
+	}
+
+	return l.w.Write(p)
+}
@@ -0,0 +1,154 @@
+package gotemplate
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/go-logr/logr"
+	utilerrors "github.com/k8s-manifest-kit/pkg/util/errors"
+)
+
+// fallbackLayer is one FS of a Source fallback chain, named for diagnostics.
+type fallbackLayer struct {
+	name string
+	fsys fs.FS
+}
+
+// fallbackFS serves every file from the first layer having it: the Source FS, then its
+// fallbacks in chain order. Directory listings (ReadDir) merge all layers sorted by name, so
+// Source patterns match the files of every layer and each resolves to a single layer.
+type fallbackFS struct {
+	layers []fallbackLayer
+	log    logr.Logger
+}
+
+// fallbackChain returns the Source fallbacks in chain order, failing on chains that lack an
+// FS or loop back to a Source already in the chain.
+func fallbackChain(source *Source) ([]*Source, error) {
+	chain := make([]*Source, 0)
+	seen := map[*Source]bool{source: true}
+
+	for f := source.Fallback; f != nil; f = f.Fallback {
+		if seen[f] {
+			return nil, errors.New("fallback chain is cyclic")
+		}
+
+		if f.FS == nil {
+			return nil, fmt.Errorf("fallback %d: %w", len(chain)+1, utilerrors.ErrFsRequired)
+		}
+
+		seen[f] = true
+		chain = append(chain, f)
+	}
+
+	return chain, nil
+}
+
+func newFallbackFS(fsys fs.FS, chain []*Source, log logr.Logger) *fallbackFS {
+	layers := make([]fallbackLayer, 0, 1+len(chain))
+	layers = append(layers, fallbackLayer{fsys: fsys})
+
+	for i, f := range chain {
+		layers = append(layers, fallbackLayer{
+			name: cmp.Or(f.Name, "fallback "+strconv.Itoa(i+1)),
+			fsys: f.FS,
+		})
+	}
+
+	return &fallbackFS{
+		layers: layers,
+		log:    log,
+	}
+}
+
+// Open opens name from the first layer having it.
+func (f *fallbackFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	var firstErr error
+
+	for i, l := range f.layers {
+		file, err := l.fsys.Open(name)
+		switch {
+		case err == nil:
+			if i > 0 {
+				f.logResolved(name, file, l)
+			}
+
+			return file, nil
+		case !errors.Is(err, fs.ErrNotExist):
+			return nil, err
+		case firstErr == nil:
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+// logResolved logs files missing from the Source FS and supplied by the fallback l.
+func (f *fallbackFS) logResolved(name string, file fs.File, l fallbackLayer) {
+	if info, err := file.Stat(); err == nil && !info.IsDir() {
+		f.log.V(1).Info("file resolved from fallback", "file", name, "fallback", l.name)
+	}
+}
+
+// Stat returns the file info of name from the first layer having it.
+func (f *fallbackFS) Stat(name string) (fs.FileInfo, error) {
+	var firstErr error
+
+	for _, l := range f.layers {
+		info, err := fs.Stat(l.fsys, name)
+		if err == nil || !errors.Is(err, fs.ErrNotExist) {
+			return info, err
+		}
+
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return nil, firstErr
+}
+
+// ReadDir returns the entries of the directory name in any layer, sorted by name, taking the
+// entries of earlier layers over those of later ones with the same name.
+func (f *fallbackFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	entries := make(map[string]fs.DirEntry)
+
+	var firstErr error
+	found := false
+
+	for _, l := range slices.Backward(f.layers) {
+		layerEntries, err := fs.ReadDir(l.fsys, name)
+		if err != nil {
+			if !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+
+			firstErr = err
+
+			continue
+		}
+
+		found = true
+		for _, e := range layerEntries {
+			entries[e.Name()] = e
+		}
+	}
+
+	if !found {
+		return nil, firstErr
+	}
+
+	return slices.SortedFunc(maps.Values(entries), func(a, b fs.DirEntry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	}), nil
+}
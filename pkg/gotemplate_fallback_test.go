@@ -0,0 +1,137 @@
+package gotemplate_test
+
+import (
+	"fmt"
+	"testing"
+	"testing/fstest"
+
+	"github.com/go-logr/logr/funcr"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func fallbackConfigMap(name string, origin string) *fstest.MapFile {
+	return &fstest.MapFile{Data: fmt.Appendf(nil,
+		"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: %s\ndata:\n  origin: %s\n", name, origin,
+	)}
+}
+
+func TestFallback(t *testing.T) {
+
+	shared := &gotemplate.Source{
+		Name: "shared",
+		FS: fstest.MapFS{
+			"templates/app.yaml":     fallbackConfigMap("app", "shared"),
+			"templates/monitor.yaml": fallbackConfigMap("monitor", "shared"),
+		},
+	}
+
+	t.Run("should resolve names missing in the primary from the fallback", func(t *testing.T) {
+		g := NewWithT(t)
+
+		lines := make([]string, 0)
+		log := funcr.New(func(_ string, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: 1})
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:       fstest.MapFS{"templates/app.yaml": fallbackConfigMap("app", "prod")},
+					Path:     "templates/*.yaml",
+					Fallback: shared,
+				},
+			},
+			gotemplate.WithLogger(log),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "app" and .data.origin == "prod"`))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.metadata.name == "monitor" and .data.origin == "shared"`))
+
+		g.Expect(lines).To(ContainElement(And(
+			ContainSubstring(`"msg"="file resolved from fallback"`),
+			ContainSubstring(`"file"="templates/monitor.yaml"`),
+			ContainSubstring(`"fallback"="shared"`),
+		)))
+		g.Expect(lines).ToNot(ContainElement(ContainSubstring(`"file"="templates/app.yaml"`)))
+	})
+
+	t.Run("should follow the fallback chain", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := &gotemplate.Source{
+			FS: fstest.MapFS{
+				"templates/monitor.yaml": fallbackConfigMap("monitor", "base"),
+				"templates/quota.yaml":   fallbackConfigMap("quota", "base"),
+			},
+		}
+		team := &gotemplate.Source{
+			FS:       fstest.MapFS{"templates/monitor.yaml": fallbackConfigMap("monitor", "team")},
+			Fallback: base,
+		}
+
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:       fstest.MapFS{"templates/app.yaml": fallbackConfigMap("app", "prod")},
+				Path:     "templates/*.yaml",
+				Fallback: team,
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.origin == "prod"`))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.data.origin == "team"`))
+		g.Expect(objects[2].Object).To(jqmatcher.Match(`.data.origin == "base"`))
+	})
+
+	t.Run("should not share cached results across fallbacks", func(t *testing.T) {
+		g := NewWithT(t)
+
+		primary := fstest.MapFS{"templates/app.yaml": fallbackConfigMap("app", "prod")}
+		other := &gotemplate.Source{
+			FS: fstest.MapFS{"templates/monitor.yaml": fallbackConfigMap("monitor", "other")},
+		}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: primary, Path: "templates/*.yaml", Fallback: shared},
+				{FS: primary, Path: "templates/*.yaml", Fallback: other},
+			},
+			gotemplate.WithCache(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(4))
+		g.Expect(objects[3].Object).To(jqmatcher.Match(`.data.origin == "other"`))
+	})
+
+	t.Run("should reject invalid fallback chains", func(t *testing.T) {
+		g := NewWithT(t)
+
+		cyclic := &gotemplate.Source{FS: fstest.MapFS{}}
+		cyclic.Fallback = &gotemplate.Source{FS: fstest.MapFS{}, Fallback: cyclic}
+
+		for _, fallback := range []*gotemplate.Source{cyclic, {Name: "empty"}} {
+			_, err := gotemplate.New([]gotemplate.Source{
+				{
+					FS:       fstest.MapFS{"templates/app.yaml": fallbackConfigMap("app", "prod")},
+					Path:     "templates/*.yaml",
+					Fallback: fallback,
+				},
+			})
+			g.Expect(err).To(MatchError(ContainSubstring("validation failed for source templates/*.yaml")))
+		}
+	})
+}
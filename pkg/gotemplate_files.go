@@ -0,0 +1,180 @@
+package gotemplate
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"unicode/utf8"
+)
+
+// filesFuncMap returns the functions reading non-template files from the Source FS, e.g. to
+// embed certificates or scripts into manifests:
+//
+//   - readFile returns the content of a file
+//   - readGlob returns the content of every file matching a pattern, keyed by path
+//   - filesAsMap returns the content of every file matching a pattern, keyed by file name,
+//     ready for the data of a ConfigMap
+//   - fileAsBase64 returns the standard base64 encoding of a file, for the data of a Secret
+//   - fileAsString returns the content of a UTF-8 file, for the stringData of a Secret
+func filesFuncMap(fsys fs.FS) template.FuncMap {
+	return template.FuncMap{
+		"readFile": func(name string) (string, error) {
+			return readFile(fsys, name)
+		},
+		"readGlob": func(pattern string) (map[string]string, error) {
+			return readGlob(fsys, pattern)
+		},
+		"filesAsMap": func(pattern string) (map[string]string, error) {
+			return filesAsMap(fsys, pattern)
+		},
+		"fileAsBase64": func(name string) (string, error) {
+			return fileAsBase64(fsys, name)
+		},
+		"fileAsString": func(name string) (string, error) {
+			return fileAsString(fsys, name)
+		},
+	}
+}
+
+// sourceFilePath returns the clean path of a file relative to the Source FS root,
+// rejecting absolute paths and paths with ".." elements.
+func sourceFilePath(name string) (string, error) {
+	if strings.HasPrefix(name, "/") {
+		return "", fmt.Errorf("%w: %q is absolute, paths are relative to the Source FS", ErrInvalidFilePath, name)
+	}
+
+	if slices.Contains(strings.Split(name, "/"), "..") {
+		return "", fmt.Errorf("%w: %q escapes the Source FS", ErrInvalidFilePath, name)
+	}
+
+	cleaned := path.Clean(name)
+	if !fs.ValidPath(cleaned) {
+		return "", fmt.Errorf("%w: %q", ErrInvalidFilePath, name)
+	}
+
+	return cleaned, nil
+}
+
+func readFile(fsys fs.FS, name string) (string, error) {
+	data, err := readSourceFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+// readSourceFile reads the file name of the Source FS, reporting missing files as such.
+func readSourceFile(fsys fs.FS, name string) ([]byte, error) {
+	file, err := sourceFilePath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := fs.ReadFile(fsys, file)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		return nil, fmt.Errorf("failed to read file %s: no such file in the Source FS: %w", name, err)
+	case err != nil:
+		return nil, fmt.Errorf("failed to read file %s: %w", name, err)
+	}
+
+	return data, nil
+}
+
+// fileAsBase64 encodes binary files as well, as Secret data expects:
+// {{ fileAsBase64 "certs/keystore.p12" }}.
+func fileAsBase64(fsys fs.FS, name string) (string, error) {
+	data, err := readSourceFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// fileAsString rejects binary (non UTF-8) files, which Secret stringData cannot hold and
+// belong in data via fileAsBase64.
+func fileAsString(fsys fs.FS, name string) (string, error) {
+	data, err := readSourceFile(fsys, name)
+	if err != nil {
+		return "", err
+	}
+
+	if !utf8.Valid(data) {
+		return "", fmt.Errorf("%w: %s (use fileAsBase64 under data instead)", ErrBinaryFile, name)
+	}
+
+	return string(data), nil
+}
+
+// readGlob supports the same patterns as Source.Path, including "**" segments.
+// Matched directories are skipped, and a pattern matching no files yields an empty map.
+func readGlob(fsys fs.FS, pattern string) (map[string]string, error) {
+	if _, err := sourceFilePath(pattern); err != nil {
+		return nil, err
+	}
+
+	files, err := globFiles(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(files))
+
+	for _, file := range files {
+		info, err := fs.Stat(fsys, file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read file %s: %w", file, err)
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		content, err := readFile(fsys, file)
+		if err != nil {
+			return nil, err
+		}
+
+		result[file] = content
+	}
+
+	return result, nil
+}
+
+// filesAsMap rejects binary (non UTF-8) files, which belong in binaryData, and files from
+// different directories sharing a name, since both would map to the same key.
+func filesAsMap(fsys fs.FS, pattern string) (map[string]string, error) {
+	files, err := readGlob(fsys, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(files))
+	sources := make(map[string]string, len(files))
+
+	for _, file := range slices.Sorted(maps.Keys(files)) {
+		content := files[file]
+		key := path.Base(file)
+
+		if !utf8.ValidString(content) {
+			return nil, fmt.Errorf("%w: %s (use b64enc under binaryData instead)", ErrBinaryFile, file)
+		}
+
+		if existing, ok := sources[key]; ok {
+			return nil, fmt.Errorf("%w: %s (from %s and %s)", ErrDuplicateFileName, key, existing, file)
+		}
+
+		result[key] = content
+		sources[key] = file
+	}
+
+	return result, nil
+}
@@ -0,0 +1,226 @@
+package gotemplate_test
+
+import (
+	"encoding/base64"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestReadFile(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl":     &fstest.MapFile{Data: []byte(tmpl)},
+						"scripts/init.sh":  &fstest.MapFile{Data: []byte("#!/bin/sh\necho init\n")},
+						"scripts/start.sh": &fstest.MapFile{Data: []byte("#!/bin/sh\necho start\n")},
+					},
+					Path: "*.tpl",
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should embed a file of an embedded Source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sub, err := gotemplate.SubFS(embeddedTemplates, "testdata/embedded/tls")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     sub,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "tls"}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(
+			jqmatcher.Match(`.stringData["ca.crt"] | startswith("-----BEGIN CERTIFICATE-----\nMIIBfakeCA\n")`),
+		)
+	})
+
+	t.Run("should read matched files with readGlob", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ range $k, $v := readGlob "scripts/*.sh" }}{{ $k }}={{ $v }};{{ end }}`)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("scripts/init.sh=#!/bin/sh\necho init\n;scripts/start.sh=#!/bin/sh\necho start\n;"))
+	})
+
+	t.Run("should fail for missing files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ readFile "scripts/missing.sh" }}`)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to read file scripts/missing.sh")))
+	})
+
+	t.Run("should reject paths escaping the Source FS", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, tmpl := range []string{
+			`{{ readFile "../secret.txt" }}`,
+			`{{ readFile "scripts/../../secret.txt" }}`,
+			`{{ readFile "/etc/passwd" }}`,
+			`{{ readGlob "../*" }}`,
+		} {
+			_, err := render(t, tmpl)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidFilePath), tmpl)
+		}
+	})
+}
+
+func TestSecretFileFunctions(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl":  &fstest.MapFile{Data: []byte(tmpl)},
+						"certs/key.der": &fstest.MapFile{Data: []byte{0x30, 0x82, 0xff, 0x00}},
+					},
+					Path: "*.tpl",
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should build a Secret from an embedded binary file", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sub, err := gotemplate.SubFS(embeddedTemplates, "testdata/embedded/keystore")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		keystore, err := fs.ReadFile(sub, "keystore.p12")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     sub,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "keystore"}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(
+			`.data["keystore.p12"] == "%s"`, base64.StdEncoding.EncodeToString(keystore),
+		))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.stringData.passphrase == "changeit"`))
+	})
+
+	t.Run("should reject binary files with fileAsString", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ fileAsString "certs/key.der" }}`)
+		g.Expect(err).To(MatchError(gotemplate.ErrBinaryFile))
+	})
+
+	t.Run("should fail for missing files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, tmpl := range []string{`{{ fileAsBase64 "certs/missing.der" }}`, `{{ fileAsString "missing.txt" }}`} {
+			_, err := render(t, tmpl)
+			g.Expect(err).To(MatchError(fs.ErrNotExist), tmpl)
+			g.Expect(err).To(MatchError(ContainSubstring("no such file in the Source FS")), tmpl)
+		}
+	})
+
+	t.Run("should reject paths escaping the Source FS", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, tmpl := range []string{`{{ fileAsBase64 "../secret.der" }}`, `{{ fileAsString "/etc/passwd" }}`} {
+			_, err := render(t, tmpl)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidFilePath), tmpl)
+		}
+	})
+}
+
+func TestFilesAsMap(t *testing.T) {
+
+	const configMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: scripts
+data: {{- filesAsMap "scripts/*.sh" | toYaml | nindent 2 }}
+`
+
+	process := func(t *testing.T, files fstest.MapFS) ([]unstructured.Unstructured, error) {
+		t.Helper()
+
+		files["configmap.yaml"] = &fstest.MapFile{Data: []byte(configMapTemplate)}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:   files,
+					Path: "*.yaml",
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer.Process(t.Context(), nil)
+	}
+
+	t.Run("should build ConfigMap data from files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := process(t, fstest.MapFS{
+			"scripts/init.sh":  &fstest.MapFile{Data: []byte("#!/bin/sh\necho init\n")},
+			"scripts/start.sh": &fstest.MapFile{Data: []byte("#!/bin/sh\necho start\n")},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(
+			`.data == {"init.sh": "#!/bin/sh\necho init\n", "start.sh": "#!/bin/sh\necho start\n"}`,
+		))
+	})
+
+	t.Run("should reject binary files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := process(t, fstest.MapFS{
+			"scripts/blob.sh": &fstest.MapFile{Data: []byte{0xff, 0xfe, 0x00}},
+		})
+		g.Expect(err).To(MatchError(gotemplate.ErrBinaryFile))
+		g.Expect(err).To(MatchError(ContainSubstring("scripts/blob.sh")))
+	})
+}
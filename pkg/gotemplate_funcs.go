@@ -0,0 +1,712 @@
+package gotemplate
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"text/template"
+	"unicode"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	"sigs.k8s.io/yaml"
+)
+
+// newFuncMap builds the function map attached to every template set.
+// Layers are applied in increasing precedence: built-in functions, Sprig-compatible
+// functions (if enabled), then user-provided functions, so WithFuncMap can always
+// override a bundled helper.
+func newFuncMap(opts RendererOptions) template.FuncMap {
+	funcs := builtinFuncMap()
+	maps.Copy(funcs, randomFuncMap(rand.Reader))
+	funcs["lookup"] = lookupFunc(opts.LookupFunc)
+	funcs["namespace"] = namespaceFunc(opts.Namespace)
+
+	// Executions rebind imageDigest to the render context (see executionTemplate)
+	funcs["imageDigest"] = imageDigestFunc(context.Background(), opts.ImageResolver)
+
+	if opts.SprigFunctions {
+		maps.Copy(funcs, sprigFuncMap(opts.MaxOutputBytes))
+	}
+
+	// Executions rebind checksumOf (see executionTemplate)
+	if opts.Checksums {
+		funcs["checksumOf"] = unboundChecksumOf
+	}
+
+	maps.Copy(funcs, opts.FuncMap)
+
+	return funcs
+}
+
+// builtinFuncMap returns the functions registered for every renderer regardless of options.
+func builtinFuncMap() template.FuncMap {
+	return template.FuncMap{
+		"toYaml":       toYaml,
+		"fromYaml":     fromYaml,
+		"toJson":       toJSON,
+		"toPrettyJson": toPrettyJSON,
+		"fromJson":     fromJSON,
+		"required":     required,
+		"default":      defaultValue,
+
+		// Quoting, so values like no, 1.0 or 2024-01-01 stay strings in YAML
+		"quote":  quote,
+		"squote": squote,
+
+		// Hashing and encoding, e.g. checksum annotations rolling pods on config change
+		"sha256sum": sha256sum,
+		"b64enc":    b64enc,
+		"b64dec":    b64dec,
+
+		// Indentation, the backbone of toYaml based composition
+		"indent":  indent,
+		"nindent": nindent,
+
+		// Kubernetes names, capped at 63 characters as DNS-1123 labels
+		"dnsName":   dnsName,
+		"truncName": truncName,
+
+		// Maps, e.g. base labels plus extra labels
+		"merge":          merge,
+		"mergeOverwrite": mergeOverwrite,
+		"dig":            dig,
+		"hasKey":         hasKey,
+		"get":            get,
+
+		// Resource quantities, canonicalized so typos fail at render time
+		"quantity": quantity,
+
+		// Versions, e.g. gating manifests on .Capabilities.KubeVersion
+		"semver":        semverVersion,
+		"semverCompare": semverCompare,
+	}
+}
+
+// defaultMaxIncludeDepth caps how deeply include and tpl calls may nest by default, so a
+// template including itself, or a values-supplied fragment rendering itself, fails with
+// ErrMaxDepthExceeded before exhausting the stack.
+const defaultMaxIncludeDepth = 100
+
+// nesting is the position of a template set in a chain of include and tpl calls. Nested calls
+// execute in clones of the set bound one level deeper, so the depth travels with the call
+// chain and concurrent renders never share a counter.
+type nesting struct {
+	depth int
+	limit int
+
+	// Output budget of the execution the include and tpl results are charged to; nil = uncapped
+	budget *outputBudget
+}
+
+func (n nesting) next() nesting {
+	return nesting{depth: n.depth + 1, limit: n.limit, budget: n.budget}
+}
+
+// exceeded returns the MaxDepthError of a call to name from a set at the limit, nil otherwise.
+func (n nesting) exceeded(name string) error {
+	if n.depth < n.limit {
+		return nil
+	}
+
+	return &MaxDepthError{Limit: n.limit, Chain: []string{name}}
+}
+
+// nestedError returns the MaxDepthError in err with name prepended to its chain, so the
+// outermost call reports the whole chain once instead of every level wrapping it again.
+// It returns nil when err is not due to the nesting limit.
+func nestedError(name string, err error) error {
+	var depthErr *MaxDepthError
+	if !errors.As(err, &depthErr) {
+		return nil
+	}
+
+	depthErr.Chain = append([]string{name}, depthErr.Chain...)
+
+	return depthErr
+}
+
+// setFuncMap returns the functions bound to a specific template set: include and tpl.
+// n is the nesting of the set in the include and tpl calls of an execution.
+func setFuncMap(templates *template.Template, funcs template.FuncMap, n nesting) template.FuncMap {
+	return template.FuncMap{
+		"include": includeFunc(templates, funcs, n),
+		"tpl":     tplFunc(templates, funcs, n),
+	}
+}
+
+// nestedSet returns a clone of templates with include and tpl rebound one level deeper than n.
+func nestedSet(templates *template.Template, funcs template.FuncMap, n nesting) (*template.Template, error) {
+	clone, err := templates.Clone()
+	if err != nil {
+		return nil, fmt.Errorf("failed to clone templates: %w", err)
+	}
+
+	return clone.Funcs(setFuncMap(clone, funcs, n.next())).Funcs(funcs), nil
+}
+
+// tplFunc returns the tpl function bound to a template set. tpl parses text as a template
+// in a clone of the set, so it shares the FuncMap, delimiters, options and named templates
+// of the renderer, and executes it with data.
+func tplFunc(templates *template.Template, funcs template.FuncMap, n nesting) func(string, any) (string, error) {
+	return func(text string, data any) (string, error) {
+		if err := n.exceeded("tpl"); err != nil {
+			return "", err
+		}
+
+		clone, err := nestedSet(templates, funcs, n)
+		if err != nil {
+			return "", fmt.Errorf("failed to prepare tpl: %w", err)
+		}
+
+		t, err := clone.New("tpl").Parse(text)
+		if err != nil {
+			return "", fmt.Errorf("failed to parse tpl template: %w", err)
+		}
+
+		var buf strings.Builder
+		if err := t.Execute(n.budget.writer(&buf), data); err != nil {
+			if depthErr := nestedError("tpl", err); depthErr != nil {
+				return "", depthErr
+			}
+
+			return "", fmt.Errorf("failed to execute tpl template: %w", err)
+		}
+
+		return buf.String(), nil
+	}
+}
+
+// includeFunc returns the include function bound to a template set. Unlike the template
+// action, include returns the output as a string, so it can be piped:
+// {{ include "labels" . | nindent 4 }}. Included templates execute in the set one level
+// deeper, cloned once on first use as parsed sets are never modified.
+func includeFunc(templates *template.Template, funcs template.FuncMap, n nesting) func(string, any) (string, error) {
+	nested := sync.OnceValues(func() (*template.Template, error) {
+		return nestedSet(templates, funcs, n)
+	})
+
+	return func(name string, data any) (string, error) {
+		if templates.Lookup(name) == nil || name == "" {
+			return "", &TemplateNotFoundError{
+				Name:      name,
+				Available: templateNames(templates),
+			}
+		}
+
+		if err := n.exceeded(name); err != nil {
+			return "", err
+		}
+
+		set, err := nested()
+		if err != nil {
+			return "", fmt.Errorf("failed to include template %s: %w", name, err)
+		}
+
+		var buf strings.Builder
+		if err := set.Lookup(name).Execute(n.budget.writer(&buf), data); err != nil {
+			if depthErr := nestedError(name, err); depthErr != nil {
+				return "", depthErr
+			}
+
+			return "", fmt.Errorf("failed to include template %s: %w", name, err)
+		}
+
+		return buf.String(), nil
+	}
+}
+
+// lookupFunc returns the lookup function delegating to fn. As with Helm, objects that do not
+// exist yield an empty map, and so does every lookup without fn, so dry-renders work.
+func lookupFunc(fn LookupFunc) func(string, string, string, string) (map[string]any, error) {
+	return func(apiVersion string, kind string, namespace string, name string) (map[string]any, error) {
+		if fn == nil {
+			return map[string]any{}, nil
+		}
+
+		obj, err := fn(apiVersion, kind, namespace, name)
+
+		switch {
+		case apierrors.IsNotFound(err):
+			return map[string]any{}, nil
+		case err != nil:
+			return nil, fmt.Errorf("failed to lookup %s %s %s/%s: %w", apiVersion, kind, namespace, name, err)
+		case obj == nil:
+			return map[string]any{}, nil
+		default:
+			return obj, nil
+		}
+	}
+}
+
+// sprigFuncMap returns the curated subset of Sprig-compatible functions enabled by WithSprigFunctions.
+// With maxOutput set (WithMaxOutputBytes), repeat refuses results larger than maxOutput bytes
+// before allocating them.
+//
+// Functions that read the environment or reach the network (env, expandenv, getHostByName)
+// are intentionally not provided so that rendering stays hermetic and reproducible.
+func sprigFuncMap(maxOutput int64) template.FuncMap {
+	return template.FuncMap{
+		// Defaults and flow control
+		"empty":    isEmpty,
+		"coalesce": coalesce,
+		"ternary":  ternary,
+
+		// Strings
+		"upper":      strings.ToUpper,
+		"lower":      strings.ToLower,
+		"trim":       strings.TrimSpace,
+		"trimAll":    func(cutset string, s string) string { return strings.Trim(s, cutset) },
+		"trimPrefix": func(prefix string, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trimSuffix": func(suffix string, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trunc":      trunc,
+		"replace":    replace,
+		"contains":   func(substr string, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix string, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix string, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     repeatFunc(maxOutput),
+		"nospace":    func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"join":       join,
+		"splitList":  func(sep string, s string) []string { return strings.Split(s, sep) },
+		"toString":   toString,
+
+		// Collections
+		"list": func(v ...any) []any { return v },
+		"dict": dict,
+		"keys": keys,
+	}
+}
+
+// repeatFunc returns the repeat function, failing with ErrOutputTooLarge on results of more
+// than maxOutput bytes when maxOutput is positive.
+func repeatFunc(maxOutput int64) func(int, string) (string, error) {
+	return func(count int, s string) (string, error) {
+		count = max(count, 0)
+		if maxOutput > 0 && len(s) > 0 && int64(count) > maxOutput/int64(len(s)) {
+			return "", fmt.Errorf("%w: repeat of %d times %d bytes exceeds %d bytes",
+				ErrOutputTooLarge, count, len(s), maxOutput)
+		}
+
+		return strings.Repeat(s, count), nil
+	}
+}
+
+// required returns val unchanged, or an error carrying msg when val is nil or an empty string.
+// Unlike missingkey=error it also catches keys that are present but unset.
+func required(msg string, val any) (any, error) {
+	switch v := val.(type) {
+	case nil:
+		return nil, fmt.Errorf("%w: %s", ErrRequiredValue, msg)
+	case string:
+		if v == "" {
+			return nil, fmt.Errorf("%w: %s", ErrRequiredValue, msg)
+		}
+	}
+
+	return val, nil
+}
+
+// defaultValue returns def when the given value is empty (see isEmpty), matching Helm:
+// 0, false, "" and empty collections are replaced too, so {{ .replicas | default 1 }}
+// cannot render an explicit 0. The argument order allows piping: {{ .name | default "app" }}.
+func defaultValue(def any, given ...any) any {
+	if len(given) == 0 || isEmpty(given[0]) {
+		return def
+	}
+
+	return given[0]
+}
+
+// isEmpty reports whether v is nil, a zero number, false, or an empty string/collection.
+// As in Helm, structs are never empty.
+func isEmpty(v any) bool {
+	if v == nil {
+		return true
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Array, reflect.Map, reflect.Slice, reflect.String:
+		return rv.Len() == 0
+	case reflect.Pointer, reflect.Interface:
+		return rv.IsNil()
+	case reflect.Struct:
+		return false
+	default:
+		return rv.IsZero()
+	}
+}
+
+func coalesce(v ...any) any {
+	for _, val := range v {
+		if !isEmpty(val) {
+			return val
+		}
+	}
+
+	return nil
+}
+
+func ternary(vt any, vf any, condition bool) any {
+	if condition {
+		return vt
+	}
+
+	return vf
+}
+
+// quote renders its non-nil arguments as double-quoted YAML scalars, separated by spaces.
+// The Go escapes used for quotes, backslashes and control characters are valid YAML escapes.
+func quote(v ...any) string {
+	out := make([]string, 0, len(v))
+	for _, s := range v {
+		if s != nil {
+			out = append(out, fmt.Sprintf("%q", toString(s)))
+		}
+	}
+
+	return strings.Join(out, " ")
+}
+
+// squote renders its non-nil arguments as single-quoted YAML scalars, separated by spaces.
+// Single-quoted scalars only escape quotes and fold line breaks, so strings containing control
+// characters are double-quoted instead, as by quote.
+func squote(v ...any) string {
+	out := make([]string, 0, len(v))
+	for _, s := range v {
+		if s == nil {
+			continue
+		}
+
+		str := toString(s)
+		if strings.ContainsFunc(str, unicode.IsControl) {
+			out = append(out, fmt.Sprintf("%q", str))
+
+			continue
+		}
+
+		// YAML single-quoted scalars escape a quote by doubling it
+		out = append(out, "'"+strings.ReplaceAll(str, "'", "''")+"'")
+	}
+
+	return strings.Join(out, " ")
+}
+
+func replace(old string, replacement string, s string) string {
+	return strings.ReplaceAll(s, old, replacement)
+}
+
+// trunc truncates s to n characters; a negative n keeps the last |n| characters.
+func trunc(n int, s string) string {
+	switch {
+	case n < 0 && len(s)+n > 0:
+		return s[len(s)+n:]
+	case n >= 0 && len(s) > n:
+		return s[:n]
+	default:
+		return s
+	}
+}
+
+// indent prefixes every line of s with n spaces. Empty lines, including the one after a
+// trailing newline, are left empty so the output carries no whitespace-only lines.
+func indent(n int, s string) string {
+	pad := strings.Repeat(" ", max(n, 0))
+
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = pad + line
+		}
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// nindent is indent preceded by a newline, for use right after a mapping key:
+// {{- toYaml .labels | nindent 4 }}.
+func nindent(n int, s string) string {
+	return "\n" + indent(n, s)
+}
+
+// maxDNSLabelLength is the length limit of DNS-1123 labels, and so of most Kubernetes names.
+const maxDNSLabelLength = 63
+
+// dnsName joins the non-empty parts with "-" into a valid DNS-1123 label: lowercased, with
+// characters other than letters, digits and "-" replaced by "-", without leading or trailing
+// hyphens and truncated to 63 characters: {{ dnsName .release "web" }}.
+func dnsName(parts ...string) string {
+	nonEmpty := slices.DeleteFunc(slices.Clone(parts), func(part string) bool { return part == "" })
+
+	name := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r + 'a' - 'A'
+		default:
+			return '-'
+		}
+	}, strings.Join(nonEmpty, "-"))
+
+	return truncName(maxDNSLabelLength, strings.TrimLeft(name, "-"))
+}
+
+// truncName cuts name to at most n characters and trims the hyphens left at the end,
+// which Kubernetes names may not end with: {{ .name | truncName 20 }}.
+func truncName(n int, name string) string {
+	if runes := []rune(name); len(runes) > n {
+		name = string(runes[:max(n, 0)])
+	}
+
+	return strings.TrimRight(name, "-")
+}
+
+// merge deep merges src into a copy of dst without overwriting the keys dst already has, so
+// dst acts as the overrides: {{ merge .extraLabels .baseLabels | toYaml }}. Unlike the Sprig
+// function of the same name, neither input is modified.
+func merge(dst map[string]any, src map[string]any) map[string]any {
+	return util.DeepMerge(src, dst)
+}
+
+// mergeOverwrite is merge with src winning on conflicting keys. Nested maps are merged
+// recursively while other values are replaced, and neither input is modified.
+func mergeOverwrite(dst map[string]any, src map[string]any) map[string]any {
+	return util.DeepMerge(dst, src)
+}
+
+// dig walks the dotted path through nested maps of m and returns the value found there, or def
+// when a segment is missing or an intermediate value is not a map, so optional values need no
+// with/if chains under missingkey=error: {{ dig "image.pullPolicy" "IfNotPresent" . }}. Unlike
+// the Sprig function of the same name, which takes one argument per key, keys are given as a
+// single dotted path, so keys containing "." cannot be reached.
+func dig(path string, def any, m map[string]any) any {
+	var current any = m
+
+	for key := range strings.SplitSeq(path, ".") {
+		node, ok := current.(map[string]any)
+		if !ok {
+			return def
+		}
+
+		if current, ok = node[key]; !ok {
+			return def
+		}
+	}
+
+	return current
+}
+
+// hasKey reports whether m has key, even with a nil value, and never fails on absence, so
+// optional keys can be checked under missingkey=error: {{ if hasKey . "tls" }}. This
+// deliberately bypasses the missingkey strictness; m itself must be reachable.
+func hasKey(m map[string]any, key string) bool {
+	_, ok := m[key]
+
+	return ok
+}
+
+// get returns the value of key in m, or nil when m has no such key, without failing under
+// missingkey=error, deliberately bypassing its strictness: {{ with get . "tls" }}. Unlike the
+// Sprig function of the same name, which returns "" for missing keys, absence yields nil, so
+// it can be told apart from an empty string.
+func get(m map[string]any, key string) any {
+	return m[key]
+}
+
+// quantity parses v as a Kubernetes resource quantity and returns its canonical form, so
+// miswritten values fail the render instead of the apply: {{ quantity .cpu }} renders "0.1"
+// as "100m" and "1Gi" unchanged. v is a quantity string or a number.
+func quantity(v any) (string, error) {
+	var s string
+
+	switch val := v.(type) {
+	case string:
+		s = val
+	case float64:
+		s = strconv.FormatFloat(val, 'f', -1, 64)
+	case float32:
+		s = strconv.FormatFloat(float64(val), 'f', -1, 32)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, json.Number:
+		s = fmt.Sprint(val)
+	default:
+		return "", fmt.Errorf("invalid quantity %v: unsupported type %T", v, v)
+	}
+
+	q, err := resource.ParseQuantity(s)
+	if err != nil {
+		return "", fmt.Errorf("invalid quantity %q: %w", s, err)
+	}
+
+	return q.String(), nil
+}
+
+func join(sep string, v any) string {
+	switch val := v.(type) {
+	case []string:
+		return strings.Join(val, sep)
+	case []any:
+		out := make([]string, 0, len(val))
+		for _, s := range val {
+			if s != nil {
+				out = append(out, toString(s))
+			}
+		}
+
+		return strings.Join(out, sep)
+	default:
+		return toString(v)
+	}
+}
+
+func toString(v any) string {
+	switch val := v.(type) {
+	case string:
+		return val
+	case []byte:
+		return string(val)
+	case fmt.Stringer:
+		return val.String()
+	case nil:
+		return ""
+	default:
+		return fmt.Sprint(val)
+	}
+}
+
+// sha256sum returns the lowercase hex SHA-256 digest of the UTF-8 bytes of s.
+func sha256sum(s string) string {
+	sum := sha256.Sum256([]byte(s))
+
+	return hex.EncodeToString(sum[:])
+}
+
+func b64enc(s string) string {
+	return base64.StdEncoding.EncodeToString([]byte(s))
+}
+
+// b64dec decodes standard base64, returning an error rather than garbage on invalid input.
+func b64dec(s string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return "", fmt.Errorf("failed to decode base64 value: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// toYaml marshals v to YAML without the trailing newline so it composes with nindent.
+// Map keys are emitted in sorted order at every level (see stringKeys), and nil renders
+// as an empty string rather than "null".
+func toYaml(v any) (string, error) {
+	if v == nil {
+		return "", nil
+	}
+
+	data, err := yaml.Marshal(stringKeys(v))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value to YAML: %w", err)
+	}
+
+	return strings.TrimSuffix(string(data), "\n"), nil
+}
+
+// fromYaml parses a YAML document into maps, slices and scalars, so it can be ranged over
+// or indexed. Keys are converted to strings and numbers to float64, as for values files.
+func fromYaml(s string) (any, error) {
+	var out any
+	if err := yaml.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse YAML value: %w", err)
+	}
+
+	return out, nil
+}
+
+// fromJSON parses a JSON document into maps, slices and scalars, like fromYaml.
+func fromJSON(s string) (any, error) {
+	var out any
+	if err := json.Unmarshal([]byte(s), &out); err != nil {
+		return nil, fmt.Errorf("failed to parse JSON value: %w", err)
+	}
+
+	return out, nil
+}
+
+// toJSON marshals v to compact, single-line JSON, with map keys sorted at every level like
+// toYaml. Unlike toYaml, nil renders as "null".
+func toJSON(v any) (string, error) {
+	data, err := json.Marshal(stringKeys(v))
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value to JSON: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// toPrettyJSON marshals v to JSON indented by two spaces, with map keys sorted like toJSON.
+func toPrettyJSON(v any) (string, error) {
+	data, err := json.MarshalIndent(stringKeys(v), "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("failed to marshal value to JSON: %w", err)
+	}
+
+	return string(data), nil
+}
+
+// stringKeys converts map[any]any values nested anywhere in v, as returned by some YAML
+// decoders and custom functions, into map[string]any. encoding/json, which both toYaml and
+// toJSON marshal through, sorts the keys of string-keyed maps, so identical input always
+// serializes byte-for-byte identically, whatever the map iteration order.
+func stringKeys(v any) any {
+	switch val := v.(type) {
+	case map[any]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[toString(k)] = stringKeys(item)
+		}
+
+		return out
+	case map[string]any:
+		out := make(map[string]any, len(val))
+		for k, item := range val {
+			out[k] = stringKeys(item)
+		}
+
+		return out
+	case []any:
+		out := make([]any, len(val))
+		for i, item := range val {
+			out[i] = stringKeys(item)
+		}
+
+		return out
+	default:
+		return v
+	}
+}
+
+func dict(kv ...any) map[string]any {
+	out := make(map[string]any, len(kv)/2)
+	for i := 0; i+1 < len(kv); i += 2 {
+		out[toString(kv[i])] = kv[i+1]
+	}
+
+	return out
+}
+
+func keys(m map[string]any) []string {
+	return slices.Sorted(maps.Keys(m))
+}
@@ -0,0 +1,1334 @@
+package gotemplate_test
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"testing/fstest"
+	"text/template"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const sprigDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name | default "my-app" }}
+  labels: {{- toYaml .labels | nindent 4 }}
+spec:
+  replicas: {{ .replicas | default 1 }}
+  selector:
+    matchLabels: {{- toYaml .labels | nindent 6 }}
+  template:
+    metadata:
+      labels: {{- toYaml .labels | nindent 8 }}
+    spec:
+      containers:
+      - name: app
+        image: {{ .image | quote }}
+        resources: {{- toYaml .resources | nindent 10 }}
+`
+
+func TestSprigFunctions(t *testing.T) {
+
+	values := map[string]any{
+		"name":     "",
+		"replicas": 3,
+		"image":    "nginx:1.25",
+		"labels": map[string]any{
+			"app":  "web",
+			"tier": "frontend",
+		},
+		"resources": map[string]any{
+			"limits": map[string]any{
+				"cpu":    "500m",
+				"memory": "128Mi",
+			},
+		},
+	}
+
+	t.Run("should render manifest using default, toYaml and nindent", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"deployment.yaml.tpl": &fstest.MapFile{Data: []byte(sprigDeploymentTemplate)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+			gotemplate.WithSprigFunctions(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "my-app"`),
+			jqmatcher.Match(`.metadata.labels == {"app": "web", "tier": "frontend"}`),
+			jqmatcher.Match(`.spec.replicas == 3`),
+			jqmatcher.Match(`.spec.selector.matchLabels.app == "web"`),
+			jqmatcher.Match(`.spec.template.metadata.labels.tier == "frontend"`),
+			jqmatcher.Match(`.spec.template.spec.containers[0].image == "nginx:1.25"`),
+			jqmatcher.Match(`.spec.template.spec.containers[0].resources.limits.memory == "128Mi"`),
+		))
+	})
+
+	t.Run("should not register sprig functions by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"deployment.yaml.tpl": &fstest.MapFile{Data: []byte(`image: {{ .image | upper }}`)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(`function "upper" not defined`)))
+	})
+
+	t.Run("should not expose environment functions", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"env.yaml.tpl": &fstest.MapFile{Data: []byte(`home: {{ env "HOME" }}`)},
+					},
+					Path: "*.tpl",
+				},
+			},
+			gotemplate.WithSprigFunctions(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(`function "env" not defined`)))
+	})
+
+	t.Run("should allow WithFuncMap to override sprig functions", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"deployment.yaml.tpl": &fstest.MapFile{Data: []byte(sprigDeploymentTemplate)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+			gotemplate.WithFuncMap(template.FuncMap{
+				"default": func(_ any, _ ...any) any { return "overridden" },
+			}),
+			gotemplate.WithSprigFunctions(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "overridden"`),
+			jqmatcher.Match(`.spec.replicas == "overridden"`),
+		))
+	})
+}
+
+func TestToYaml(t *testing.T) {
+
+	render := func(
+		t *testing.T,
+		tmpl string,
+		values map[string]any,
+		opts ...gotemplate.RendererOption,
+	) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	resources := map[string]any{
+		"requests": map[string]any{"memory": "64Mi", "cpu": "250m"},
+		"limits":   map[string]any{"memory": "128Mi", "cpu": "500m"},
+	}
+
+	t.Run("should compose with nindent", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t,
+			"resources: {{- .resources | toYaml | nindent 4 }}",
+			map[string]any{"resources": resources},
+			gotemplate.WithSprigFunctions(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal(`resources:
+    limits:
+      cpu: 500m
+      memory: 128Mi
+    requests:
+      cpu: 250m
+      memory: 64Mi`))
+	})
+
+	t.Run("should be available without sprig functions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, "{{ toYaml .labels }}", map[string]any{"labels": map[string]any{"app": "web"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("app: web"))
+	})
+
+	t.Run("should produce stable output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first, err := render(t, "{{ toYaml .resources }}", map[string]any{"resources": resources})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 20 {
+			out, err := render(t, "{{ toYaml .resources }}", map[string]any{"resources": resources})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(out).To(Equal(first))
+		}
+	})
+
+	t.Run("should serialize nested maps deterministically", func(t *testing.T) {
+		g := NewWithT(t)
+
+		nested := map[string]any{
+			"zeta":  map[string]any{"b": []any{map[string]any{"q": 1, "p": 2}}, "a": true},
+			"alpha": map[any]any{"d": "four", 3: "three"},
+			"mid":   map[string]any{"k2": nil, "k1": "v1"},
+		}
+
+		const tmpl = `{{ toYaml .nested }}
+{{ toJson .nested }}`
+
+		first, err := render(t, tmpl, map[string]any{"nested": nested})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(first).To(Equal(`alpha:
+  "3": three
+  d: four
+mid:
+  k1: v1
+  k2: null
+zeta:
+  a: true
+  b:
+  - p: 2
+    q: 1
+{"alpha":{"3":"three","d":"four"},"mid":{"k1":"v1","k2":null},"zeta":{"a":true,"b":[{"p":2,"q":1}]}}`))
+
+		for range 50 {
+			out, err := render(t, tmpl, map[string]any{"nested": nested})
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(out).To(Equal(first))
+		}
+	})
+
+	t.Run("should render nil as empty string", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, "value: {{ toYaml .empty }}", map[string]any{"empty": nil})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("value: "))
+	})
+}
+
+func TestToJson(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	config := map[string]any{
+		"retries": 3,
+		"backend": map[string]any{"url": "http://svc", "headers": []any{"a", "b"}},
+	}
+
+	t.Run("should render compact JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ toJson .config }}`, map[string]any{"config": config})
+		g.Expect(out).To(Equal(`{"backend":{"headers":["a","b"],"url":"http://svc"},"retries":3}`))
+	})
+
+	t.Run("should render indented JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ toPrettyJson .config }}`, map[string]any{"config": config})
+		g.Expect(out).To(Equal(`{
+  "backend": {
+    "headers": [
+      "a",
+      "b"
+    ],
+    "url": "http://svc"
+  },
+  "retries": 3
+}`))
+	})
+
+	t.Run("should render nil and empty maps consistently", func(t *testing.T) {
+		g := NewWithT(t)
+
+		values := map[string]any{"empty": map[string]any{}, "none": nil}
+
+		g.Expect(render(t, `{{ toJson .none }} {{ toJson .empty }}`, values)).To(Equal("null {}"))
+		g.Expect(render(t, `{{ toPrettyJson .none }} {{ toPrettyJson .empty }}`, values)).To(Equal("null {}"))
+	})
+
+	t.Run("should produce stable output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		const tmpl = `{{ toJson .config }}{{ toPrettyJson .config }}`
+
+		first := render(t, tmpl, map[string]any{"config": config})
+		for range 20 {
+			g.Expect(render(t, tmpl, map[string]any{"config": config})).To(Equal(first))
+		}
+	})
+}
+
+func TestFromYaml(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should range over a parsed YAML map", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t,
+			`{{- range $k, $v := fromYaml .config }}{{ $k }}={{ $v }};{{ end }}`,
+			map[string]any{"config": "b: 2\na: one\n"},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("a=one;b=2;"))
+	})
+
+	t.Run("should index a parsed JSON document", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t,
+			`{{ $c := fromJson .config }}{{ index $c.ports 1 }} {{ len $c.ports }}`,
+			map[string]any{"config": `{"ports": [80, 443]}`},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("443 2"))
+	})
+
+	t.Run("should surface parse errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ fromYaml .config }}`, map[string]any{"config": "a: [unclosed"})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to parse YAML value")))
+		g.Expect(err).To(MatchError(ContainSubstring("template.tpl")))
+
+		_, err = render(t, `{{ fromJson .config }}`, map[string]any{"config": "{not json"})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to parse JSON value")))
+	})
+}
+
+func TestEncodingFunctions(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should hash with sha256sum", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ sha256sum .data }}`, map[string]any{"data": "hello"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("2cf24dba5fb0a30e26e83b2ac5b9e29e1b161e5c1fa7425e73043362938b9824"))
+	})
+
+	t.Run("should round-trip base64", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ b64enc .data }} {{ .data | b64enc | b64dec }}`, map[string]any{"data": "héllo"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("aMOpbGxv héllo"))
+	})
+
+	t.Run("should fail on invalid base64", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ b64dec .data }}`, map[string]any{"data": "not base64!"})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to decode base64 value")))
+	})
+}
+
+func TestNameFunctions(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	tests := []struct {
+		name     string
+		tmpl     string
+		values   map[string]any
+		expected string
+	}{
+		{
+			name:     "join and lowercase parts",
+			tmpl:     `{{ dnsName .release "Web" "" }}`,
+			values:   map[string]any{"release": "MyApp"},
+			expected: "myapp-web",
+		},
+		{
+			name:     "replace invalid characters",
+			tmpl:     `{{ dnsName .release }}`,
+			values:   map[string]any{"release": "_my.app_v1.2_"},
+			expected: "my-app-v1-2",
+		},
+		{
+			name:     "truncate overlong names to 63 characters",
+			tmpl:     `{{ dnsName .release "web" }}`,
+			values:   map[string]any{"release": strings.Repeat("a", 70)},
+			expected: strings.Repeat("a", 63),
+		},
+		{
+			name:     "trim hyphens left by dnsName truncation",
+			tmpl:     `{{ dnsName .release "web" }}`,
+			values:   map[string]any{"release": strings.Repeat("a", 62)},
+			expected: strings.Repeat("a", 62),
+		},
+		{
+			name:     "truncate with truncName",
+			tmpl:     `{{ .name | truncName 8 }}`,
+			values:   map[string]any{"name": "frontend-web"},
+			expected: "frontend",
+		},
+		{
+			name:     "trim hyphens left by truncName",
+			tmpl:     `{{ .name | truncName 9 }}`,
+			values:   map[string]any{"name": "frontend--web"},
+			expected: "frontend",
+		},
+		{
+			name:     "keep short names",
+			tmpl:     `{{ .name | truncName 63 }}`,
+			values:   map[string]any{"name": "web"},
+			expected: "web",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run("should "+tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(render(t, tt.tmpl, tt.values)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestQuoteFunctions(t *testing.T) {
+
+	const quotedTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: quoted
+data:
+{{- range $key, $value := .values }}
+  {{ $key }}: {{ $value | quote }}
+  {{ $key }}Single: {{ $value | squote }}
+{{- end }}
+`
+
+	values := map[string]any{
+		"norway":  "no",
+		"version": "1.0",
+		"date":    "2024-01-01",
+		"quotes":  `say "hi" and 'bye'`,
+		"escapes": `C:\path\to` + "\tfile\n",
+	}
+
+	source := func(tmpl string) gotemplate.Source {
+		return gotemplate.Source{
+			FS:     fstest.MapFS{"template.yaml": &fstest.MapFile{Data: []byte(tmpl)}},
+			Path:   "*.yaml",
+			Values: gotemplate.Values(map[string]any{"values": values}),
+		}
+	}
+
+	t.Run("should keep quoted values strings", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source(quotedTemplate)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		data, _, _ := unstructured.NestedStringMap(objects[0].Object, "data")
+		for key, value := range values {
+			g.Expect(data).To(HaveKeyWithValue(key, value))
+			g.Expect(data).To(HaveKeyWithValue(key+"Single", value))
+		}
+	})
+
+	t.Run("should escape embedded quotes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		tmpl := `{{ .values.quotes | quote }} {{ .values.quotes | squote }}`
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source(tmpl)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.yaml", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal(`"say \"hi\" and 'bye'" 'say "hi" and ''bye'''`))
+	})
+
+	t.Run("should be available without sprig functions", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source(`{{ quote .values.norway nil 1 }}`)})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.yaml", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal(`"no" "1"`))
+	})
+}
+
+func TestMergeFunctions(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	values := func() map[string]any {
+		return map[string]any{
+			"base": map[string]any{
+				"app":  "web",
+				"tier": "frontend",
+				"meta": map[string]any{"team": "a", "cost": "1"},
+			},
+			"extra": map[string]any{
+				"tier": "backend",
+				"meta": map[string]any{"team": "b", "zone": "eu"},
+			},
+		}
+	}
+
+	t.Run("should keep existing keys with merge", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ merge .base .extra | toJson }}`, values())
+		g.Expect(out).To(Equal(`{"app":"web","meta":{"cost":"1","team":"a","zone":"eu"},"tier":"frontend"}`))
+	})
+
+	t.Run("should overwrite existing keys with mergeOverwrite", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ mergeOverwrite .base .extra | toJson }}`, values())
+		g.Expect(out).To(Equal(`{"app":"web","meta":{"cost":"1","team":"b","zone":"eu"},"tier":"backend"}`))
+	})
+
+	t.Run("should not modify the inputs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ $_ := merge .base .extra }}{{ $_ := mergeOverwrite .base .extra }}{{ toJson . }}`, values())
+		g.Expect(out).To(MatchJSON(`{
+			"base": {"app": "web", "tier": "frontend", "meta": {"team": "a", "cost": "1"}},
+			"extra": {"tier": "backend", "meta": {"team": "b", "zone": "eu"}}
+		}`))
+	})
+
+	t.Run("should compose with toYaml for label blocks", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, "labels:{{ mergeOverwrite .base .extra | toYaml | nindent 2 }}", map[string]any{
+			"base":  map[string]any{"app": "web", "tier": "frontend"},
+			"extra": map[string]any{"tier": "db"},
+		})
+		g.Expect(out).To(Equal("labels:\n  app: web\n  tier: db"))
+	})
+
+	t.Run("should accept missing maps", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ merge .base nil | toJson }}`, map[string]any{"base": map[string]any{"app": "web"}})
+		g.Expect(out).To(Equal(`{"app":"web"}`))
+	})
+}
+
+func TestDigFunction(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path: "*.tpl",
+					Values: gotemplate.Values(map[string]any{
+						"image": map[string]any{
+							"registry": map[string]any{"host": "quay.io"},
+							"tag":      "",
+						},
+						"replicas": 3,
+					}),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	t.Run("should return the value of a present deep path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ dig "image.registry.host" "docker.io" . }}`)).To(Equal("quay.io"))
+	})
+
+	t.Run("should keep present empty values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `[{{ dig "image.tag" "latest" . }}]`)).To(Equal("[]"))
+	})
+
+	t.Run("should return the default for a missing path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ dig "image.pullPolicy" "IfNotPresent" . }}`)).To(Equal("IfNotPresent"))
+		g.Expect(render(t, `{{ dig "ingress.tls.secret" "none" . }}`)).To(Equal("none"))
+	})
+
+	t.Run("should return the default through non-map values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ dig "replicas.min" 1 . }}`)).To(Equal("1"))
+	})
+}
+
+func TestHasKeyAndGetFunctions(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path: "*.tpl",
+					Values: gotemplate.Values(map[string]any{
+						"tls":      map[string]any{"secretName": "web-tls"},
+						"replicas": 0,
+						"extra":    nil,
+					}),
+				},
+			},
+			gotemplate.WithMissingKeyMode(gotemplate.MissingKeyError),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	t.Run("should report present keys", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ hasKey . "tls" }} {{ hasKey . "replicas" }} {{ hasKey . "extra" }}`)).
+			To(Equal("true true true"))
+		g.Expect(render(t, `{{ if hasKey . "tls" }}{{ .tls.secretName }}{{ end }}`)).To(Equal("web-tls"))
+	})
+
+	t.Run("should report absent keys without failing", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ hasKey . "ingress" }} {{ hasKey .tls "hosts" }}`)).To(Equal("false false"))
+	})
+
+	t.Run("should get present values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ get .tls "secretName" }} {{ get . "replicas" }}`)).To(Equal("web-tls 0"))
+	})
+
+	t.Run("should get nil for absent keys without failing", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `{{ eq (get . "ingress") nil }}|{{ with get . "ingress" }}set{{ end }}`)).To(Equal("true|"))
+	})
+}
+
+func TestQuantityFunction(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should canonicalize quantity strings", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ quantity .cpu }} {{ quantity .fraction }} {{ quantity .memory }}`, map[string]any{
+			"cpu":      "100m",
+			"fraction": "0.1",
+			"memory":   "1Gi",
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("100m 100m 1Gi"))
+	})
+
+	t.Run("should accept numbers", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ quantity .cpu }} {{ quantity .replicas }}`, map[string]any{
+			"cpu":      0.5,
+			"replicas": 2,
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("500m 2"))
+	})
+
+	t.Run("should fail the render on invalid quantities", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ quantity .memory }}`, map[string]any{"memory": "1GB"})
+		g.Expect(err).To(MatchError(ContainSubstring(`invalid quantity "1GB"`)))
+
+		_, err = render(t, `{{ quantity .memory }}`, map[string]any{"memory": true})
+		g.Expect(err).To(MatchError(ContainSubstring("unsupported type bool")))
+	})
+}
+
+func TestIndent(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	t.Run("should indent every non-empty line", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ indent 2 .text }}`, map[string]any{"text": "a: 1\n\nb: 2"})
+		g.Expect(out).To(Equal("  a: 1\n\n  b: 2"))
+	})
+
+	t.Run("should not pad the line after a trailing newline", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `{{ indent 2 .text }}|`, map[string]any{"text": "a: 1\nb: 2\n"})
+		g.Expect(out).To(Equal("  a: 1\n  b: 2\n|"))
+	})
+
+	t.Run("should prepend a newline with nindent", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out := render(t, `data:{{ nindent 2 .text }}`, map[string]any{"text": "a: 1\n\nb: 2"})
+		g.Expect(out).To(Equal("data:\n  a: 1\n\n  b: 2"))
+	})
+
+	t.Run("should handle empty strings", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(render(t, `[{{ indent 4 "" }}]`, nil)).To(Equal("[]"))
+		g.Expect(render(t, `[{{ nindent 4 "" }}]`, nil)).To(Equal("[\n]"))
+	})
+}
+
+func TestDefault(t *testing.T) {
+
+	render := func(t *testing.T, tmpl string, values map[string]any) string {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+			gotemplate.WithMissingKeyMode(gotemplate.MissingKeyZero),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return string(out)
+	}
+
+	tests := []struct {
+		name     string
+		values   map[string]any
+		expected string
+	}{
+		{name: "nil", values: map[string]any{"value": nil}, expected: "fallback"},
+		{name: "missing key", values: map[string]any{}, expected: "fallback"},
+		{name: "empty string", values: map[string]any{"value": ""}, expected: "fallback"},
+		{name: "zero int", values: map[string]any{"value": 0}, expected: "fallback"},
+		{name: "false", values: map[string]any{"value": false}, expected: "fallback"},
+		{name: "empty list", values: map[string]any{"value": []any{}}, expected: "fallback"},
+		{name: "non-empty string", values: map[string]any{"value": "set"}, expected: "set"},
+		{name: "non-zero int", values: map[string]any{"value": 3}, expected: "3"},
+	}
+
+	for _, tt := range tests {
+		t.Run("should handle "+tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+
+			g.Expect(render(t, `{{ .value | default "fallback" }}`, tt.values)).To(Equal(tt.expected))
+		})
+	}
+}
+
+func TestRequired(t *testing.T) {
+
+	const requiredTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ required "name is required" .name }}
+`
+
+	process := func(t *testing.T, values map[string]any) ([]unstructured.Unstructured, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(requiredTemplate)},
+					},
+					Path: "*.yaml",
+				},
+			},
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer.Process(t.Context(), values)
+	}
+
+	t.Run("should fail on nil value", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := process(t, map[string]any{"name": nil})
+		g.Expect(err).To(MatchError(gotemplate.ErrRequiredValue))
+		g.Expect(err).To(MatchError(ContainSubstring("name is required")))
+		g.Expect(err).To(MatchError(ContainSubstring("configmap.yaml")))
+	})
+
+	t.Run("should fail on empty string", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := process(t, map[string]any{"name": ""})
+		g.Expect(err).To(MatchError(gotemplate.ErrRequiredValue))
+		g.Expect(err).To(MatchError(ContainSubstring("name is required")))
+	})
+
+	t.Run("should pass through populated value", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := process(t, map[string]any{"name": "app-config"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("app-config"))
+	})
+}
+
+func TestInclude(t *testing.T) {
+
+	const helpers = `{{- define "labels" -}}
+app: {{ .app }}
+tier: {{ .tier }}
+{{- end -}}`
+
+	const deployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .app }}
+  labels:
+{{ include "labels" . | indent 4 }}
+spec:
+  selector:
+    matchLabels: {{- include "labels" . | nindent 6 }}
+`
+
+	newRenderer := func(t *testing.T, tmpl string) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"_helpers.tpl":    &fstest.MapFile{Data: []byte(helpers)},
+						"deployment.yaml": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.yaml",
+					Paths:  []string{"*.tpl"},
+					Values: gotemplate.Values(map[string]any{"app": "web", "tier": "frontend"}),
+				},
+			},
+			gotemplate.WithSprigFunctions(),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should pipe included template output through indent", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, deployment)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.labels == {"app": "web", "tier": "frontend"}`),
+			jqmatcher.Match(`.spec.selector.matchLabels == {"app": "web", "tier": "frontend"}`),
+		))
+	})
+
+	t.Run("should fail clearly for a missing template", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `labels: {{ include "missing" . }}`)
+
+		_, err := renderer.Process(t.Context(), nil)
+
+		var notFound *gotemplate.TemplateNotFoundError
+		g.Expect(errors.As(err, &notFound)).To(BeTrue())
+		g.Expect(notFound.Name).To(Equal("missing"))
+		g.Expect(notFound.Available).To(ContainElement("labels"))
+	})
+}
+
+func TestTpl(t *testing.T) {
+
+	const configMap = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+data:
+  greeting: {{ tpl .greeting . | quote }}
+`
+
+	newRenderer := func(t *testing.T, tmpl string, values map[string]any) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"_helpers.tpl":   &fstest.MapFile{Data: []byte(`{{ define "suffix" }}!{{ end }}`)},
+						"configmap.yaml": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.yaml",
+					Paths:  []string{"*.tpl"},
+					Values: gotemplate.Values(values),
+				},
+			},
+			gotemplate.WithSprigFunctions(),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should render a values-supplied fragment with the current context", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, configMap, map[string]any{
+			"name":     "app",
+			"greeting": `Hello {{ .name | upper }}{{ include "suffix" . }}`,
+		})
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.greeting == "Hello APP!"`))
+	})
+
+	t.Run("should report parse errors in the fragment", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, configMap, map[string]any{
+			"name":     "app",
+			"greeting": `Hello {{ .name `,
+		})
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to parse tpl template")))
+	})
+
+	t.Run("should stop unbounded recursion", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, configMap, map[string]any{
+			"name":     "app",
+			"greeting": `{{ tpl .greeting . }}`,
+		})
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrMaxDepthExceeded))
+	})
+}
+
+func TestMaxIncludeDepth(t *testing.T) {
+
+	const helpers = `{{- define "loop" }}{{ include "loop" . }}{{ end -}}
+{{- define "ping" }}{{ include "pong" . }}{{ end -}}
+{{- define "pong" }}{{ include "ping" . }}{{ end -}}
+{{- define "outer" }}{{ include "inner" . }}{{ end -}}
+{{- define "inner" }}{{ .name }}{{ end -}}`
+
+	newRenderer := func(t *testing.T, tmpl string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		sources := make([]gotemplate.Source, 0, 4)
+		for range 4 {
+			sources = append(sources, gotemplate.Source{
+				FS: fstest.MapFS{
+					"_helpers.tpl":   &fstest.MapFile{Data: []byte(helpers)},
+					"configmap.yaml": &fstest.MapFile{Data: []byte(tmpl)},
+				},
+				Path:   "*.yaml",
+				Paths:  []string{"*.tpl"},
+				Values: gotemplate.Values(map[string]any{"name": "app"}),
+			})
+		}
+
+		renderer, err := gotemplate.New(sources, append(opts, gotemplate.WithParallelism(4))...)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should stop a self-including template at the default limit", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `# {{ include "loop" . }}`)
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrMaxDepthExceeded))
+
+		var depthErr *gotemplate.MaxDepthError
+		g.Expect(errors.As(err, &depthErr)).To(BeTrue())
+		g.Expect(depthErr.Limit).To(Equal(100))
+		g.Expect(depthErr.Chain).To(HaveLen(101))
+		g.Expect(depthErr.Chain).To(HaveEach("loop"))
+	})
+
+	t.Run("should report the chain of mutually recursive templates", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `# {{ include "ping" . }}`, gotemplate.WithMaxIncludeDepth(3))
+
+		_, err := renderer.Process(t.Context(), nil)
+
+		var depthErr *gotemplate.MaxDepthError
+		g.Expect(errors.As(err, &depthErr)).To(BeTrue())
+		g.Expect(depthErr.Limit).To(Equal(3))
+		g.Expect(depthErr.Chain).To(Equal([]string{"ping", "pong", "ping", "pong"}))
+		g.Expect(err).To(MatchError(ContainSubstring("ping -> pong -> ping -> pong")))
+	})
+
+	t.Run("should count tpl calls in the chain", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `# {{ tpl "{{ include \"outer\" . }}" . }}`, gotemplate.WithMaxIncludeDepth(2))
+
+		_, err := renderer.Process(t.Context(), nil)
+
+		var depthErr *gotemplate.MaxDepthError
+		g.Expect(errors.As(err, &depthErr)).To(BeTrue())
+		g.Expect(depthErr.Chain).To(Equal([]string{"tpl", "outer", "inner"}))
+	})
+
+	t.Run("should render nesting within the limit", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `name: {{ include "outer" . }}`, gotemplate.WithMaxIncludeDepth(2))
+
+		for range 2 {
+			out, err := renderer.RenderTemplate(t.Context(), "configmap.yaml", nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(string(out)).To(Equal("name: app"))
+		}
+	})
+}
+
+func TestLookup(t *testing.T) {
+
+	const secretTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+data:
+  {{- $existing := lookup "v1" "Secret" "default" .secret }}
+  {{- if $existing }}
+  password: {{ $existing.data.password }}
+  {{- else }}
+  password: Z2VuZXJhdGVk
+  {{- end }}
+`
+
+	errLookupFailed := errors.New("cluster unreachable")
+
+	stub := func(apiVersion string, kind string, namespace string, name string) (map[string]any, error) {
+		switch {
+		case kind == "Broken":
+			return nil, errLookupFailed
+		case apiVersion == "v1" && kind == "Secret" && namespace == "default" && name == "db":
+			return map[string]any{
+				"apiVersion": "v1",
+				"kind":       "Secret",
+				"metadata":   map[string]any{"name": "db", "namespace": "default"},
+				"data":       map[string]any{"password": "c2VjcmV0"},
+			}, nil
+		default:
+			return nil, apierrors.NewNotFound(schema.GroupResource{Resource: "secrets"}, name)
+		}
+	}
+
+	render := func(t *testing.T, tmpl string, values map[string]any, opts ...gotemplate.RendererOption) (string, error) {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.tpl": &fstest.MapFile{Data: []byte(tmpl)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(values),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		out, err := renderer.RenderTemplate(t.Context(), "template.tpl", nil)
+
+		return string(out), err
+	}
+
+	t.Run("should return objects found by the lookup func", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ (lookup "v1" "Secret" "default" "db").data.password }}`, nil,
+			gotemplate.WithLookupFunc(stub))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("c2VjcmV0"))
+	})
+
+	t.Run("should return an empty map for missing objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ lookup "v1" "Secret" "default" "missing" | len }}`, nil,
+			gotemplate.WithLookupFunc(stub))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("0"))
+	})
+
+	t.Run("should return an empty map without a lookup func", func(t *testing.T) {
+		g := NewWithT(t)
+
+		out, err := render(t, `{{ lookup "v1" "Secret" "default" "db" | len }}`, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(out).To(Equal("0"))
+	})
+
+	t.Run("should fail on lookup errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := render(t, `{{ lookup "v1" "Broken" "default" "db" }}`, nil, gotemplate.WithLookupFunc(stub))
+		g.Expect(err).To(MatchError(errLookupFailed))
+	})
+
+	t.Run("should render manifests from cluster state", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"secret.yaml": &fstest.MapFile{Data: []byte(secretTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"secret": "db"}),
+				},
+			},
+			gotemplate.WithLookupFunc(stub),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.password == "c2VjcmV0"`))
+
+		objects, err = renderer.Process(t.Context(), map[string]any{"secret": "missing"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.password == "Z2VuZXJhdGVk"`))
+	})
+}
@@ -0,0 +1,288 @@
+package gotemplate
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+const globStar = "**"
+
+// isRecursivePattern reports whether pattern uses doublestar ("**") segments.
+func isRecursivePattern(pattern string) bool {
+	return strings.Contains(pattern, globStar)
+}
+
+// globRecursive returns the files in fsys matching a doublestar-style pattern,
+// in lexical order. A "**" segment matches zero or more directories; all other
+// segments follow path.Match semantics.
+func globRecursive(fsys fs.FS, pattern string) ([]string, error) {
+	segments := strings.Split(pattern, "/")
+
+	// Validate every non-doublestar segment up front so malformed patterns
+	// fail even when the FS is empty
+	for _, seg := range segments {
+		if seg == globStar {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+		}
+	}
+
+	// Only walk the subtree below the static prefix of the pattern
+	root := "."
+	for i, seg := range segments {
+		if seg == globStar || strings.ContainsAny(seg, `*?[\`) {
+			root = path.Join(append([]string{"."}, segments[:i]...)...)
+
+			break
+		}
+	}
+
+	matches := make([]string, 0)
+
+	err := fs.WalkDir(fsys, root, func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if name == root && errors.Is(err, fs.ErrNotExist) {
+				return fs.SkipAll
+			}
+
+			return err
+		}
+
+		if !d.IsDir() && matchSegments(segments, strings.Split(name, "/")) {
+			matches = append(matches, name)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to walk %q: %w", root, err)
+	}
+
+	return matches, nil
+}
+
+// matchSegments matches path segments against pattern segments, expanding "**".
+func matchSegments(pattern []string, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == globStar {
+			// Collapse consecutive doublestars and try every possible split
+			for len(pattern) > 0 && pattern[0] == globStar {
+				pattern = pattern[1:]
+			}
+			if len(pattern) == 0 {
+				return true
+			}
+			for i := range name {
+				if matchSegments(pattern, name[i:]) {
+					return true
+				}
+			}
+
+			return false
+		}
+
+		if len(name) == 0 {
+			return false
+		}
+
+		// Pattern syntax was validated in globRecursive
+		if ok, _ := path.Match(pattern[0], name[0]); !ok {
+			return false
+		}
+
+		pattern = pattern[1:]
+		name = name[1:]
+	}
+
+	return len(name) == 0
+}
+
+// globFiles returns the files in fsys matching pattern, in lexical order.
+func globFiles(fsys fs.FS, pattern string) ([]string, error) {
+	if isRecursivePattern(pattern) {
+		return globRecursive(fsys, pattern)
+	}
+
+	files, err := fs.Glob(fsys, pattern)
+	if errors.Is(err, path.ErrBadPattern) {
+		return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to match %q: %w", pattern, err)
+	}
+
+	return files, nil
+}
+
+// templateName returns the name under which a matched file is registered.
+// Doublestar patterns use the path relative to the FS root, which keeps names
+// unique across directories; single-level globs keep the ParseFS convention of
+// naming templates by base name.
+func templateName(pattern string, file string) string {
+	if isRecursivePattern(pattern) {
+		return file
+	}
+
+	return path.Base(file)
+}
+
+// templateFile is a matched template file and the name it is registered under.
+type templateFile struct {
+	name string
+	file string
+}
+
+// parseFiles parses every file matching any of patterns into tmpl.
+// Each pattern must match at least one file. A file matched by several patterns
+// is parsed once; distinct files resolving to the same template name are rejected.
+// The file registered as first, if any, is parsed before the others, so their
+// {{ define }} blocks override its {{ block }} defaults. With normalize, the content
+// goes through normalizeTemplate before parsing. Files matching any of the passthrough
+// patterns are added verbatim, without parsing.
+func parseFiles(
+	tmpl *template.Template,
+	fsys fs.FS,
+	patterns []string,
+	first string,
+	normalize bool,
+	passthrough []string,
+) error {
+	files, err := matchTemplateFiles(fsys, patterns)
+	if err != nil {
+		return err
+	}
+
+	if i := slices.IndexFunc(files, func(f templateFile) bool { return f.name == first }); i > 0 {
+		files = slices.Concat(files[i:i+1], files[:i], files[i+1:])
+	}
+
+	for _, f := range files {
+		if tmpl.Lookup(f.name) != nil {
+			return fmt.Errorf("%w: %s (already defined by another template)", ErrDuplicateTemplate, f.name)
+		}
+
+		content, err := fs.ReadFile(fsys, f.file)
+		if err != nil {
+			return fmt.Errorf("failed to read template %s: %w", f.file, err)
+		}
+
+		if normalize {
+			content = normalizeTemplate(content)
+		}
+
+		if isPassthrough(passthrough, f.file) {
+			if _, err := tmpl.New(f.name).AddParseTree(f.name, verbatimTree(f.name, content)); err != nil {
+				return fmt.Errorf("failed to add passthrough file %s: %w", f.file, err)
+			}
+
+			continue
+		}
+
+		if _, err := tmpl.New(f.name).Parse(string(content)); err != nil {
+			return fmt.Errorf("failed to parse template %s: %w", f.file, err)
+		}
+	}
+
+	return nil
+}
+
+// isPassthrough reports whether file matches any of the passthrough patterns: patterns
+// containing "/" match the file path, supporting "**", the others match its base name.
+// Patterns were validated by validatePassthrough.
+func isPassthrough(patterns []string, file string) bool {
+	for _, pattern := range patterns {
+		if strings.Contains(pattern, "/") {
+			if matchSegments(strings.Split(pattern, "/"), strings.Split(file, "/")) {
+				return true
+			}
+
+			continue
+		}
+
+		if ok, _ := path.Match(pattern, path.Base(file)); ok {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validatePassthrough checks the syntax of a WithPassthrough pattern.
+func validatePassthrough(pattern string) error {
+	for _, seg := range strings.Split(pattern, "/") {
+		if seg == globStar {
+			continue
+		}
+		if _, err := path.Match(seg, ""); err != nil {
+			return fmt.Errorf("invalid passthrough pattern %q: %w", pattern, err)
+		}
+	}
+
+	return nil
+}
+
+// verbatimTree returns a template tree made of a single text node, which executes to content
+// as is, whatever actions or delimiters it contains.
+func verbatimTree(name string, content []byte) *parse.Tree {
+	return &parse.Tree{
+		Name:      name,
+		ParseName: name,
+		Root: &parse.ListNode{
+			NodeType: parse.NodeList,
+			Nodes:    []parse.Node{&parse.TextNode{NodeType: parse.NodeText, Text: content}},
+		},
+	}
+}
+
+// normalizeTemplate strips a leading UTF-8 byte order mark and converts CRLF line endings to
+// LF, so templates authored on Windows do not render stray "\r" characters into the YAML.
+func normalizeTemplate(content []byte) []byte {
+	content = bytes.TrimPrefix(content, []byte("\uFEFF"))
+
+	return bytes.ReplaceAll(content, []byte("\r\n"), []byte("\n"))
+}
+
+// matchTemplateFiles returns the files matching any of patterns in match order, each once.
+// Each pattern must match at least one file, and distinct files resolving to the same
+// template name are rejected.
+func matchTemplateFiles(fsys fs.FS, patterns []string) ([]templateFile, error) {
+	parsed := make(map[string]string)
+	result := make([]templateFile, 0)
+
+	for _, pattern := range patterns {
+		files, err := globFiles(fsys, pattern)
+		if err != nil {
+			return nil, err
+		}
+		if len(files) == 0 {
+			return nil, fmt.Errorf("%w: %s", ErrNoMatchingTemplates, pattern)
+		}
+
+		for _, file := range files {
+			name := templateName(pattern, file)
+
+			if existing, ok := parsed[name]; ok {
+				if existing == file {
+					continue
+				}
+
+				return nil, fmt.Errorf("%w: %s (from %s and %s)", ErrDuplicateTemplate, name, existing, file)
+			}
+
+			parsed[name] = file
+			result = append(result, templateFile{name: name, file: file})
+		}
+	}
+
+	return result, nil
+}
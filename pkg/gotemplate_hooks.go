@@ -0,0 +1,87 @@
+package gotemplate
+
+import (
+	"bytes"
+	"context"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+)
+
+// BeforeRenderHook is called by Process before a Source is rendered, with the spec identifying
+// the render: the Source name, path and FS identity and the merged values, nil when the Source
+// templates or values failed to load.
+type BeforeRenderHook func(ctx context.Context, spec TemplateSpec)
+
+// AfterRenderHook is called by Process once a Source is rendered, with the spec passed to the
+// BeforeRenderHook hooks, the output of the Source templates and the render error, if any.
+// output is nil when the render failed or its result came from the render cache.
+type AfterRenderHook func(ctx context.Context, spec TemplateSpec, output []byte, err error)
+
+// renderHooks invokes the WithBeforeRender and WithAfterRender hooks around one Source render.
+// The hooks see copies of the values and output, so they cannot alter the render.
+type renderHooks struct {
+	spec   TemplateSpec
+	after  []AfterRenderHook
+	output *bytes.Buffer
+}
+
+// startRender invokes the WithBeforeRender hooks for spec and returns the hooks to finish the
+// render with, nil without hooks.
+func (r *Renderer) startRender(ctx context.Context, spec TemplateSpec) *renderHooks {
+	if len(r.opts.BeforeRender) == 0 && len(r.opts.AfterRender) == 0 {
+		return nil
+	}
+
+	if values, ok := spec.Values.(map[string]any); ok {
+		spec.Values = util.DeepMerge(nil, values)
+	}
+
+	for _, hook := range r.opts.BeforeRender {
+		hook(ctx, spec)
+	}
+
+	h := &renderHooks{
+		spec:  spec,
+		after: r.opts.AfterRender,
+	}
+	if len(h.after) > 0 {
+		h.output = &bytes.Buffer{}
+	}
+
+	return h
+}
+
+// failRender reports a Source render failing before its templates run, because they or its
+// values failed to load, to both hooks with spec lacking Values, and returns err.
+func (r *Renderer) failRender(ctx context.Context, spec TemplateSpec, err error) error {
+	r.startRender(ctx, spec).Finish(ctx, false, err)
+
+	return err
+}
+
+// Output returns the buffer collecting the output of the Source templates, nil when no
+// WithAfterRender hook needs it.
+func (h *renderHooks) Output() *bytes.Buffer {
+	if h == nil {
+		return nil
+	}
+
+	return h.output
+}
+
+// Finish invokes the WithAfterRender hooks. cached tells whether the result came from the
+// render cache, in which case no output was produced.
+func (h *renderHooks) Finish(ctx context.Context, cached bool, err error) {
+	if h == nil || len(h.after) == 0 {
+		return
+	}
+
+	var output []byte
+	if !cached && err == nil {
+		output = bytes.Clone(h.output.Bytes())
+	}
+
+	for _, hook := range h.after {
+		hook(ctx, h.spec, bytes.Clone(output), err)
+	}
+}
@@ -0,0 +1,196 @@
+package gotemplate_test
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// renderEvent is a hook invocation recorded by hookRecorder.
+type renderEvent struct {
+	hook   string
+	spec   gotemplate.TemplateSpec
+	output string
+	err    error
+}
+
+// hookRecorder records the WithBeforeRender and WithAfterRender hook invocations.
+type hookRecorder struct {
+	mu     sync.Mutex
+	events []renderEvent
+}
+
+func (h *hookRecorder) options() []gotemplate.RendererOption {
+	return []gotemplate.RendererOption{
+		gotemplate.WithBeforeRender(func(_ context.Context, spec gotemplate.TemplateSpec) {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			h.events = append(h.events, renderEvent{hook: "before", spec: spec})
+		}),
+		gotemplate.WithAfterRender(func(_ context.Context, spec gotemplate.TemplateSpec, output []byte, err error) {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+
+			h.events = append(h.events, renderEvent{hook: "after", spec: spec, output: string(output), err: err})
+		}),
+	}
+}
+
+func TestRenderHooks(t *testing.T) {
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .name }}\n"
+
+	sources := func() []gotemplate.Source {
+		return []gotemplate.Source{
+			{
+				Name:   "first",
+				FS:     fstest.MapFS{"a.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+				Path:   "a.yaml",
+				Values: gotemplate.Values(map[string]any{"name": "a"}),
+			},
+			{
+				Name:   "second",
+				FS:     fstest.MapFS{"b.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+				Path:   "b.yaml",
+				Values: gotemplate.Values(map[string]any{"name": "b"}),
+			},
+		}
+	}
+
+	t.Run("should call both hooks with the spec of every Source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := &hookRecorder{}
+
+		renderer, err := gotemplate.New(sources(), recorder.options()...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), map[string]any{"env": "prod"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(recorder.events).To(HaveLen(4))
+
+		for i, name := range []string{"first", "second"} {
+			before, after := recorder.events[2*i], recorder.events[2*i+1]
+
+			g.Expect(before.hook).To(Equal("before"))
+			g.Expect(before.spec.Name).To(Equal(name))
+			g.Expect(before.spec.Values).To(HaveKeyWithValue("env", "prod"))
+
+			g.Expect(after.hook).To(Equal("after"))
+			g.Expect(after.spec).To(Equal(before.spec))
+			g.Expect(after.err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(recorder.events[0].spec.Path).To(Equal("a.yaml"))
+		g.Expect(recorder.events[1].output).To(Equal("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n"))
+		g.Expect(recorder.events[3].output).To(Equal("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: b\n"))
+	})
+
+	t.Run("should pass the render error to the after hook", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := &hookRecorder{}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{
+				FS:   fstest.MapFS{"a.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+				Path: "a.yaml",
+			}},
+			recorder.options()...,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(HaveOccurred())
+
+		g.Expect(recorder.events).To(HaveLen(2))
+		g.Expect(recorder.events[1].output).To(BeEmpty())
+		g.Expect(recorder.events[1].err).To(HaveOccurred())
+		g.Expect(err).To(MatchError(recorder.events[1].err))
+	})
+
+	t.Run("should pass load failures to both hooks", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for name, source := range map[string]gotemplate.Source{
+			"broken template": {
+				Name: "broken",
+				FS:   fstest.MapFS{"a.yaml": &fstest.MapFile{Data: []byte("name: {{ .name ")}},
+				Path: "a.yaml",
+			},
+			"failing values file": {
+				Name: "broken",
+				FS: fstest.MapFS{
+					"a.yaml":      &fstest.MapFile{Data: []byte(configMap)},
+					"values.yaml": &fstest.MapFile{Data: []byte("name: [unterminated\n")},
+				},
+				Path:        "a.yaml",
+				ValuesFiles: []string{"values.yaml"},
+			},
+		} {
+			recorder := &hookRecorder{}
+
+			renderer, err := gotemplate.New([]gotemplate.Source{source}, recorder.options()...)
+			g.Expect(err).ToNot(HaveOccurred(), name)
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).To(HaveOccurred(), name)
+
+			g.Expect(recorder.events).To(HaveLen(2), name)
+			g.Expect(recorder.events[0].hook).To(Equal("before"), name)
+			g.Expect(recorder.events[0].spec.Name).To(Equal("broken"), name)
+			g.Expect(recorder.events[0].spec.Values).To(BeNil(), name)
+			g.Expect(recorder.events[1].hook).To(Equal("after"), name)
+			g.Expect(recorder.events[1].spec).To(Equal(recorder.events[0].spec), name)
+			g.Expect(recorder.events[1].output).To(BeEmpty(), name)
+			g.Expect(err).To(MatchError(recorder.events[1].err), name)
+		}
+	})
+
+	t.Run("should pass no output for cached renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := &hookRecorder{}
+
+		renderer, err := gotemplate.New(sources()[:1], append(recorder.options(), gotemplate.WithCache())...)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(recorder.events).To(HaveLen(4))
+		g.Expect(recorder.events[1].output).ToNot(BeEmpty())
+		g.Expect(recorder.events[3].output).To(BeEmpty())
+		g.Expect(recorder.events[3].err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should not let hooks alter the render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			sources()[:1],
+			gotemplate.WithBeforeRender(func(_ context.Context, spec gotemplate.TemplateSpec) {
+				values, _ := spec.Values.(map[string]any)
+				values["name"] = "changed"
+			}),
+			gotemplate.WithAfterRender(func(_ context.Context, _ gotemplate.TemplateSpec, output []byte, _ error) {
+				clear(output)
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("a"))
+	})
+}
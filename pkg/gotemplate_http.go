@@ -0,0 +1,253 @@
+package gotemplate
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"sync"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+)
+
+// defaultHTTPMaxSize bounds the size of an HTTPSource template, 64 MiB.
+const defaultHTTPMaxSize = 64 << 20
+
+// HTTPOption is a generic option for HTTPOptions.
+type HTTPOption = util.Option[HTTPOptions]
+
+// HTTPOptions configures how HTTPSource fetches a remote template.
+type HTTPOptions struct {
+	// Client performs the request. nil = http.DefaultClient.
+	Client *http.Client
+
+	// Header holds additional request headers.
+	Header http.Header
+
+	// Username and Password enable HTTP basic authentication when Username is non-empty.
+	Username string
+	Password string
+
+	// MaxSize bounds the size of the response body in bytes. Zero = 64 MiB.
+	MaxSize int64
+}
+
+// ApplyTo applies the HTTP options to the target configuration.
+func (opts HTTPOptions) ApplyTo(target *HTTPOptions) {
+	if opts.Client != nil {
+		target.Client = opts.Client
+	}
+
+	if len(opts.Header) > 0 {
+		if target.Header == nil {
+			target.Header = make(http.Header, len(opts.Header))
+		}
+		maps.Copy(target.Header, opts.Header)
+	}
+
+	if opts.Username != "" {
+		target.Username = opts.Username
+		target.Password = opts.Password
+	}
+
+	if opts.MaxSize > 0 {
+		target.MaxSize = opts.MaxSize
+	}
+}
+
+// WithHTTPClient sets the client used to fetch the template, e.g. to configure TLS or timeouts.
+func WithHTTPClient(client *http.Client) HTTPOption {
+	return util.FunctionalOption[HTTPOptions](func(opts *HTTPOptions) {
+		opts.Client = client
+	})
+}
+
+// WithHTTPHeader adds a request header, e.g. an API token. Multiple values for the same key
+// are all sent.
+func WithHTTPHeader(key string, value string) HTTPOption {
+	return util.FunctionalOption[HTTPOptions](func(opts *HTTPOptions) {
+		if opts.Header == nil {
+			opts.Header = make(http.Header)
+		}
+
+		opts.Header.Add(key, value)
+	})
+}
+
+// WithHTTPBasicAuth authenticates the request with HTTP basic authentication.
+func WithHTTPBasicAuth(username string, password string) HTTPOption {
+	return util.FunctionalOption[HTTPOptions](func(opts *HTTPOptions) {
+		opts.Username = username
+		opts.Password = password
+	})
+}
+
+// WithHTTPMaxSize bounds the size of the fetched template, so a misbehaving server cannot
+// exhaust memory. Default: 64 MiB.
+func WithHTTPMaxSize(n int64) HTTPOption {
+	return util.FunctionalOption[HTTPOptions](func(opts *HTTPOptions) {
+		opts.MaxSize = n
+	})
+}
+
+// HTTPSource returns a Source serving the template at rawURL, fetched on first use and kept in
+// memory. Any non-2xx response fails with ErrUnexpectedHTTPStatus and bodies larger than the
+// maximum size (WithHTTPMaxSize) with ErrTemplateTooLarge; as New validates Source patterns, these
+// errors surface from New, or from the first render with WithLazyValidation. Malformed URLs fail
+// here with ErrInvalidSourceURL.
+//
+// The fetch runs with ctx, as fs.FS carries no context of its own, so ctx must outlive the
+// renderers using the Source: pass a long-lived context, not a request-scoped one, and bound
+// the fetch with WithHTTPClient timeouts instead.
+//
+// The template is exposed under its URL host and path (e.g. "example.com/templates/app.yaml"),
+// which becomes the Source pattern, so render cache entries, source annotations and errors are
+// keyed by URL while the template itself is named after the file, as for local Sources. The
+// content is fetched once it succeeds; failed fetches, e.g. on a transient network error, are
+// retried on the next load. The renderer caches parsed templates and render results as usual.
+// Call HTTPSource again to pick up remote changes.
+func HTTPSource(ctx context.Context, rawURL string, opts ...HTTPOption) (Source, error) {
+	httpOpts := HTTPOptions{
+		MaxSize: defaultHTTPMaxSize,
+	}
+	for _, opt := range opts {
+		opt.ApplyTo(&httpOpts)
+	}
+
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return Source{}, fmt.Errorf("%w: %w", ErrInvalidSourceURL, err)
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return Source{}, fmt.Errorf("%w: unsupported scheme %q in %s", ErrInvalidSourceURL, u.Scheme, rawURL)
+	}
+
+	file := strings.TrimPrefix(path.Clean("/"+u.Path), "/")
+	if u.Host == "" || file == "" {
+		return Source{}, fmt.Errorf("%w: %s does not reference a file", ErrInvalidSourceURL, rawURL)
+	}
+
+	name := path.Join(u.Host, file)
+
+	return Source{
+		FS: &httpFS{
+			name:  name,
+			fetch: func() ([]byte, error) { return fetchHTTP(ctx, u, httpOpts) },
+		},
+		Path: escapeGlob(name),
+	}, nil
+}
+
+// httpFS is an fs.FS serving a single template fetched over HTTP on its first successful Open.
+type httpFS struct {
+	name  string
+	fetch func() ([]byte, error)
+
+	// Mutex protects files, nil until a fetch succeeds
+	mu    sync.Mutex
+	files memFS
+}
+
+func (h *httpFS) Open(name string) (fs.File, error) {
+	files, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return files.Open(name)
+}
+
+// Glob implements fs.GlobFS so fetch errors fail pattern matching instead of matching nothing.
+func (h *httpFS) Glob(pattern string) ([]string, error) {
+	files, err := h.load()
+	if err != nil {
+		return nil, err
+	}
+
+	return fs.Glob(files, pattern)
+}
+
+// load returns the files serving the template, fetching it until a fetch succeeds.
+func (h *httpFS) load() (memFS, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.files != nil {
+		return h.files, nil
+	}
+
+	data, err := h.fetch()
+	if err != nil {
+		return nil, err
+	}
+
+	h.files = memFS{h.name: data}
+
+	return h.files, nil
+}
+
+func fetchHTTP(ctx context.Context, u *url.URL, opts HTTPOptions) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request for %s: %w", u.Redacted(), err)
+	}
+
+	for key, values := range opts.Header {
+		for _, v := range values {
+			req.Header.Add(key, v)
+		}
+	}
+
+	if opts.Username != "" {
+		req.SetBasicAuth(opts.Username, opts.Password)
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch template %s: %w", u.Redacted(), err)
+	}
+
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("%w: %s returned %s", ErrUnexpectedHTTPStatus, u.Redacted(), resp.Status)
+	}
+
+	// Read one byte past the limit, so reaching it tells exceeding bodies apart
+	limited := &io.LimitedReader{R: resp.Body, N: opts.MaxSize + 1}
+
+	data, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read template %s: %w", u.Redacted(), err)
+	}
+
+	if limited.N <= 0 {
+		return nil, fmt.Errorf("%w: %s is larger than %d bytes", ErrTemplateTooLarge, u.Redacted(), opts.MaxSize)
+	}
+
+	return data, nil
+}
+
+// escapeGlob escapes the glob metacharacters of name so it matches only itself.
+func escapeGlob(name string) string {
+	var b strings.Builder
+	for _, r := range name {
+		if strings.ContainsRune(`*?[\`, r) {
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,230 @@
+package gotemplate_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"path"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const remoteTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+`
+
+func newTemplateServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/templates/configmap.yaml", func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = w.Write([]byte(remoteTemplate))
+	})
+	mux.HandleFunc("/private/configmap.yaml", func(w http.ResponseWriter, r *http.Request) {
+		user, pass, ok := r.BasicAuth()
+		if !ok || user != "user" || pass != "secret" || r.Header.Get("X-Tenant") != "acme" {
+			w.WriteHeader(http.StatusUnauthorized)
+
+			return
+		}
+
+		_, _ = w.Write([]byte(remoteTemplate))
+	})
+
+	server := httptest.NewServer(mux)
+	t.Cleanup(server.Close)
+
+	return server
+}
+
+// processHTTPSource renders the template at rawURL, returning the first fetch or render error.
+func processHTTPSource(t *testing.T, rawURL string, opts ...gotemplate.HTTPOption) error {
+	t.Helper()
+
+	source, err := gotemplate.HTTPSource(t.Context(), rawURL, opts...)
+	if err != nil {
+		return err
+	}
+
+	source.Values = gotemplate.Values(map[string]any{"name": "remote"})
+
+	renderer, err := gotemplate.New([]gotemplate.Source{source})
+	if err != nil {
+		return err
+	}
+
+	_, err = renderer.Process(t.Context(), nil)
+
+	return err
+}
+
+func TestHTTPSource(t *testing.T) {
+
+	t.Run("should render a fetched template", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		source, err := gotemplate.HTTPSource(t.Context(), server.URL+"/templates/configmap.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		source.Values = gotemplate.Values(map[string]any{"name": "remote"})
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source}, gotemplate.WithSourceAnnotations(true))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "remote"`))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceFile, "configmap.yaml"))
+	})
+
+	t.Run("should key the Source by URL", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		source, err := gotemplate.HTTPSource(t.Context(), server.URL+"/templates/configmap.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		u, err := url.Parse(server.URL)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		name := path.Join(u.Host, "templates/configmap.yaml")
+		g.Expect(source.Path).To(Equal(name))
+		g.Expect(fstest.TestFS(source.FS, name)).To(Succeed())
+	})
+
+	t.Run("should send basic auth and custom headers", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		err := processHTTPSource(t, server.URL+"/private/configmap.yaml")
+		g.Expect(err).To(MatchError(gotemplate.ErrUnexpectedHTTPStatus))
+
+		err = processHTTPSource(t, server.URL+"/private/configmap.yaml",
+			gotemplate.WithHTTPBasicAuth("user", "secret"),
+			gotemplate.WithHTTPHeader("X-Tenant", "acme"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should fail on missing templates", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		err := processHTTPSource(t, server.URL+"/templates/missing.yaml")
+		g.Expect(err).To(MatchError(gotemplate.ErrUnexpectedHTTPStatus))
+		g.Expect(err).To(MatchError(ContainSubstring("404")))
+	})
+
+	t.Run("should fetch once on first use", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			requests.Add(1)
+			_, _ = w.Write([]byte(remoteTemplate))
+		}))
+		t.Cleanup(server.Close)
+
+		source, err := gotemplate.HTTPSource(t.Context(), server.URL+"/configmap.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(requests.Load()).To(BeZero())
+
+		source.Values = gotemplate.Values(map[string]any{"name": "remote"})
+
+		for range 2 {
+			renderer, err := gotemplate.New([]gotemplate.Source{source}, gotemplate.WithCache())
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(requests.Load()).To(Equal(int32(1)))
+	})
+
+	t.Run("should retry failed fetches", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var requests atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+			if requests.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			_, _ = w.Write([]byte(remoteTemplate))
+		}))
+		t.Cleanup(server.Close)
+
+		source, err := gotemplate.HTTPSource(t.Context(), server.URL+"/configmap.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		source.Values = gotemplate.Values(map[string]any{"name": "remote"})
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source}, gotemplate.WithLazyValidation())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrUnexpectedHTTPStatus))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(requests.Load()).To(Equal(int32(2)))
+	})
+
+	t.Run("should bound the template size", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		err := processHTTPSource(t, server.URL+"/templates/configmap.yaml",
+			gotemplate.WithHTTPMaxSize(int64(len(remoteTemplate)-1)),
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrTemplateTooLarge))
+
+		err = processHTTPSource(t, server.URL+"/templates/configmap.yaml",
+			gotemplate.WithHTTPMaxSize(int64(len(remoteTemplate))),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should respect context cancellation", func(t *testing.T) {
+		g := NewWithT(t)
+		server := newTemplateServer(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		source, err := gotemplate.HTTPSource(ctx, server.URL+"/templates/configmap.yaml")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = gotemplate.New([]gotemplate.Source{source})
+		g.Expect(err).To(MatchError(context.Canceled))
+	})
+
+	t.Run("should reject invalid URLs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, rawURL := range []string{"ftp://example.com/a.yaml", "https://example.com/", "://bad"} {
+			_, err := gotemplate.HTTPSource(t.Context(), rawURL)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidSourceURL), rawURL)
+		}
+	})
+}
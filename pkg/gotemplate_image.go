@@ -0,0 +1,28 @@
+package gotemplate
+
+import (
+	"context"
+	"fmt"
+)
+
+// ImageResolver resolves an image reference, e.g. "repo/image:tag", to a reference pinned by
+// digest, e.g. "repo/image@sha256:...", by querying a registry or a static map. It is called
+// once per imageDigest call, so resolvers querying registries should cache their results.
+type ImageResolver func(ctx context.Context, ref string) (string, error)
+
+// imageDigestFunc returns the imageDigest function resolving references with resolve under
+// ctx. Without resolve references are returned unchanged, so dry-renders work.
+func imageDigestFunc(ctx context.Context, resolve ImageResolver) func(string) (string, error) {
+	return func(ref string) (string, error) {
+		if resolve == nil {
+			return ref, nil
+		}
+
+		resolved, err := resolve(ctx, ref)
+		if err != nil {
+			return "", fmt.Errorf("failed to resolve image %s: %w", ref, err)
+		}
+
+		return resolved, nil
+	}
+}
@@ -0,0 +1,113 @@
+package gotemplate_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const imageDeploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: {{ imageDigest .image }}
+`
+
+type resolverKey struct{}
+
+func TestImageResolver(t *testing.T) {
+
+	const digest = "quay.io/org/web@sha256:0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef"
+
+	stub := func(ctx context.Context, ref string) (string, error) {
+		if ctx.Value(resolverKey{}) == nil {
+			return "", errors.New("missing render context")
+		}
+
+		digests := map[string]string{"quay.io/org/web:v1": digest}
+		if resolved, ok := digests[ref]; ok {
+			return resolved, nil
+		}
+
+		return "", errors.New("unknown image")
+	}
+
+	newRenderer := func(t *testing.T, image string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fstest.MapFS{"deployment.yaml": &fstest.MapFile{Data: []byte(imageDeploymentTemplate)}},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"image": image}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	ctx := func(t *testing.T) context.Context {
+		t.Helper()
+
+		return context.WithValue(t.Context(), resolverKey{}, true)
+	}
+
+	t.Run("should resolve tags to digests", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, "quay.io/org/web:v1", gotemplate.WithImageResolver(stub))
+
+		objects, err := renderer.Process(ctx(t), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.spec.template.spec.containers[0].image == "%s"`, digest))
+	})
+
+	t.Run("should return references unchanged without a resolver", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, "quay.io/org/web:v1").Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(
+			`.spec.template.spec.containers[0].image == "quay.io/org/web:v1"`,
+		))
+	})
+
+	t.Run("should fail the render on resolve errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, "quay.io/org/other:v1", gotemplate.WithImageResolver(stub))
+
+		_, err := renderer.Process(ctx(t), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to resolve image quay.io/org/other:v1: unknown image")))
+	})
+
+	t.Run("should keep user functions over the resolver", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, "quay.io/org/web:v1",
+			gotemplate.WithImageResolver(stub),
+			gotemplate.WithFuncMap(map[string]any{"imageDigest": func(string) string { return "pinned" }}),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.spec.template.spec.containers[0].image == "pinned"`))
+	})
+}
@@ -0,0 +1,75 @@
+package gotemplate
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// resourceListAPIVersion and resourceListKind identify the ResourceList written with
+	// WithKRMOutput, as defined by the KRM Functions specification.
+	resourceListAPIVersion = "config.kubernetes.io/v1"
+	resourceListKind       = "ResourceList"
+)
+
+// renderResourceList implements RenderTo with WithKRMOutput: the Sources are rendered into
+// objects like Process and written to w as the items of a single ResourceList, adding the
+// provenance of the values of every Source to report when not nil. With WithContinueOnError
+// the objects of the successful Sources are written before the joined errors are returned.
+func (r *Renderer) renderResourceList(
+	ctx context.Context,
+	w io.Writer,
+	values map[string]any,
+	report *ProvenanceReport,
+) error {
+	if report != nil {
+		for _, holder := range r.inputs {
+			layers, err := r.valuesLayers(ctx, holder, values)
+			if err != nil {
+				return err
+			}
+
+			report.add(holder, layers)
+		}
+	}
+
+	objects, renderErr := r.process(ctx, r.inputs, values)
+	if objects == nil {
+		return renderErr
+	}
+
+	items := make([]any, 0, len(objects))
+	for i := range objects {
+		items = append(items, objects[i].Object)
+	}
+
+	list := map[string]any{
+		"apiVersion": resourceListAPIVersion,
+		"kind":       resourceListKind,
+		"items":      items,
+	}
+
+	var data []byte
+	var err error
+
+	if r.opts.OutputFormat == FormatJSON {
+		data, err = json.Marshal(list)
+		data = append(data, '\n')
+	} else {
+		data, err = yaml.Marshal(list)
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to encode resource list: %w", err)
+	}
+
+	if _, err := w.Write(data); err != nil {
+		return fmt.Errorf("failed to write rendered output: %w", err)
+	}
+
+	return renderErr
+}
@@ -0,0 +1,124 @@
+package gotemplate_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	"sigs.k8s.io/yaml"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const krmTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}-config
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+`
+
+func TestKRMOutput(t *testing.T) {
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "web"}),
+				},
+			},
+			append(opts, gotemplate.WithKRMOutput())...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	manifests := fstest.MapFS{
+		"app.yaml":     &fstest.MapFile{Data: []byte(krmTemplate)},
+		"service.yaml": &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: web\n")},
+	}
+
+	t.Run("should wrap the rendered objects in a ResourceList", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		g.Expect(newRenderer(t, manifests).RenderTo(t.Context(), &buf, nil)).To(Succeed())
+
+		var list map[string]any
+		g.Expect(yaml.Unmarshal(buf.Bytes(), &list)).To(Succeed())
+		g.Expect(list).To(jqmatcher.Match(`.apiVersion == "config.kubernetes.io/v1" and .kind == "ResourceList"`))
+		g.Expect(list).To(jqmatcher.Match(`.items | length == 3`))
+		g.Expect(list).To(jqmatcher.Match(`[.items[].kind] == ["ConfigMap", "Deployment", "Service"]`))
+		g.Expect(list).To(jqmatcher.Match(`.items[0].metadata.name == "web-config"`))
+		g.Expect(bytes.Count(buf.Bytes(), []byte("\n---"))).To(BeZero())
+	})
+
+	t.Run("should encode the ResourceList in the output format", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		renderer := newRenderer(t, manifests, gotemplate.WithOutputFormat(gotemplate.FormatJSON))
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(Succeed())
+
+		var list map[string]any
+		g.Expect(json.Unmarshal(buf.Bytes(), &list)).To(Succeed())
+		g.Expect(list).To(jqmatcher.Match(`.kind == "ResourceList" and (.items | length == 3)`))
+	})
+
+	t.Run("should write an empty ResourceList when nothing is rendered", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var buf bytes.Buffer
+		empty := fstest.MapFS{"empty.yaml": &fstest.MapFile{Data: []byte("# nothing to render\n")}}
+		g.Expect(newRenderer(t, empty).RenderTo(t.Context(), &buf, nil)).To(Succeed())
+
+		var list map[string]any
+		g.Expect(yaml.Unmarshal(buf.Bytes(), &list)).To(Succeed())
+		g.Expect(list).To(jqmatcher.Match(`.kind == "ResourceList" and .items == []`))
+	})
+
+	t.Run("should fail on non-manifest output", func(t *testing.T) {
+		g := NewWithT(t)
+
+		notes := fstest.MapFS{"notes.yaml": &fstest.MapFile{Data: []byte("replicas: 3\n")}}
+		text := fstest.MapFS{"notes.yaml": &fstest.MapFile{Data: []byte("Thank you for installing {{ .name }}\n")}}
+
+		var buf bytes.Buffer
+		err := newRenderer(t, notes, gotemplate.WithRequireGVK("*.yaml")).RenderTo(t.Context(), &buf, nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrMissingGVK))
+		g.Expect(buf.Len()).To(BeZero())
+
+		err = newRenderer(t, text).RenderTo(t.Context(), &buf, nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+
+		_, err = newRenderer(t, notes).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrMissingGVK))
+	})
+
+	t.Run("should report the values provenance", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, manifests, gotemplate.WithValueProvenance())
+
+		out, report, err := renderer.RenderWithProvenance(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(ContainSubstring("kind: ResourceList"))
+		g.Expect(report.Values).To(ContainElement(And(
+			HaveField("Key", "name"),
+			HaveField("Layer", gotemplate.LayerSource),
+		)))
+	})
+}
@@ -0,0 +1,71 @@
+package gotemplate
+
+import (
+	"fmt"
+	"slices"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// podTemplatePath returns the path of the pod template of workload kinds, nil for other kinds.
+func podTemplatePath(kind string) []string {
+	switch kind {
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "ReplicationController", "Job":
+		return []string{"spec", "template"}
+	case "CronJob":
+		return []string{"spec", "jobTemplate", "spec", "template"}
+	default:
+		return nil
+	}
+}
+
+// setCommonMetadata merges the configured common labels and annotations into obj,
+// keeping keys the template already set. With PropagatePodLabels, the labels are also
+// merged into the pod template of workload kinds.
+func (opts RendererOptions) setCommonMetadata(obj *unstructured.Unstructured) error {
+	if len(opts.CommonLabels) > 0 {
+		obj.SetLabels(mergeMissing(obj.GetLabels(), opts.CommonLabels))
+	}
+
+	if len(opts.CommonAnnotations) > 0 {
+		obj.SetAnnotations(mergeMissing(obj.GetAnnotations(), opts.CommonAnnotations))
+	}
+
+	if !opts.PropagatePodLabels || len(opts.CommonLabels) == 0 {
+		return nil
+	}
+
+	path := podTemplatePath(obj.GetKind())
+	if path == nil {
+		return nil
+	}
+
+	labelsPath := slices.Concat(path, []string{"metadata", "labels"})
+
+	labels, _, err := unstructured.NestedStringMap(obj.Object, labelsPath...)
+	if err != nil {
+		return fmt.Errorf("failed to read pod template labels of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	err = unstructured.SetNestedStringMap(obj.Object, mergeMissing(labels, opts.CommonLabels), labelsPath...)
+	if err != nil {
+		return fmt.Errorf("failed to set pod template labels of %s %s: %w", obj.GetKind(), obj.GetName(), err)
+	}
+
+	return nil
+}
+
+// mergeMissing returns existing with the entries of common it does not already contain.
+func mergeMissing(existing map[string]string, common map[string]string) map[string]string {
+	if existing == nil {
+		existing = make(map[string]string, len(common))
+	}
+
+	for k, v := range common {
+		if _, ok := existing[k]; !ok {
+			existing[k] = v
+		}
+	}
+
+	return existing
+}
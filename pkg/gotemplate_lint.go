@@ -0,0 +1,296 @@
+package gotemplate
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+	"text/template"
+	"text/template/parse"
+)
+
+// LintReport lists value references that do not line up with the values provided.
+type LintReport struct {
+	// Undefined lists fields referenced by templates that are missing from the values.
+	Undefined []LintFinding
+
+	// Unused lists values that no template references.
+	Unused []LintFinding
+}
+
+// LintFinding identifies a single value key reported by Lint.
+type LintFinding struct {
+	// Path identifies the Source, as its patterns joined with ",".
+	Path string
+
+	// Template is the template referencing the key. Empty for unused values.
+	Template string
+
+	// Key is the dotted path of the value, e.g. "image.tag".
+	Key string
+}
+
+// HasFindings reports whether the report contains any finding.
+func (r LintReport) HasFindings() bool {
+	return len(r.Undefined) > 0 || len(r.Unused) > 0
+}
+
+// Lint statically inspects the parse trees of every configured input, without executing them,
+// and cross-references field references with the Source values (defaults, values files and
+// Source.Values merged as for rendering).
+//
+// The analysis is conservative: only references whose dot is known to be the values root
+// (or a "with" scope over a plain field) are checked for being undefined, while references in
+// "range" bodies, named templates and variables only count towards marking values as used.
+// Passing the whole dot (e.g. {{ toYaml . }}) marks every value as used.
+func (r *Renderer) Lint(ctx context.Context) (LintReport, error) {
+	if err := r.checkOpen(); err != nil {
+		return LintReport{}, err
+	}
+
+	report := LintReport{
+		Undefined: make([]LintFinding, 0),
+		Unused:    make([]LintFinding, 0),
+	}
+
+	for _, holder := range r.inputs {
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return LintReport{}, fmt.Errorf("error linting gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		values, err := r.values(ctx, holder, nil)
+		if err != nil {
+			return LintReport{}, fmt.Errorf("error linting gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		l := &linter{
+			values:    values,
+			used:      make(map[string]struct{}),
+			undefined: make(map[string]string),
+		}
+
+		// Reserved values (WithReleaseInfo, WithCapabilities) are never reported as unused
+		for _, reserved := range r.reserved {
+			for key := range reserved.values {
+				l.used[key] = struct{}{}
+			}
+		}
+
+		for _, t := range executableTemplates(templates) {
+			if t.Tree == nil || t.Root == nil {
+				continue
+			}
+
+			l.template = t.Name()
+			l.walk(t.Root, l.rootScope(t))
+		}
+
+		for _, key := range slices.Sorted(maps.Keys(l.undefined)) {
+			report.Undefined = append(report.Undefined, LintFinding{
+				Path:     holder.pathPattern(),
+				Template: l.undefined[key],
+				Key:      key,
+			})
+		}
+
+		for _, key := range l.unused() {
+			report.Unused = append(report.Unused, LintFinding{
+				Path: holder.pathPattern(),
+				Key:  key,
+			})
+		}
+	}
+
+	return report, nil
+}
+
+// lintScope describes what dot refers to. When known, dot is the values map at prefix.
+type lintScope struct {
+	known  bool
+	prefix []string
+}
+
+type linter struct {
+	values   map[string]any
+	template string
+
+	// used holds referenced value paths; "" means the whole values map is used
+	used map[string]struct{}
+
+	// undefined maps missing value paths to the first template referencing them
+	undefined map[string]string
+}
+
+// rootScope returns the dot of a template: the values root for template files, unknown for
+// {{ define }} blocks since they may be invoked with any data.
+func (l *linter) rootScope(t *template.Template) lintScope {
+	return lintScope{known: t.Name() == t.ParseName}
+}
+
+func (l *linter) walk(node parse.Node, scope lintScope) {
+	switch n := node.(type) {
+	case *parse.ListNode:
+		l.walkList(n, scope)
+	case *parse.ActionNode:
+		l.walkPipe(n.Pipe, scope)
+	case *parse.TemplateNode:
+		l.walkPipe(n.Pipe, scope)
+	case *parse.IfNode:
+		l.walkBranch(&n.BranchNode, scope, scope)
+	case *parse.WithNode:
+		l.walkBranch(&n.BranchNode, scope, l.withScope(n.Pipe, scope))
+	case *parse.RangeNode:
+		// The element type is unknown, so the body is only used to mark values as used
+		l.walkBranch(&n.BranchNode, scope, lintScope{})
+	}
+}
+
+func (l *linter) walkList(list *parse.ListNode, scope lintScope) {
+	if list == nil {
+		return
+	}
+
+	for _, child := range list.Nodes {
+		l.walk(child, scope)
+	}
+}
+
+// walkBranch walks an if/with/range node whose body is evaluated with dot set to body.
+func (l *linter) walkBranch(branch *parse.BranchNode, scope lintScope, body lintScope) {
+	l.walkPipe(branch.Pipe, scope)
+	l.walkList(branch.List, body)
+	l.walkList(branch.ElseList, scope)
+}
+
+func (l *linter) walkPipe(pipe *parse.PipeNode, scope lintScope) {
+	if pipe == nil {
+		return
+	}
+
+	for _, cmd := range pipe.Cmds {
+		for _, arg := range cmd.Args {
+			l.walkArg(arg, scope)
+		}
+	}
+}
+
+func (l *linter) walkArg(node parse.Node, scope lintScope) {
+	switch n := node.(type) {
+	case *parse.PipeNode:
+		l.walkPipe(n, scope)
+	case *parse.ChainNode:
+		l.walkArg(n.Node, scope)
+	case *parse.DotNode:
+		l.reference(nil, scope)
+	case *parse.FieldNode:
+		l.reference(n.Ident, scope)
+	case *parse.VariableNode:
+		// $ is always the values root; other variables hold arbitrary data
+		if len(n.Ident) > 0 && n.Ident[0] == "$" {
+			l.reference(n.Ident[1:], lintScope{known: true})
+		}
+	}
+}
+
+// withScope returns the dot inside a {{ with }} body, known only for a plain field pipeline.
+func (l *linter) withScope(pipe *parse.PipeNode, scope lintScope) lintScope {
+	if !scope.known || len(pipe.Decl) > 0 || len(pipe.Cmds) != 1 || len(pipe.Cmds[0].Args) != 1 {
+		return lintScope{}
+	}
+
+	field, ok := pipe.Cmds[0].Args[0].(*parse.FieldNode)
+	if !ok {
+		return lintScope{}
+	}
+
+	return lintScope{known: true, prefix: slices.Concat(scope.prefix, field.Ident)}
+}
+
+// reference records a field reference relative to scope.
+func (l *linter) reference(ident []string, scope lintScope) {
+	if !scope.known {
+		// Dot may be anything; assume a root-relative reference so the value is not reported unused
+		if len(ident) > 0 {
+			l.used[strings.Join(ident, ".")] = struct{}{}
+		}
+
+		return
+	}
+
+	path := slices.Concat(scope.prefix, ident)
+	key := strings.Join(path, ".")
+	l.used[key] = struct{}{}
+
+	if _, reported := l.undefined[key]; !reported && !l.defined(path) {
+		l.undefined[key] = l.template
+	}
+}
+
+// defined reports whether path resolves in the values. Paths crossing a value that is not a
+// map[string]any (e.g. a struct or typed map) cannot be checked and are assumed defined.
+func (l *linter) defined(path []string) bool {
+	var current any = l.values
+
+	for _, segment := range path {
+		m, ok := current.(map[string]any)
+		if !ok {
+			return true
+		}
+
+		current, ok = m[segment]
+		if !ok {
+			return false
+		}
+	}
+
+	return true
+}
+
+// unused returns the top-most value paths no reference touches, sorted.
+func (l *linter) unused() []string {
+	if _, all := l.used[""]; all {
+		return nil
+	}
+
+	result := make([]string, 0)
+	l.collectUnused(l.values, "", &result)
+	slices.Sort(result)
+
+	return result
+}
+
+func (l *linter) collectUnused(values map[string]any, prefix string, out *[]string) {
+	for key, value := range values {
+		path := key
+		if prefix != "" {
+			path = prefix + "." + key
+		}
+
+		if _, ok := l.used[path]; ok {
+			continue
+		}
+
+		if !l.referencedBelow(path) {
+			*out = append(*out, path)
+
+			continue
+		}
+
+		if nested, ok := value.(map[string]any); ok {
+			l.collectUnused(nested, path, out)
+		}
+	}
+}
+
+// referencedBelow reports whether any reference points inside path.
+func (l *linter) referencedBelow(path string) bool {
+	for used := range l.used {
+		if strings.HasPrefix(used, path+".") {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,90 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const lintTemplate = `{{ define "labels" }}app: {{ .app }}{{ end }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+  labels: {{ template "labels" .labels }}
+data:
+  missing: {{ .missing }}
+  {{- with .image }}
+  image: {{ .repository }}:{{ .tag }}
+  {{- end }}
+  {{- range .ports }}
+  port: {{ .containerPort }}
+  {{- end }}
+  root: {{ $.root.value }}
+`
+
+func TestLint(t *testing.T) {
+
+	newRenderer := func(t *testing.T, tmpl string, values map[string]any) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(tmpl)}},
+				Path:   "*.yaml",
+				Values: gotemplate.Values(values),
+			},
+		})
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should report undefined references and unused values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, lintTemplate, map[string]any{
+			"name":   "app",
+			"labels": map[string]any{"app": "web"},
+			"image":  map[string]any{"repository": "nginx"},
+			"ports":  []any{map[string]any{"containerPort": 80}},
+			"root":   map[string]any{"value": "x", "other": "y"},
+			"extra":  "unused",
+		})
+
+		report, err := renderer.Lint(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(report.HasFindings()).To(BeTrue())
+		g.Expect(report.Undefined).To(ConsistOf(
+			gotemplate.LintFinding{Path: "*.yaml", Template: "configmap.yaml", Key: "missing"},
+			gotemplate.LintFinding{Path: "*.yaml", Template: "configmap.yaml", Key: "image.tag"},
+		))
+		g.Expect(report.Unused).To(ConsistOf(
+			gotemplate.LintFinding{Path: "*.yaml", Key: "extra"},
+			gotemplate.LintFinding{Path: "*.yaml", Key: "root.other"},
+		))
+	})
+
+	t.Run("should not report values when the whole dot is used", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "data: {{ toYaml . }}", map[string]any{"extra": "value"})
+
+		report, err := renderer.Lint(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(report.HasFindings()).To(BeFalse())
+	})
+
+	t.Run("should not report references inside range bodies as undefined", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "{{ range .items }}{{ .name }}{{ end }}", map[string]any{
+			"items": []any{map[string]any{"name": "a"}},
+		})
+
+		report, err := renderer.Lint(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(report.Undefined).To(BeEmpty())
+		g.Expect(report.Unused).To(BeEmpty())
+	})
+}
@@ -0,0 +1,131 @@
+package gotemplate
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"time"
+)
+
+// memFS is a read-only in-memory fs.FS holding fetched template content.
+// Keys are slash-separated paths relative to the root; parent directories are implied.
+type memFS map[string][]byte
+
+func (m memFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	if data, ok := m[name]; ok {
+		return &memFile{
+			Reader: bytes.NewReader(data),
+			info:   memFileInfo{name: path.Base(name), size: int64(len(data))},
+		}, nil
+	}
+
+	entries := m.children(name)
+	if len(entries) == 0 && name != "." {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrNotExist}
+	}
+
+	return &memDir{
+		info:    memFileInfo{name: path.Base(name), dir: true},
+		entries: entries,
+	}, nil
+}
+
+// children returns the sorted direct entries of directory dir.
+func (m memFS) children(dir string) []fs.DirEntry {
+	prefix := dir + "/"
+	if dir == "." {
+		prefix = ""
+	}
+
+	seen := make(map[string]fs.DirEntry)
+	for name, data := range m {
+		rest, ok := strings.CutPrefix(name, prefix)
+		if !ok {
+			continue
+		}
+
+		if child, _, nested := strings.Cut(rest, "/"); nested {
+			seen[child] = fs.FileInfoToDirEntry(memFileInfo{name: child, dir: true})
+		} else {
+			seen[child] = fs.FileInfoToDirEntry(memFileInfo{name: child, size: int64(len(data))})
+		}
+	}
+
+	entries := make([]fs.DirEntry, 0, len(seen))
+	for _, e := range seen {
+		entries = append(entries, e)
+	}
+
+	slices.SortFunc(entries, func(a fs.DirEntry, b fs.DirEntry) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return entries
+}
+
+type memFileInfo struct {
+	name string
+	size int64
+	dir  bool
+}
+
+func (i memFileInfo) Name() string       { return i.name }
+func (i memFileInfo) Size() int64        { return i.size }
+func (i memFileInfo) ModTime() time.Time { return time.Time{} }
+func (i memFileInfo) IsDir() bool        { return i.dir }
+func (i memFileInfo) Sys() any           { return nil }
+
+func (i memFileInfo) Mode() fs.FileMode {
+	if i.dir {
+		return fs.ModeDir | 0o555
+	}
+
+	return 0o444
+}
+
+type memFile struct {
+	*bytes.Reader
+
+	info memFileInfo
+}
+
+func (f *memFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *memFile) Close() error               { return nil }
+
+type memDir struct {
+	info    memFileInfo
+	entries []fs.DirEntry
+	offset  int
+}
+
+func (d *memDir) Stat() (fs.FileInfo, error) { return d.info, nil }
+func (d *memDir) Close() error               { return nil }
+
+func (d *memDir) Read([]byte) (int, error) {
+	return 0, &fs.PathError{Op: "read", Path: d.info.name, Err: fs.ErrInvalid}
+}
+
+func (d *memDir) ReadDir(n int) ([]fs.DirEntry, error) {
+	remaining := d.entries[d.offset:]
+	if n <= 0 {
+		d.offset = len(d.entries)
+
+		return remaining, nil
+	}
+
+	if len(remaining) == 0 {
+		return nil, io.EOF
+	}
+
+	n = min(n, len(remaining))
+	d.offset += n
+
+	return remaining[:n], nil
+}
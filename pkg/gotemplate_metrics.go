@@ -0,0 +1,122 @@
+package gotemplate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	metricsNamespace = "gotemplate"
+
+	// sourceLabel is the metric label carrying the Source name, or its path pattern if unnamed.
+	sourceLabel = "source"
+)
+
+// metrics holds the Prometheus collectors updated by Process. A nil *metrics records nothing.
+type metrics struct {
+	renders     *prometheus.CounterVec
+	errors      *prometheus.CounterVec
+	cacheHits   *prometheus.CounterVec
+	cacheMisses *prometheus.CounterVec
+	duration    *prometheus.HistogramVec
+}
+
+// newMetrics registers the renderer collectors with reg. Collectors already registered by
+// another renderer are reused, so any number of renderers can share a registry.
+func newMetrics(reg prometheus.Registerer) (*metrics, error) {
+	counter := func(name string, help string) (*prometheus.CounterVec, error) {
+		return registerCollector(reg, prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: metricsNamespace,
+			Name:      name,
+			Help:      help,
+		}, []string{sourceLabel}))
+	}
+
+	m := &metrics{}
+
+	var err error
+
+	if m.renders, err = counter("renders_total", "Number of Source renders."); err != nil {
+		return nil, err
+	}
+	if m.errors, err = counter("render_errors_total", "Number of failed Source renders."); err != nil {
+		return nil, err
+	}
+	if m.cacheHits, err = counter("cache_hits_total", "Number of render cache hits."); err != nil {
+		return nil, err
+	}
+	if m.cacheMisses, err = counter("cache_misses_total", "Number of render cache misses."); err != nil {
+		return nil, err
+	}
+
+	m.duration, err = registerCollector(reg, prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "render_duration_seconds",
+		Help:      "Duration of Source renders, including render cache hits.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{sourceLabel}))
+	if err != nil {
+		return nil, err
+	}
+
+	return m, nil
+}
+
+// registerCollector registers c with reg, returning the collector registered earlier
+// under the same descriptor if there is one.
+func registerCollector[T prometheus.Collector](reg prometheus.Registerer, c T) (T, error) {
+	err := reg.Register(c)
+	if err == nil {
+		return c, nil
+	}
+
+	var are prometheus.AlreadyRegisteredError
+	if errors.As(err, &are) {
+		if existing, ok := are.ExistingCollector.(T); ok {
+			return existing, nil
+		}
+	}
+
+	return c, fmt.Errorf("failed to register metrics: %w", err)
+}
+
+// observeRender records a render of the Source that took duration and failed with err, if not nil.
+func (m *metrics) observeRender(holder *sourceHolder, duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+
+	source := holder.metricsLabel()
+
+	m.renders.WithLabelValues(source).Inc()
+	m.duration.WithLabelValues(source).Observe(duration.Seconds())
+
+	if err != nil {
+		m.errors.WithLabelValues(source).Inc()
+	}
+}
+
+// observeCache records a render cache lookup of the Source.
+func (m *metrics) observeCache(holder *sourceHolder, hit bool) {
+	if m == nil {
+		return
+	}
+
+	if hit {
+		m.cacheHits.WithLabelValues(holder.metricsLabel()).Inc()
+	} else {
+		m.cacheMisses.WithLabelValues(holder.metricsLabel()).Inc()
+	}
+}
+
+// metricsLabel returns the value of the source metric label: the Source name, or its path pattern.
+func (h *sourceHolder) metricsLabel() string {
+	if h.Name != "" {
+		return h.Name
+	}
+
+	return h.pathPattern()
+}
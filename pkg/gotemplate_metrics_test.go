@@ -0,0 +1,110 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestMetrics(t *testing.T) {
+
+	const podTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .name }}
+`
+
+	newRenderer := func(t *testing.T, reg prometheus.Registerer, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name: "pods",
+					FS: fstest.MapFS{
+						"pod.yaml": &fstest.MapFile{Data: []byte(podTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			append(opts, gotemplate.WithMetricsRegisterer(reg))...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should count renders and cache lookups", func(t *testing.T) {
+		g := NewWithT(t)
+		reg := prometheus.NewRegistry()
+		renderer := newRenderer(t, reg, gotemplate.WithCache())
+
+		for range 3 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": "[unclosed"})
+		g.Expect(err).To(HaveOccurred())
+
+		g.Expect(testutil.GatherAndCount(reg)).To(Equal(5))
+
+		families, err := reg.Gather()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		values := make(map[string]float64)
+		for _, family := range families {
+			for _, metric := range family.GetMetric() {
+				g.Expect(metric.GetLabel()).To(HaveLen(1))
+				g.Expect(metric.GetLabel()[0].GetValue()).To(Equal("pods"))
+
+				if h := metric.GetHistogram(); h != nil {
+					values[family.GetName()] = float64(h.GetSampleCount())
+				} else {
+					values[family.GetName()] = metric.GetCounter().GetValue()
+				}
+			}
+		}
+
+		g.Expect(values).To(Equal(map[string]float64{
+			"gotemplate_renders_total":           4,
+			"gotemplate_render_errors_total":     1,
+			"gotemplate_cache_hits_total":        2,
+			"gotemplate_cache_misses_total":      2,
+			"gotemplate_render_duration_seconds": 4,
+		}))
+	})
+
+	t.Run("should share collectors between renderers", func(t *testing.T) {
+		g := NewWithT(t)
+		reg := prometheus.NewRegistry()
+
+		for range 2 {
+			renderer := newRenderer(t, reg)
+
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		problems, err := testutil.GatherAndLint(reg)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(problems).To(BeEmpty())
+
+		families, err := reg.Gather()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for _, family := range families {
+			if family.GetName() == "gotemplate_renders_total" {
+				g.Expect(family.GetMetric()[0].GetCounter().GetValue()).To(Equal(2.0))
+			}
+		}
+	})
+
+}
@@ -0,0 +1,216 @@
+package gotemplate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"regexp"
+	"slices"
+	"strings"
+	"text/template"
+)
+
+// maxMissingValuesPasses bounds the executions of each template by MissingValues, and so the
+// number of missing values a single template can report.
+const maxMissingValuesPasses = 256
+
+// missingKeyError matches the "at <.a.b>: map has no entry for key "b"" suffix of the errors
+// reported by text/template for missing map keys with missingkey=error.
+var missingKeyError = regexp.MustCompile(`at <([^>]*)>: map has no entry for key "([^"]*)"`)
+
+// missingValuePlaceholder is the value MissingValues injects for missing leaf values, non-empty
+// so that conditionals on it take their branch and reveal the values referenced there.
+const missingValuePlaceholder = "<missing>"
+
+// MissingValues reports the dotted paths (e.g. "image.tag") of the values referenced by the
+// templates but not defined, an onboarding aid for new templates and values. Templates are
+// executed with missingkey=error as in Process, with values merged with the Source values;
+// on a missing value, a placeholder is injected at its path and the template is executed
+// again, until it completes, so all the values on the executed branches are reported rather
+// than the first one only. Paths are sorted and unique across Sources.
+//
+// This is a best-effort walk: branches not taken with the placeholders are not explored,
+// references relative to a with or range block or to a variable are reported relative to it,
+// and templates executed through include keep the configured WithMissingKeyMode. Failures
+// other than missing values are returned when they occur with the values as given; failures
+// caused by the placeholders only stop the walk of the template.
+// This method is safe for concurrent use.
+func (r *Renderer) MissingValues(ctx context.Context, values map[string]any) ([]string, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	missing := make([]string, 0)
+
+	for _, holder := range r.inputs {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled before gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		templates, err := holder.LoadTemplates()
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		merged, err := r.values(ctx, holder, values)
+		if err != nil {
+			return nil, err
+		}
+
+		strict, err := templates.Clone()
+		if err != nil {
+			return nil, fmt.Errorf("failed to clone templates of gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		strict.Option("missingkey=" + string(MissingKeyError))
+
+		entries, err := holder.entryTemplates(strict)
+		if err != nil {
+			return nil, fmt.Errorf("error rendering gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		for _, t := range entries {
+			paths, err := r.missingValues(ctx, holder, t, merged)
+			if err != nil {
+				return nil, err
+			}
+
+			missing = append(missing, paths...)
+		}
+	}
+
+	slices.Sort(missing)
+
+	return slices.Compact(missing), nil
+}
+
+// missingValues executes t until it no longer fails on a missing value, injecting placeholders
+// for the missing ones, and returns their paths.
+func (r *Renderer) missingValues(
+	ctx context.Context,
+	holder *sourceHolder,
+	t *template.Template,
+	values map[string]any,
+) ([]string, error) {
+	missing := make([]string, 0)
+	injected := make(map[string]bool)
+
+	for pass := range maxMissingValuesPasses {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled during gotemplate pattern %s: %w", holder.describe(), err)
+		}
+
+		err := r.executeTemplate(ctx, holder, t, io.Discard, values)
+		if err == nil {
+			break
+		}
+
+		path, leaf, ok := missingValuePath(err, values)
+		if !ok {
+			if pass == 0 {
+				return nil, err
+			}
+
+			break
+		}
+
+		// Relative references cannot be resolved from the root, so injecting does not help
+		if injected[path] || strings.HasPrefix(path, "$") {
+			missing = append(missing, path)
+
+			break
+		}
+
+		injected[path] = true
+
+		var placeholder any = missingValuePlaceholder
+		if !leaf {
+			placeholder = map[string]any{}
+		} else {
+			missing = append(missing, path)
+		}
+
+		values = setValuePath(values, strings.Split(path, "."), placeholder)
+	}
+
+	return missing, nil
+}
+
+// missingValuePath extracts from a missing key execution error the dotted path of the missing
+// value, up to the missing key, and whether the reference ends at that key. The missing key is
+// located by walking values along the reference, as its name may repeat along the path.
+func missingValuePath(err error, values map[string]any) (string, bool, bool) {
+	var renderErr *RenderError
+	if !errors.As(err, &renderErr) {
+		return "", false, false
+	}
+
+	m := missingKeyError.FindStringSubmatch(renderErr.Err.Error())
+	if m == nil {
+		return "", false, false
+	}
+
+	chain, key := m[1], m[2]
+
+	if fields, ok := strings.CutPrefix(chain, "$."); ok {
+		chain = "." + fields
+	}
+
+	if !strings.HasPrefix(chain, ".") {
+		return chain, true, true
+	}
+
+	fields := strings.Split(strings.TrimPrefix(chain, "."), ".")
+
+	idx := missingField(values, fields)
+	if idx < 0 || fields[idx] != key {
+		idx = slices.Index(fields, key)
+	}
+
+	if idx < 0 {
+		return strings.Join(fields, "."), true, true
+	}
+
+	return strings.Join(fields[:idx+1], "."), idx == len(fields)-1, true
+}
+
+// missingField returns the index of the first of fields absent from values when followed as
+// a path of nested maps, -1 when they are all present or the path leaves the maps.
+func missingField(values map[string]any, fields []string) int {
+	current := values
+
+	for i, field := range fields {
+		value, ok := current[field]
+		if !ok {
+			return i
+		}
+
+		if current, ok = value.(map[string]any); !ok {
+			return -1
+		}
+	}
+
+	return -1
+}
+
+// setValuePath returns a copy of values with value set at path, copying the maps along the
+// path so values shared with the caller are never modified.
+func setValuePath(values map[string]any, path []string, value any) map[string]any {
+	result := maps.Clone(values)
+	if result == nil {
+		result = make(map[string]any)
+	}
+
+	if len(path) == 1 {
+		result[path[0]] = value
+
+		return result
+	}
+
+	child, _ := result[path[0]].(map[string]any)
+	result[path[0]] = setValuePath(child, path[1:], value)
+
+	return result
+}
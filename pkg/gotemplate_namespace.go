@@ -0,0 +1,81 @@
+package gotemplate
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// namespaceFunc returns the namespace template function, returning the WithNamespace
+// namespace, empty when none is configured, e.g. for the subjects of a RoleBinding:
+// namespace: {{ namespace }}.
+func namespaceFunc(ns string) func() string {
+	return func() string {
+		return ns
+	}
+}
+
+// builtinClusterScoped reports whether kind is a built-in Kubernetes kind that is not namespaced.
+// Kinds not listed here, including custom resources, are assumed to be namespaced unless
+// WithClusterScopedKinds says otherwise, since the scope cannot be discovered without a live cluster.
+func builtinClusterScoped(kind string) bool {
+	switch kind {
+	case "APIService",
+		"CertificateSigningRequest",
+		"ClusterRole",
+		"ClusterRoleBinding",
+		"ComponentStatus",
+		"CSIDriver",
+		"CSINode",
+		"CustomResourceDefinition",
+		"DeviceClass",
+		"FlowSchema",
+		"IngressClass",
+		"IPAddress",
+		"MutatingAdmissionPolicy",
+		"MutatingAdmissionPolicyBinding",
+		"MutatingWebhookConfiguration",
+		"Namespace",
+		"Node",
+		"PersistentVolume",
+		"PriorityClass",
+		"PriorityLevelConfiguration",
+		"ResourceSlice",
+		"RuntimeClass",
+		"SelfSubjectAccessReview",
+		"SelfSubjectRulesReview",
+		"ServiceCIDR",
+		"StorageClass",
+		"SubjectAccessReview",
+		"TokenReview",
+		"ValidatingAdmissionPolicy",
+		"ValidatingAdmissionPolicyBinding",
+		"ValidatingWebhookConfiguration",
+		"VolumeAttachment":
+		return true
+	default:
+		return false
+	}
+}
+
+// clusterScoped reports whether objects of kind are cluster-scoped, consulting the
+// WithClusterScopedKinds overrides before the built-in list.
+func (opts RendererOptions) clusterScoped(kind string) bool {
+	if scoped, ok := opts.ClusterScopedKinds[kind]; ok {
+		return scoped
+	}
+
+	return builtinClusterScoped(kind)
+}
+
+// setNamespace stamps the configured namespace on a namespaced object. An existing namespace
+// is kept unless WithForceNamespace was used.
+func (opts RendererOptions) setNamespace(obj *unstructured.Unstructured) {
+	if opts.Namespace == "" || opts.clusterScoped(obj.GetKind()) {
+		return
+	}
+
+	if obj.GetNamespace() != "" && !opts.ForceNamespace {
+		return
+	}
+
+	obj.SetNamespace(opts.Namespace)
+}
@@ -0,0 +1,272 @@
+package gotemplate
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/name"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/k8s-manifest-kit/pkg/util"
+)
+
+// ociTitleAnnotation names the file carried by a raw (non-tar) artifact layer, as set by ORAS.
+const ociTitleAnnotation = "org.opencontainers.image.title"
+
+// defaultOCIMaxSize bounds the uncompressed size of an OCISource artifact, 64 MiB.
+const defaultOCIMaxSize = 64 << 20
+
+// OCIOption is a generic option for OCIOptions.
+type OCIOption = util.Option[OCIOptions]
+
+// OCIOptions configures how OCISource pulls an artifact.
+type OCIOptions struct {
+	// Keychain resolves registry credentials. nil = authn.DefaultKeychain (docker config).
+	Keychain authn.Keychain
+
+	// Insecure allows pulling from registries over plain HTTP.
+	Insecure bool
+
+	// Path is the glob pattern matching templates within the artifact. Empty = "*.yaml".
+	Path string
+
+	// MaxSize bounds the uncompressed size of the artifact layers in bytes. Zero = 64 MiB.
+	MaxSize int64
+}
+
+// ApplyTo applies the OCI options to the target configuration.
+func (opts OCIOptions) ApplyTo(target *OCIOptions) {
+	if opts.Keychain != nil {
+		target.Keychain = opts.Keychain
+	}
+
+	if opts.Insecure {
+		target.Insecure = true
+	}
+
+	if opts.Path != "" {
+		target.Path = opts.Path
+	}
+
+	if opts.MaxSize > 0 {
+		target.MaxSize = opts.MaxSize
+	}
+}
+
+// WithOCIKeychain sets the keychain used to authenticate against the registry.
+func WithOCIKeychain(keychain authn.Keychain) OCIOption {
+	return util.FunctionalOption[OCIOptions](func(opts *OCIOptions) {
+		opts.Keychain = keychain
+	})
+}
+
+// WithOCIInsecure allows pulling from registries over plain HTTP, e.g. a local development registry.
+func WithOCIInsecure() OCIOption {
+	return util.FunctionalOption[OCIOptions](func(opts *OCIOptions) {
+		opts.Insecure = true
+	})
+}
+
+// WithOCIPath sets the glob pattern matching templates within the artifact, e.g. "templates/*.yaml".
+// Default: "*.yaml", the YAML files at the artifact root.
+func WithOCIPath(pattern string) OCIOption {
+	return util.FunctionalOption[OCIOptions](func(opts *OCIOptions) {
+		opts.Path = pattern
+	})
+}
+
+// WithOCIMaxSize bounds the uncompressed size of the artifact, summed over its layers with tar
+// headers included, so a small compressed artifact cannot expand to exhaust memory. Default: 64 MiB.
+func WithOCIMaxSize(n int64) OCIOption {
+	return util.FunctionalOption[OCIOptions](func(opts *OCIOptions) {
+		opts.MaxSize = n
+	})
+}
+
+// OCISource pulls the OCI artifact ref (e.g. "ghcr.io/org/templates:v1" or a digest reference)
+// and returns a Source serving its files from memory. The pull honors ctx. Credentials come
+// from the docker config unless WithOCIKeychain is given.
+//
+// Tar layers (optionally compressed) are extracted in order, later layers overwriting earlier
+// files; raw layers are stored under their "org.opencontainers.image.title" annotation, as pushed
+// by ORAS. Every blob is verified against its digest while reading, and for digest references the
+// manifest digest must match, otherwise ErrDigestMismatch is returned. Artifacts expanding to
+// more than the maximum size (WithOCIMaxSize) fail with ErrArchiveTooLarge.
+//
+// Files are exposed under the repository and resolved manifest digest, which becomes the prefix
+// of the Source pattern, so render cache entries and source annotations are keyed by content.
+// Templates matched by single-level patterns are named by base name as for local Sources.
+func OCISource(ctx context.Context, ref string, opts ...OCIOption) (Source, error) {
+	ociOpts := OCIOptions{
+		Keychain: authn.DefaultKeychain,
+		Path:     "*.yaml",
+		MaxSize:  defaultOCIMaxSize,
+	}
+	for _, opt := range opts {
+		opt.ApplyTo(&ociOpts)
+	}
+
+	nameOpts := make([]name.Option, 0, 1)
+	if ociOpts.Insecure {
+		nameOpts = append(nameOpts, name.Insecure)
+	}
+
+	reference, err := name.ParseReference(ref, nameOpts...)
+	if err != nil {
+		return Source{}, fmt.Errorf("%w: %w", ErrInvalidOCIReference, err)
+	}
+
+	img, err := remote.Image(reference, remote.WithContext(ctx), remote.WithAuthFromKeychain(ociOpts.Keychain))
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to pull artifact %s: %w", ref, err)
+	}
+
+	digest, err := img.Digest()
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to compute digest of artifact %s: %w", ref, err)
+	}
+
+	if d, ok := reference.(name.Digest); ok && d.DigestStr() != digest.String() {
+		return Source{}, fmt.Errorf("%w: %s resolved to %s", ErrDigestMismatch, ref, digest)
+	}
+
+	root := reference.Context().Name() + "@" + digest.String()
+
+	files, err := extractArtifact(img, root, ociOpts.MaxSize)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to extract artifact %s: %w", ref, err)
+	}
+
+	return Source{
+		FS:   files,
+		Path: escapeGlob(root) + "/" + ociOpts.Path,
+	}, nil
+}
+
+// extractArtifact reads the files of every layer of img into a memFS, below root, failing
+// with ErrArchiveTooLarge once the layers expand to more than maxSize bytes.
+func extractArtifact(img v1.Image, root string, maxSize int64) (memFS, error) {
+	manifest, err := img.Manifest()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read manifest: %w", err)
+	}
+
+	layers, err := img.Layers()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read layers: %w", err)
+	}
+
+	files := memFS{}
+	remaining := maxSize
+
+	for i, layer := range layers {
+		remaining, err = extractLayer(layer, manifest.Layers[i].Annotations[ociTitleAnnotation], root, files, remaining)
+		if errors.Is(err, ErrArchiveTooLarge) {
+			return nil, fmt.Errorf("%w: more than %d bytes uncompressed", ErrArchiveTooLarge, maxSize)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("layer %d: %w", i, err)
+		}
+	}
+
+	return files, nil
+}
+
+// extractLayer reads the files of layer into files, below root, reading at most remaining
+// bytes, and returns the bytes left. Layers with a title are raw files stored under it, other
+// layers tar streams.
+func extractLayer(layer v1.Layer, title string, root string, files memFS, remaining int64) (int64, error) {
+	open := layer.Uncompressed
+	if title != "" {
+		open = layer.Compressed
+	}
+
+	rc, err := open()
+	if err != nil {
+		return 0, fmt.Errorf("failed to open layer: %w", err)
+	}
+
+	defer func() { _ = rc.Close() }()
+
+	// Read one byte past the limit, so reaching it tells exceeding artifacts apart
+	limited := &io.LimitedReader{R: rc, N: remaining + 1}
+
+	if title != "" {
+		err = extractRawFile(limited, root, title, files)
+	} else {
+		err = extractTar(limited, root, files)
+	}
+
+	if limited.N <= 0 {
+		return 0, ErrArchiveTooLarge
+	}
+
+	return limited.N - 1, err
+}
+
+// extractRawFile reads r into files as the file title, below root.
+func extractRawFile(r io.Reader, root string, title string, files memFS) error {
+	file, err := artifactPath(title)
+	if err != nil {
+		return err
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return fmt.Errorf("failed to read %s: %w", title, err)
+	}
+
+	files[path.Join(root, file)] = data
+
+	return nil
+}
+
+// extractTar reads the regular files of the tar stream r into files, below root.
+func extractTar(r io.Reader, root string, files memFS) error {
+	tr := tar.NewReader(r)
+
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read tar stream: %w", err)
+		}
+
+		if hdr.Typeflag != tar.TypeReg {
+			continue
+		}
+
+		file, err := artifactPath(hdr.Name)
+		if err != nil {
+			return err
+		}
+
+		data, err := io.ReadAll(tr)
+		if err != nil {
+			return fmt.Errorf("failed to read %s: %w", hdr.Name, err)
+		}
+
+		files[path.Join(root, file)] = data
+	}
+}
+
+// artifactPath returns the clean relative path of an artifact file, rejecting paths
+// escaping the artifact root.
+func artifactPath(file string) (string, error) {
+	cleaned := path.Clean(strings.TrimPrefix(file, "/"))
+	if !fs.ValidPath(cleaned) || cleaned == "." {
+		return "", fmt.Errorf("%w: invalid file path %q", ErrInvalidArtifact, file)
+	}
+
+	return cleaned, nil
+}
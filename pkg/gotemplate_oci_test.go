@@ -0,0 +1,233 @@
+package gotemplate_test
+
+import (
+	"context"
+	"io"
+	"log"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/google/go-containerregistry/pkg/authn"
+	"github.com/google/go-containerregistry/pkg/crane"
+	"github.com/google/go-containerregistry/pkg/name"
+	"github.com/google/go-containerregistry/pkg/registry"
+	v1 "github.com/google/go-containerregistry/pkg/v1"
+	"github.com/google/go-containerregistry/pkg/v1/empty"
+	"github.com/google/go-containerregistry/pkg/v1/mutate"
+	"github.com/google/go-containerregistry/pkg/v1/remote"
+	"github.com/google/go-containerregistry/pkg/v1/static"
+	"github.com/google/go-containerregistry/pkg/v1/types"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// pushArtifact pushes img to a new in-memory registry and returns its tag and digest references.
+func pushArtifact(t *testing.T, img v1.Image) (string, string) {
+	t.Helper()
+
+	server := httptest.NewServer(registry.New(registry.Logger(log.New(io.Discard, "", 0))))
+	t.Cleanup(server.Close)
+
+	ref := strings.TrimPrefix(server.URL, "http://") + "/templates:v1"
+
+	tag, err := name.ParseReference(ref, name.Insecure)
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	NewWithT(t).Expect(remote.Write(tag, img, remote.WithAuthFromKeychain(authn.NewMultiKeychain()))).To(Succeed())
+
+	digest, err := img.Digest()
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	return ref, tag.Context().Digest(digest.String()).String()
+}
+
+func TestOCISource(t *testing.T) {
+
+	anonymous := gotemplate.WithOCIKeychain(authn.NewMultiKeychain())
+
+	tarArtifact := func(t *testing.T) v1.Image {
+		t.Helper()
+
+		layer, err := crane.Layer(map[string][]byte{
+			"configmap.yaml":         []byte(remoteTemplate),
+			"templates/service.yaml": []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .name }}\n"),
+		})
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		img, err := mutate.AppendLayers(empty.Image, layer)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return img
+	}
+
+	render := func(t *testing.T, source gotemplate.Source) []string {
+		t.Helper()
+
+		source.Values = gotemplate.Values(map[string]any{"name": "oci"})
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source})
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		kinds := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			NewWithT(t).Expect(obj.Object).To(jqmatcher.Match(`.metadata.name == "oci"`))
+			kinds = append(kinds, obj.GetKind())
+		}
+
+		return kinds
+	}
+
+	t.Run("should render templates from a tar layer", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, _ := pushArtifact(t, tarArtifact(t))
+
+		source, err := gotemplate.OCISource(t.Context(), ref, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal([]string{"ConfigMap"}))
+	})
+
+	t.Run("should select templates with a pattern", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, _ := pushArtifact(t, tarArtifact(t))
+
+		source, err := gotemplate.OCISource(t.Context(), ref,
+			gotemplate.WithOCIInsecure(),
+			gotemplate.WithOCIPath("templates/*.yaml"),
+			anonymous,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal([]string{"Service"}))
+	})
+
+	t.Run("should keep insecure mode across option structs", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, _ := pushArtifact(t, tarArtifact(t))
+
+		source, err := gotemplate.OCISource(t.Context(), ref,
+			gotemplate.WithOCIInsecure(),
+			gotemplate.OCIOptions{Path: "templates/*.yaml"},
+			anonymous,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal([]string{"Service"}))
+	})
+
+	t.Run("should render raw layers named by title", func(t *testing.T) {
+		g := NewWithT(t)
+
+		img, err := mutate.Append(empty.Image, mutate.Addendum{
+			Layer:       static.NewLayer([]byte(remoteTemplate), types.MediaType("application/vnd.example.template")),
+			Annotations: map[string]string{"org.opencontainers.image.title": "configmap.yaml"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, ref := pushArtifact(t, img)
+
+		source, err := gotemplate.OCISource(t.Context(), ref, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal([]string{"ConfigMap"}))
+	})
+
+	t.Run("should key the Source by digest", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, digestRef := pushArtifact(t, tarArtifact(t))
+
+		byTag, err := gotemplate.OCISource(t.Context(), ref, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		byDigest, err := gotemplate.OCISource(t.Context(), digestRef, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(byTag.Path).To(Equal(byDigest.Path))
+		g.Expect(byTag.Path).To(Equal(digestRef + "/*.yaml"))
+	})
+
+	t.Run("should fail when the digest does not match", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, _ := pushArtifact(t, tarArtifact(t))
+
+		other, err := mutate.AppendLayers(empty.Image, static.NewLayer([]byte("other"), types.OCILayer))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		digest, err := other.Digest()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		wrong := strings.TrimSuffix(ref, ":v1") + "@" + digest.String()
+
+		_, err = gotemplate.OCISource(t.Context(), wrong, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).To(HaveOccurred())
+	})
+
+	t.Run("should bound the artifact size", func(t *testing.T) {
+		g := NewWithT(t)
+
+		base := tarArtifact(t)
+		layers, err := base.Layers()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		rc, err := layers[0].Uncompressed()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		tarSize, err := io.Copy(io.Discard, rc)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(rc.Close()).To(Succeed())
+
+		raw := static.NewLayer([]byte(remoteTemplate), types.MediaType("application/vnd.example.template"))
+		img, err := mutate.Append(base, mutate.Addendum{
+			Layer:       raw,
+			Annotations: map[string]string{"org.opencontainers.image.title": "raw.yaml"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		ref, _ := pushArtifact(t, img)
+
+		_, err = gotemplate.OCISource(t.Context(), ref,
+			gotemplate.WithOCIInsecure(),
+			gotemplate.WithOCIMaxSize(tarSize/2),
+			anonymous,
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrArchiveTooLarge))
+
+		// The tar layer alone fits, the raw layer pushes the artifact over the limit
+		limit := tarSize + int64(len(remoteTemplate)) - 1
+		_, err = gotemplate.OCISource(t.Context(), ref,
+			gotemplate.WithOCIInsecure(),
+			gotemplate.WithOCIMaxSize(limit),
+			anonymous,
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrArchiveTooLarge))
+
+		source, err := gotemplate.OCISource(t.Context(), ref,
+			gotemplate.WithOCIInsecure(),
+			gotemplate.WithOCIMaxSize(limit+1),
+			anonymous,
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal([]string{"ConfigMap", "ConfigMap"}))
+	})
+
+	t.Run("should respect context cancellation", func(t *testing.T) {
+		g := NewWithT(t)
+		ref, _ := pushArtifact(t, tarArtifact(t))
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := gotemplate.OCISource(ctx, ref, gotemplate.WithOCIInsecure(), anonymous)
+		g.Expect(err).To(MatchError(context.Canceled))
+	})
+
+	t.Run("should reject invalid references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.OCISource(t.Context(), "INVALID::ref", anonymous)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidOCIReference))
+	})
+}
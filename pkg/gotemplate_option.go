@@ -1,9 +1,20 @@
 package gotemplate
 
 import (
+	"context"
+	"fmt"
+	"io/fs"
+	"maps"
+	"slices"
+	"text/template"
+	"time"
+
+	"github.com/go-logr/logr"
 	"github.com/k8s-manifest-kit/engine/pkg/types"
 	"github.com/k8s-manifest-kit/pkg/util"
 	"github.com/k8s-manifest-kit/pkg/util/cache"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 // RendererOption is a generic option for RendererOptions.
@@ -17,17 +28,242 @@ type RendererOptions struct {
 	// Transformers are renderer-specific transformers applied during Process().
 	Transformers []types.Transformer
 
+	// ObjectsTransformers mutate the complete set of rendered objects, in registration order.
+	ObjectsTransformers []ObjectsTransformer
+
+	// BeforeRender hooks are called before every Source render of Process, in registration order.
+	BeforeRender []BeforeRenderHook
+
+	// AfterRender hooks are called after every Source render of Process, in registration order.
+	AfterRender []AfterRenderHook
+
+	// DetectDuplicates fails renders producing several objects with the same identity.
+	DetectDuplicates bool
+
 	// CacheOptions holds cache configuration. nil = caching disabled.
 	CacheOptions *cache.Options
 
 	// SourceAnnotations enables automatic addition of source tracking annotations.
 	SourceAnnotations bool
+
+	// FuncMap holds custom functions made available to all templates.
+	// Functions are attached before parsing, so templates may reference them.
+	FuncMap template.FuncMap
+
+	// LookupFunc backs the lookup template function. nil = lookup returns an empty map.
+	LookupFunc LookupFunc
+
+	// ImageResolver backs the imageDigest template function. nil = references are returned unchanged.
+	ImageResolver ImageResolver
+
+	// SprigFunctions enables the bundled subset of Sprig-compatible template functions.
+	SprigFunctions bool
+
+	// Checksums enables the checksumOf template function.
+	Checksums bool
+
+	// KeepLineEndings parses templates as read, without stripping byte order marks or
+	// converting CRLF line endings.
+	KeepLineEndings bool
+
+	// PassthroughPaths lists the patterns of the Source files emitted verbatim instead of being
+	// parsed as templates. Patterns containing "/" match file paths, others base names.
+	PassthroughPaths []string
+
+	// OverlayFS provides files taking precedence over the files of every Source FS with the
+	// same path. nil = Source FSes are used as given.
+	OverlayFS fs.FS
+
+	// Delimiters overrides the template action delimiters. nil = default "{{" and "}}".
+	Delimiters *Delimiters
+
+	// MissingKeyMode controls how templates handle references to missing map keys.
+	// Default: MissingKeyError.
+	MissingKeyMode MissingKeyMode
+
+	// Layout names the base template executed for every Source instead of each template.
+	// Empty = every template is executed.
+	Layout string
+
+	// MaxIncludeDepth caps how deeply include and tpl calls may nest. Zero = 100.
+	MaxIncludeDepth int
+
+	// RenderTimeout bounds each template execution. Zero = no timeout.
+	RenderTimeout time.Duration
+
+	// MaxOutputBytes caps the bytes produced by each render. Zero = unlimited.
+	MaxOutputBytes int64
+
+	// DocumentSeparator is written between documents by RenderTo. nil = "---" on its own line.
+	DocumentSeparator *string
+
+	// OutputFormat is the encoding of the documents returned by RenderDocuments and RenderTo.
+	// Default: FormatYAML.
+	OutputFormat OutputFormat
+
+	// Canonicalize re-emits rendered YAML documents with sorted keys and normalized formatting.
+	Canonicalize bool
+
+	// KeepEmptyDocuments keeps rendered documents containing only whitespace or comments.
+	KeepEmptyDocuments bool
+
+	// StrictYAML makes every rendered document parse with a strict YAML decoder.
+	StrictYAML bool
+
+	// RequireGVK makes every rendered document have a non-empty apiVersion and kind.
+	RequireGVK bool
+
+	// GVKExemptSources lists the names or path patterns of the Sources RequireGVK does not apply to.
+	GVKExemptSources []string
+
+	// KRMOutput makes RenderTo write the rendered objects as the items of a KRM ResourceList.
+	KRMOutput bool
+
+	// CacheTTL is how long parsed templates are reused before being re-parsed from the Source FS.
+	// Zero means parsed templates never expire.
+	CacheTTL time.Duration
+
+	// WatchInterval is how often Source files are polled for changes. Zero = no watching.
+	WatchInterval time.Duration
+
+	// Clock returns the current time. nil = time.Now.
+	Clock func() time.Time
+
+	// NoCache disables both the render result cache and the reuse of parsed templates.
+	NoCache bool
+
+	// LazyValidation defers checking that Source patterns match files from New to the first render.
+	LazyValidation bool
+
+	// CacheSalt is mixed into every render cache key. Empty = keys are used as produced by the KeyFunc.
+	CacheSalt string
+
+	// FSIdentity identifies Source FSes in render cache keys. nil = FSes are compared by pointer.
+	FSIdentity func(fs.FS) string
+
+	// CacheMaxEntries bounds the render cache to an LRU of the given size. 0 = unbounded.
+	CacheMaxEntries int
+
+	// DefaultValues are deep merged under Source and render-time values.
+	DefaultValues map[string]any
+
+	// ValuesFunc provides values on every render, deep merged over DefaultValues. nil = none.
+	ValuesFunc func(context.Context) (map[string]any, error)
+
+	// EnvPrefix is prepended to the EnvAllow names to form the environment variables read.
+	EnvPrefix string
+
+	// EnvAllow lists the environment variables exposed to templates under Env. nil = none.
+	EnvAllow []string
+
+	// RandomSeed seeds the random template functions. nil = crypto/rand, not reproducible.
+	RandomSeed *int64
+
+	// Parallelism is the maximum number of Sources rendered concurrently by Process. <= 1 = sequential.
+	Parallelism int
+
+	// ContinueOnError makes Process attempt every Source instead of stopping at the first failure.
+	ContinueOnError bool
+
+	// ValuesTemplating renders string values containing template actions against the values.
+	ValuesTemplating bool
+
+	// ValueProvenance enables RenderWithProvenance.
+	ValueProvenance bool
+
+	// ValuesSchema is a JSON Schema document the merged values must satisfy. nil = no validation.
+	ValuesSchema []byte
+
+	// SetValues are Helm-style key=value pairs merged over all other values. nil = none.
+	SetValues []string
+
+	// Release is exposed to templates as .Release, over all other values. nil = no .Release.
+	Release *ReleaseInfo
+
+	// Capabilities is exposed to templates as .Capabilities, over all other values.
+	// nil = no .Capabilities.
+	Capabilities *CapabilitiesInfo
+
+	// Namespace is set on rendered namespaced objects. Empty = objects are left as rendered.
+	Namespace string
+
+	// ForceNamespace makes Namespace override namespaces already present on rendered objects.
+	ForceNamespace bool
+
+	// ClusterScopedKinds overrides the built-in kind scope list: true marks a kind as
+	// cluster-scoped, false as namespaced.
+	ClusterScopedKinds map[string]bool
+
+	// CommonLabels are added to every rendered object, without overriding labels set by templates.
+	CommonLabels map[string]string
+
+	// CommonAnnotations are added to every rendered object, without overriding annotations set by templates.
+	CommonAnnotations map[string]string
+
+	// PropagatePodLabels also adds CommonLabels to the pod templates of workload kinds.
+	PropagatePodLabels bool
+
+	// TracerProvider creates the tracer of the parse and render spans. nil = no tracing.
+	TracerProvider trace.TracerProvider
+
+	// Logger receives debug logs (V(1)) about parsing, cache hits and renders. Default: discard.
+	Logger logr.Logger
+
+	// VerboseValueLogging adds the merged values to render logs. They may contain secrets.
+	VerboseValueLogging bool
+
+	// MetricsRegisterer receives the render and cache metrics collectors. nil = no metrics.
+	MetricsRegisterer prometheus.Registerer
 }
 
+// MissingKeyMode controls the text/template "missingkey" option.
+type MissingKeyMode string
+
+const (
+	// MissingKeyError stops execution with an error when a map key is missing.
+	MissingKeyError MissingKeyMode = "error"
+
+	// MissingKeyZero returns the zero value of the map element type for missing keys.
+	// Note that for map[string]any the zero value prints as "<no value>".
+	MissingKeyZero MissingKeyMode = "zero"
+
+	// MissingKeyInvalid returns an invalid reflect.Value, printed as "<no value>".
+	MissingKeyInvalid MissingKeyMode = "invalid"
+
+	// MissingKeyDefault is the text/template default, equivalent to MissingKeyInvalid.
+	MissingKeyDefault MissingKeyMode = "default"
+)
+
+// OutputFormat is the encoding of rendered documents.
+type OutputFormat string
+
+const (
+	// FormatYAML returns documents as rendered.
+	FormatYAML OutputFormat = "yaml"
+
+	// FormatJSON re-encodes every rendered document as a JSON object.
+	FormatJSON OutputFormat = "json"
+)
+
+// Delimiters defines the left and right template action delimiters.
+type Delimiters struct {
+	Left  string
+	Right string
+}
+
+// LookupFunc fetches the object of the given apiVersion, kind, namespace and name from
+// a cluster as unstructured content, e.g. through a dynamic client. Objects that do not
+// exist are reported with a NotFound API error (apierrors.IsNotFound).
+type LookupFunc func(apiVersion string, kind string, namespace string, name string) (map[string]any, error)
+
 // ApplyTo applies the renderer options to the target configuration.
 func (opts RendererOptions) ApplyTo(target *RendererOptions) {
 	target.Filters = opts.Filters
 	target.Transformers = opts.Transformers
+	target.ObjectsTransformers = opts.ObjectsTransformers
+	target.BeforeRender = opts.BeforeRender
+	target.AfterRender = opts.AfterRender
+	target.DetectDuplicates = opts.DetectDuplicates
 
 	if opts.CacheOptions != nil {
 		if target.CacheOptions == nil {
@@ -37,6 +273,232 @@ func (opts RendererOptions) ApplyTo(target *RendererOptions) {
 	}
 
 	target.SourceAnnotations = opts.SourceAnnotations
+
+	if len(opts.FuncMap) > 0 {
+		if target.FuncMap == nil {
+			target.FuncMap = make(template.FuncMap, len(opts.FuncMap))
+		}
+		maps.Copy(target.FuncMap, opts.FuncMap)
+	}
+
+	target.SprigFunctions = opts.SprigFunctions
+	target.Checksums = opts.Checksums
+	target.KeepLineEndings = opts.KeepLineEndings
+
+	if len(opts.PassthroughPaths) > 0 {
+		target.PassthroughPaths = slices.Clone(opts.PassthroughPaths)
+	}
+
+	if opts.OverlayFS != nil {
+		target.OverlayFS = opts.OverlayFS
+	}
+
+	if opts.LookupFunc != nil {
+		target.LookupFunc = opts.LookupFunc
+	}
+
+	if opts.ImageResolver != nil {
+		target.ImageResolver = opts.ImageResolver
+	}
+
+	if opts.MissingKeyMode != "" {
+		target.MissingKeyMode = opts.MissingKeyMode
+	}
+
+	if opts.Layout != "" {
+		target.Layout = opts.Layout
+	}
+
+	if opts.MaxIncludeDepth > 0 {
+		target.MaxIncludeDepth = opts.MaxIncludeDepth
+	}
+
+	if opts.RenderTimeout > 0 {
+		target.RenderTimeout = opts.RenderTimeout
+	}
+
+	if opts.MaxOutputBytes > 0 {
+		target.MaxOutputBytes = opts.MaxOutputBytes
+	}
+
+	if opts.DocumentSeparator != nil {
+		sep := *opts.DocumentSeparator
+		target.DocumentSeparator = &sep
+	}
+
+	if opts.OutputFormat != "" {
+		target.OutputFormat = opts.OutputFormat
+	}
+
+	target.Canonicalize = opts.Canonicalize
+	target.KeepEmptyDocuments = opts.KeepEmptyDocuments
+	target.StrictYAML = opts.StrictYAML
+	target.RequireGVK = opts.RequireGVK
+
+	if len(opts.GVKExemptSources) > 0 {
+		target.GVKExemptSources = slices.Clone(opts.GVKExemptSources)
+	}
+
+	target.KRMOutput = opts.KRMOutput
+
+	if opts.CacheTTL > 0 {
+		target.CacheTTL = opts.CacheTTL
+	}
+
+	if opts.WatchInterval > 0 {
+		target.WatchInterval = opts.WatchInterval
+	}
+
+	if opts.Clock != nil {
+		target.Clock = opts.Clock
+	}
+
+	if opts.FSIdentity != nil {
+		target.FSIdentity = opts.FSIdentity
+	}
+
+	if opts.CacheMaxEntries > 0 {
+		target.CacheMaxEntries = opts.CacheMaxEntries
+	}
+
+	target.NoCache = opts.NoCache
+	target.LazyValidation = opts.LazyValidation
+
+	if opts.CacheSalt != "" {
+		target.CacheSalt = opts.CacheSalt
+	}
+
+	if opts.DefaultValues != nil {
+		target.DefaultValues = util.DeepMerge(target.DefaultValues, opts.DefaultValues)
+	}
+
+	if opts.ValuesFunc != nil {
+		target.ValuesFunc = opts.ValuesFunc
+	}
+
+	if opts.EnvAllow != nil {
+		target.EnvPrefix = opts.EnvPrefix
+		target.EnvAllow = slices.Clone(opts.EnvAllow)
+	}
+
+	if opts.RandomSeed != nil {
+		seed := *opts.RandomSeed
+		target.RandomSeed = &seed
+	}
+
+	if opts.Parallelism > 0 {
+		target.Parallelism = opts.Parallelism
+	}
+
+	target.ContinueOnError = opts.ContinueOnError
+
+	target.ValuesTemplating = opts.ValuesTemplating
+	target.ValueProvenance = opts.ValueProvenance
+
+	if opts.ValuesSchema != nil {
+		target.ValuesSchema = opts.ValuesSchema
+	}
+
+	if len(opts.SetValues) > 0 {
+		target.SetValues = append(slices.Clone(target.SetValues), opts.SetValues...)
+	}
+
+	if opts.Release != nil {
+		release := *opts.Release
+		target.Release = &release
+	}
+
+	if opts.Capabilities != nil {
+		capabilities := *opts.Capabilities
+		target.Capabilities = &capabilities
+	}
+
+	if opts.Namespace != "" {
+		target.Namespace = opts.Namespace
+	}
+
+	target.ForceNamespace = opts.ForceNamespace
+
+	if len(opts.ClusterScopedKinds) > 0 {
+		if target.ClusterScopedKinds == nil {
+			target.ClusterScopedKinds = make(map[string]bool, len(opts.ClusterScopedKinds))
+		}
+		maps.Copy(target.ClusterScopedKinds, opts.ClusterScopedKinds)
+	}
+
+	if len(opts.CommonLabels) > 0 {
+		if target.CommonLabels == nil {
+			target.CommonLabels = make(map[string]string, len(opts.CommonLabels))
+		}
+		maps.Copy(target.CommonLabels, opts.CommonLabels)
+	}
+
+	if len(opts.CommonAnnotations) > 0 {
+		if target.CommonAnnotations == nil {
+			target.CommonAnnotations = make(map[string]string, len(opts.CommonAnnotations))
+		}
+		maps.Copy(target.CommonAnnotations, opts.CommonAnnotations)
+	}
+
+	target.PropagatePodLabels = opts.PropagatePodLabels
+
+	if opts.TracerProvider != nil {
+		target.TracerProvider = opts.TracerProvider
+	}
+
+	if opts.Logger.GetSink() != nil {
+		target.Logger = opts.Logger
+	}
+
+	target.VerboseValueLogging = opts.VerboseValueLogging
+
+	if opts.MetricsRegisterer != nil {
+		target.MetricsRegisterer = opts.MetricsRegisterer
+	}
+
+	if opts.Delimiters != nil {
+		target.Delimiters = &Delimiters{
+			Left:  opts.Delimiters.Left,
+			Right: opts.Delimiters.Right,
+		}
+	}
+}
+
+// validate checks the combined options for invalid configurations.
+func (opts RendererOptions) validate() error {
+	if d := opts.Delimiters; d != nil {
+		if d.Left == "" || d.Right == "" || d.Left == d.Right {
+			return fmt.Errorf("%w: left=%q, right=%q", ErrInvalidDelimiters, d.Left, d.Right)
+		}
+	}
+
+	switch opts.MissingKeyMode {
+	case MissingKeyError, MissingKeyZero, MissingKeyInvalid, MissingKeyDefault:
+	default:
+		return fmt.Errorf(
+			"%w: %q (supported: %q, %q, %q, %q)",
+			ErrInvalidMissingKeyMode,
+			opts.MissingKeyMode,
+			MissingKeyError,
+			MissingKeyZero,
+			MissingKeyInvalid,
+			MissingKeyDefault,
+		)
+	}
+
+	switch opts.OutputFormat {
+	case FormatYAML, FormatJSON:
+	default:
+		return fmt.Errorf("%w: %q (supported: %q, %q)", ErrInvalidOutputFormat, opts.OutputFormat, FormatYAML, FormatJSON)
+	}
+
+	for _, pattern := range opts.PassthroughPaths {
+		if err := validatePassthrough(pattern); err != nil {
+			return err
+		}
+	}
+
+	return nil
 }
 
 // WithFilter adds a renderer-specific filter to this GoTemplate renderer's processing chain.
@@ -57,6 +519,31 @@ func WithTransformer(t types.Transformer) RendererOption {
 	})
 }
 
+// WithObjectsTransformer adds a transformer of the complete set of rendered objects, run by
+// Process, Validate and RenderObjects once all Sources are rendered, after the per-Source filters
+// and transformers. Transformers compose in registration order, each receiving the objects
+// returned by the previous one, so they may add, remove or modify objects. Errors identify the
+// failing transformer by its registration index. With WithContinueOnError the transformers run
+// over the objects of the Sources rendered successfully.
+func WithObjectsTransformer(t ObjectsTransformer) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ObjectsTransformers = append(opts.ObjectsTransformers, t)
+	})
+}
+
+// WithDetectDuplicates makes Process and RenderObjects check, once all Sources are rendered,
+// that no two objects share the same apiVersion, kind, namespace and name, which would conflict
+// when applied. Collisions are reported by a *DuplicateError listing every colliding identity
+// with the Sources rendering it. Identities are taken after namespace defaulting
+// (WithNamespace) and before the WithObjectsTransformer transformers; objects without apiVersion,
+// kind or name are not manifests and are ignored. With WithContinueOnError the objects are
+// returned together with the error.
+func WithDetectDuplicates() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DetectDuplicates = true
+	})
+}
+
 // WithCache enables render result caching with the specified options.
 // If no options are provided, uses default TTL of 5 minutes.
 // By default, caching is NOT enabled.
@@ -81,3 +568,660 @@ func WithSourceAnnotations(enabled bool) RendererOption {
 		opts.SourceAnnotations = enabled
 	})
 }
+
+// WithFuncMap registers custom functions available to all templates.
+// The functions are attached to each template set before parsing, so templates
+// referencing them parse successfully. Multiple WithFuncMap options are merged,
+// with functions from later options overriding earlier ones of the same name.
+func WithFuncMap(funcs template.FuncMap) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		if opts.FuncMap == nil {
+			opts.FuncMap = make(template.FuncMap, len(funcs))
+		}
+
+		maps.Copy(opts.FuncMap, funcs)
+	})
+}
+
+// WithSprigFunctions enables a curated subset of Sprig-compatible template functions.
+//
+// Included functions:
+//   - defaults: empty, coalesce, ternary
+//   - strings: upper, lower, trim, trimAll, trimPrefix, trimSuffix, trunc, replace, contains,
+//     hasPrefix, hasSuffix, repeat, nospace, join, splitList, toString
+//   - collections: list, dict, keys
+//
+// Functions that depend on the environment or network (env, expandenv, getHostByName)
+// are deliberately excluded to keep rendering hermetic.
+// Built-in functions (toYaml, fromYaml, toJson, toPrettyJson, fromJson, required, default,
+// quote, squote, sha256sum, b64enc, b64dec, indent, nindent, dnsName, truncName, merge,
+// mergeOverwrite, dig, hasKey, get, quantity, semver, semverCompare, randAlphaNum, randAlpha,
+// randNumeric, include, tpl, readFile, readGlob, filesAsMap, fileAsBase64, fileAsString, lookup,
+// imageDigest, namespace) are available with or without this option.
+// Functions registered via WithFuncMap take precedence over the bundled ones.
+func WithSprigFunctions() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.SprigFunctions = true
+	})
+}
+
+// WithNormalizeLineEndings controls whether template files are normalized before parsing:
+// a leading UTF-8 byte order mark is stripped and CRLF line endings are converted to LF, so
+// templates authored on Windows or exported by some tools do not render an invisible BOM or
+// trailing "\r" characters into the YAML. Pass false to parse the raw bytes, e.g. for
+// templates that must emit CRLF. Default: enabled.
+func WithNormalizeLineEndings(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.KeepLineEndings = !enabled
+	})
+}
+
+// WithPassthrough makes the Source files matching any of patterns be emitted verbatim instead
+// of being parsed as templates, e.g. static manifests next to templates in one directory:
+//
+//	gotemplate.Source{FS: bundle, Path: "manifests/*"}
+//	gotemplate.WithPassthrough("*.yaml") // manifests/*.yaml.tmpl files are still rendered
+//
+// Patterns apply to the files matched by the Source patterns: those containing "/" match the
+// file path relative to the FS root, supporting "**" like Source patterns, the others match the
+// base name. Passthrough files keep their place in template name order and, apart from not
+// being parsed, are handled like templates: their output is split into documents, decoded and
+// run through the filters and transformers, and include can reference them. Their content is
+// normalized like templates (see WithNormalizeLineEndings). Can be called multiple times.
+func WithPassthrough(patterns ...string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.PassthroughPaths = append(opts.PassthroughPaths, patterns...)
+	})
+}
+
+// WithOverlayFS layers overlay over the FS of every Source: a file of overlay replaces the file
+// of a Source FS with the same path, e.g. to customize a few templates of a shared base set per
+// environment without copying the whole set. Files only present in overlay are added, so they
+// are matched by Source patterns like base files. Templates keep the name they have in the base
+// FS, and template functions (readFile, filesAsMap) and ValuesFiles read through the overlay
+// too. Every override is logged at V(1) when the file is read.
+func WithOverlayFS(overlay fs.FS) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.OverlayFS = overlay
+	})
+}
+
+// WithChecksums enables the checksumOf template function, returning the SHA-256 of another
+// template of the same Source rendered with the same values, e.g. to roll pods when their
+// ConfigMap changes:
+//
+//	checksum/config: {{ checksumOf "configmap.yaml" }}
+//
+// Referenced templates are rendered first, on demand, once per execution; templates whose
+// checksums reference each other, directly or through others, fail with ErrChecksumCycle.
+// As the function depends on the execution, every template then runs from a clone of its
+// parsed set. A checksumOf function registered with WithFuncMap takes precedence.
+func WithChecksums() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Checksums = true
+	})
+}
+
+// WithImageResolver backs the imageDigest template function with resolve, so templates can pin
+// images by digest for reproducible deploys while the resolution strategy (a registry query, a
+// static map) stays pluggable:
+//
+//	image: {{ imageDigest "quay.io/org/app:v1.2.0" }} # quay.io/org/app@sha256:...
+//
+// Executions call resolve with the render context, and resolve errors fail the render. Without
+// this option imageDigest returns references unchanged, so dry-renders work. Cached render
+// results (WithCache) are returned without calling resolve again. As the function depends on
+// the execution, every template then runs from a clone of its parsed set. An imageDigest
+// function registered with WithFuncMap takes precedence.
+func WithImageResolver(resolve ImageResolver) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ImageResolver = resolve
+	})
+}
+
+// WithLookupFunc backs the lookup template function with fn, so templates can reference existing
+// cluster state, e.g. {{ (lookup "v1" "Secret" .namespace "db").data.password }}, while the
+// renderer stays cluster-agnostic. As with Helm, lookup returns an empty map for objects that do
+// not exist (fn returning a NotFound API error) and, without this option, for every lookup, so
+// dry-renders work. Other errors fail the render. Cached render results (WithCache) are
+// returned without calling fn again.
+func WithLookupFunc(fn LookupFunc) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.LookupFunc = fn
+	})
+}
+
+// WithDelimiters sets custom template action delimiters, e.g. "[[" and "]]".
+// This is useful when templates embed "{{ }}" belonging to another templating layer
+// (Prometheus rules, Grafana dashboards). Both delimiters must be non-empty and distinct,
+// otherwise New returns ErrInvalidDelimiters.
+//
+// Delimiters are fixed for the lifetime of a renderer and each renderer owns its cache,
+// so renderers configured with different delimiters never share cache entries.
+func WithDelimiters(left string, right string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Delimiters = &Delimiters{
+			Left:  left,
+			Right: right,
+		}
+	})
+}
+
+// WithMissingKeyMode controls how templates handle references to missing map keys.
+// The default, MissingKeyError, fails fast on undefined values; the other modes allow
+// optional values to render as empty. Unknown modes are rejected by New.
+func WithMissingKeyMode(mode MissingKeyMode) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.MissingKeyMode = mode
+	})
+}
+
+// WithLayout renders every Source by executing the base template called baseName instead of
+// each template, for consistent scaffolding: the base declares {{ block }} sections and the
+// other files of the Source override them with {{ define }}. The file named baseName is parsed
+// first, so overrides always win regardless of file names, and blocks that are not overridden
+// render their default content. A Source not defining baseName fails with a *TemplateNotFoundError.
+func WithLayout(baseName string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Layout = baseName
+	})
+}
+
+// WithMaxIncludeDepth caps how deeply include and tpl calls may nest within one execution,
+// so mutually recursive templates fail with a *MaxDepthError (wrapping ErrMaxDepthExceeded)
+// listing the call chain instead of exhausting the stack. The depth travels with each call
+// chain, so parallel renders (WithParallelism) never affect each other. The template action
+// is bounded by text/template itself. n <= 0 keeps the default of 100.
+func WithMaxIncludeDepth(n int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		if n > 0 {
+			opts.MaxIncludeDepth = n
+		}
+	})
+}
+
+// WithRenderTimeout bounds each template execution, so a pathological template (deep tpl
+// recursion, a huge range) cannot wedge the caller. A template not completing within d fails
+// with ErrRenderTimeout, identifying the Source. The execution cannot be interrupted and finishes
+// in the background; its output is discarded. With a timeout, RenderTo buffers the output of
+// each template before writing it. A duration of zero (the default) means no timeout.
+func WithRenderTimeout(d time.Duration) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RenderTimeout = d
+	})
+}
+
+// WithMaxOutputBytes caps the bytes produced by a render, so untrusted values cannot drive
+// templates (e.g. through repeat) to exhaust memory. Each rendering call shares one budget
+// across the template executions of all its Sources, charging include, tpl and checksumOf
+// results as they are produced, and repeat refuses results larger than the cap up front.
+// The render aborts with ErrOutputTooLarge as soon as the budget is exhausted, for buffered
+// renders and RenderTo alike; with RenderTo, output written before the failure has already
+// reached the writer. A cap of zero (the default) means unlimited.
+func WithMaxOutputBytes(n int64) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.MaxOutputBytes = n
+	})
+}
+
+// WithDocumentSeparator sets the bytes written between documents by RenderTo and
+// RenderWithProvenance, for downstream tools splitting documents differently, e.g. "\n...\n"
+// end-of-document markers. The separator is written as is, so it should start with a newline
+// when documents may not end with one; an empty separator concatenates documents directly.
+// Default: "---" on its own line, preceded by a newline when the previous document lacks one.
+func WithDocumentSeparator(sep string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DocumentSeparator = &sep
+	})
+}
+
+// WithOutputFormat sets the encoding of the documents returned by RenderDocuments and written
+// by RenderTo and RenderWithProvenance. FormatJSON parses every rendered document as YAML and
+// re-encodes it as a compact JSON object on a single line, with keys sorted so the output is
+// deterministic; RenderTo then writes one object per line (JSON Lines) unless a separator is
+// set with WithDocumentSeparator. Documents that are not YAML mappings, e.g. the output of
+// non-manifest Sources, fail with ErrInvalidYAML, and empty documents are dropped even with
+// WithKeepEmptyDocuments since they have no JSON form. Process, RenderObjects and
+// RenderTemplate are not affected. Unknown formats are rejected by New.
+// Default: FormatYAML.
+func WithOutputFormat(format OutputFormat) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.OutputFormat = format
+	})
+}
+
+// WithKeepEmptyDocuments keeps the rendered documents containing only whitespace or comments,
+// which are dropped by default so that e.g. a template guarded by {{ if .enabled }} produces no
+// document when disabled. Every document between separators is then kept, including the empty
+// ones before a leading or after a trailing "---". Kept documents are returned by RenderDocuments
+// as rendered (empty for whitespace-only documents) and count towards document indices in
+// errors, but decode to no objects.
+func WithKeepEmptyDocuments() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.KeepEmptyDocuments = true
+	})
+}
+
+// WithCanonicalize makes RenderDocuments and RenderTo parse every rendered YAML document and
+// re-emit it with sorted map keys, two-space indentation and normalized quoting, so the output
+// only changes with the manifests it describes, not with the formatting of the templates, e.g.
+// for GitOps drift detection. Comments are dropped. Documents that are not YAML mappings or
+// sequences are kept as rendered, and so are documents failing to parse unless WithStrictYAML
+// is set, which makes them fail with ErrInvalidYAML. With FormatJSON, which already sorts keys,
+// the option has no effect. Process and RenderObjects return objects and are not affected.
+func WithCanonicalize() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Canonicalize = true
+	})
+}
+
+// WithStrictYAML parses every rendered document with a strict YAML decoder before it is returned
+// or decoded into objects, rejecting duplicate keys and malformed indentation. A failing document
+// fails the render with ErrInvalidYAML, identifying the Source, template and document index.
+// Documents are checked by RenderDocuments (which otherwise returns them unparsed), RenderObjects
+// and Process; RenderTo and RenderTemplate are left alone so non-YAML output keeps working.
+// Default: disabled.
+func WithStrictYAML() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.StrictYAML = true
+	})
+}
+
+// WithRequireGVK makes every non-empty rendered document have a non-empty apiVersion and kind,
+// so half-rendered manifests (e.g. a kind produced by a missing value) never reach the cluster.
+// A failing document fails the render with ErrMissingGVK, identifying the Source, template and
+// document index and quoting the first line of the document. Sources intentionally emitting
+// non-manifest output are exempted by listing their Name or Path pattern in exempt. Documents
+// are checked like with WithStrictYAML. Default: disabled.
+func WithRequireGVK(exempt ...string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RequireGVK = true
+		opts.GVKExemptSources = append(opts.GVKExemptSources, exempt...)
+	})
+}
+
+// WithKRMOutput makes the renderer usable as a KRM function (kustomize, kpt): RenderTo and
+// RenderWithProvenance render the Sources into objects like Process, filters and transformers
+// included, and write them as the items of a single config.kubernetes.io/v1 ResourceList,
+// encoded in the configured OutputFormat. Since every item must be a manifest, every non-empty
+// rendered document must then have an apiVersion and kind, as with WithRequireGVK but without
+// exemptions, failing the render with ErrMissingGVK (or ErrInvalidYAML for documents that do
+// not parse) instead of dropping it. Other rendering methods return their usual shapes.
+// Default: disabled.
+func WithKRMOutput() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.KRMOutput = true
+	})
+}
+
+// WithCacheTTL sets how long parsed templates are reused before being re-parsed from the Source FS.
+// Expiration is checked lazily when templates are loaded, so no background goroutine is started.
+// This lets long-running processes pick up template changes on disk.
+// A TTL of zero (the default) means parsed templates never expire.
+//
+// Render results cached via WithCache expire independently according to their own TTL.
+func WithCacheTTL(d time.Duration) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CacheTTL = d
+	})
+}
+
+// WithWatch polls the files matched by every Source pattern each interval and, when a file is
+// added, removed or modified (by size or modification time), discards the parsed templates and
+// cached render results of that Source so the next render picks up the change. Polling works
+// with any fs.FS, since fs.FS offers no change notification; implementations reporting zero
+// modification times (e.g. embed.FS) only detect size changes and added or removed files.
+//
+// Watching starts in New and runs in a background goroutine until Close is called.
+func WithWatch(interval time.Duration) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.WatchInterval = interval
+	})
+}
+
+// WithClock sets the function used to obtain the current time for expiration checks.
+// Primarily useful for testing. Default: time.Now.
+func WithClock(now func() time.Time) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Clock = now
+	})
+}
+
+// WithCacheMaxEntries bounds the render cache to at most n entries, evicting the
+// least recently used result on overflow. This prevents unbounded growth when a
+// renderer is used with many distinct values (e.g. across namespaces).
+// Only effective when caching is enabled via WithCache. A value of 0 means unbounded (default).
+func WithCacheMaxEntries(n int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CacheMaxEntries = n
+	})
+}
+
+// WithBeforeRender adds a hook called by Process before rendering each Source, e.g. for audit
+// logging or timing. The spec carries the Source name, path and FS identity and a copy of the
+// merged values, so the hook cannot alter the render. Hooks run in registration order on the
+// goroutine rendering the Source, concurrently for different Sources with WithParallelism.
+func WithBeforeRender(hook BeforeRenderHook) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.BeforeRender = append(opts.BeforeRender, hook)
+	})
+}
+
+// WithAfterRender adds a hook called by Process after rendering each Source, with the spec given
+// to the WithBeforeRender hooks, a copy of the output of the Source templates and the render
+// error. The output is nil when the render failed or was served from the render cache. Sources
+// whose templates or values fail to load reach both hooks too, with a spec without Values and
+// the load error. Hooks only observe the render; use WithObjectsTransformer to modify the
+// output. Hooks run in registration order on the goroutine rendering the Source.
+func WithAfterRender(hook AfterRenderHook) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.AfterRender = append(opts.AfterRender, hook)
+	})
+}
+
+// WithLazyValidation defers checking that every Source pattern matches at least one file from
+// New to the first render, for Sources whose files appear after the renderer is created, e.g.
+// a directory populated by a sidecar. By default New fails fast with ErrNoMatchingTemplates,
+// so a bad pattern surfaces at controller startup rather than mid-reconcile. The Source
+// configuration itself is always validated in New.
+func WithLazyValidation() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.LazyValidation = true
+	})
+}
+
+// WithNoCache disables all caching: templates are re-parsed from the Source FS on every render
+// and the render result cache is bypassed, even if WithCache is also given, so no cache key is
+// ever computed. Useful when iterating on templates and for one-shot invocations where caching
+// is pure overhead. Concurrent renders stay safe since each one works on its own template set.
+func WithNoCache() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.NoCache = true
+	})
+}
+
+// WithCacheSalt mixes salt into every render cache key, whichever KeyFunc is configured, so
+// renderers with different salts never produce the same key for identical templates and values.
+// This isolates tenants in multi-tenant setups that share key space (e.g. a custom KeyFunc used
+// for external storage or logging), and likewise helps when one process serves several
+// configurations. The salt is applied deterministically, so keys stay stable across restarts.
+// Only effective when caching is enabled via WithCache.
+func WithCacheSalt(salt string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.CacheSalt = salt
+	})
+}
+
+// WithFSIdentity sets how Source FSes are identified in render cache keys (TemplateSpec.FS), so
+// that Sources sharing a path but reading different FSes (e.g. embedded and on disk) never
+// share cached results. By default FSes are compared by pointer for reference types such as
+// fstest.MapFS and by value otherwise (e.g. os.DirFS), which suits most FS types; identity
+// can name FSes where that is insufficient, e.g. value types not comparable or wrappers
+// created per Source around the same files. Equal identities must serve the same files.
+// An empty identity falls back to the default comparison.
+func WithFSIdentity(identity func(fs.FS) string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.FSIdentity = identity
+	})
+}
+
+// WithDefaultValues sets chart-like default values that Source and render-time values override.
+// Precedence, lowest to highest: default values, Source values, render-time values.
+// Nested maps are merged recursively while slices and scalars are replaced, and the merge
+// happens before execution so missingkey=error sees the merged result.
+// Multiple WithDefaultValues options are deep merged, with later options winning.
+func WithDefaultValues(values map[string]any) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.DefaultValues = util.DeepMerge(opts.DefaultValues, values)
+	})
+}
+
+// WithValuesFunc sets a provider of values computed on every render, e.g. timestamps or values
+// derived from the request context, complementing the static WithDefaultValues. The provider is
+// called with the rendering context for every Source, as Source.Values is, and its values are
+// deep merged as defaults: over WithDefaultValues, under Source and render-time values, and not
+// at all for ValuesModeReplace Sources. An error aborts the render, naming the Source. The
+// returned values are part of the render cache key, so changing values miss the cache.
+func WithValuesFunc(fn func(context.Context) (map[string]any, error)) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ValuesFunc = fn
+	})
+}
+
+// WithEnvValues exposes the allowlisted environment variables to templates as .Env, e.g. for CI
+// to inject a commit SHA: with prefix "CI_" and allow ["COMMIT_SHA"], the value of CI_COMMIT_SHA
+// is available as {{ .Env.COMMIT_SHA }}. Only the listed variables are read, on every render;
+// unset ones are left out of Env. The Env map is merged as a default, over WithDefaultValues
+// and under WithValuesFunc, Source and render-time values.
+//
+// Rendering is hermetic by default and there is intentionally no env template function: it
+// would let any template read the whole process environment, including credentials, and make
+// the output depend on it, which an allowlist keeps narrow and auditable. A later
+// WithEnvValues replaces the prefix and allowlist of an earlier one.
+func WithEnvValues(prefix string, allow []string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.EnvPrefix = prefix
+		opts.EnvAllow = slices.Clone(allow)
+		if opts.EnvAllow == nil {
+			opts.EnvAllow = make([]string, 0)
+		}
+	})
+}
+
+// WithRandomSeed makes the random template functions (randAlphaNum, randAlpha, randNumeric)
+// reproducible: each template draws from its own stream derived from seed, the Source name
+// and path and the template name, so the same templates yield the same strings on every
+// render, in any order. Without a seed they draw from crypto/rand and change on every render,
+// which makes generated passwords churn under GitOps; a seed keeps them stable, but anyone
+// knowing it and the templates can recompute them, so treat the seed as a secret. Render-time
+// values do not affect the streams. A later WithRandomSeed replaces an earlier one.
+func WithRandomSeed(seed int64) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.RandomSeed = &seed
+	})
+}
+
+// WithValuesTemplating lets values reference other values, e.g. fullname: "{{ .name }}-web".
+// Before any template is executed, string values containing template actions are rendered
+// against the merged values, with the delimiters, missing key mode and functions of the Source
+// templates, in passes until no value changes, so references may chain. Values referencing
+// themselves, directly or through other values, fail with ErrValuesCycle instead of looping.
+// Schema validation (WithValuesSchema) sees the rendered values. Default: disabled.
+func WithValuesTemplating() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ValuesTemplating = true
+	})
+}
+
+// WithSetValues sets values from Helm-style --set pairs such as "image.tag=v2", for CLI-driven
+// rendering. Keys are dotted paths whose segments may index lists ("ports[0].port=8080"), a pair
+// may hold several comma separated assignments ("a=1,b=2"), values in braces are lists
+// ("args={--verbose,--debug}") and a backslash escapes the next character ("a\.b=x" sets the
+// key "a.b"). Like Helm, true and false become bools, integers become int64, null unsets the
+// key and anything else is a string. The pairs apply over all other values, with the highest
+// precedence, except for ValuesModeReplace Sources, which use their own values only. As with
+// Helm, they update the merged values in place: "a[0].b=x" sets b in the first element of an
+// existing list a, keeping its other elements and keys.
+// Pairs apply in order, later ones winning, and multiple WithSetValues options accumulate.
+// Malformed pairs fail New with ErrInvalidSetValue.
+func WithSetValues(pairs ...string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.SetValues = append(opts.SetValues, pairs...)
+	})
+}
+
+// WithReleaseInfo exposes release metadata to templates as .Release, like Helm does, so chart
+// templates referencing {{ .Release.Name }} or {{ .Release.Namespace }} render unchanged. The
+// Release map has the Name, Namespace, Service and Revision keys; an empty Namespace defaults
+// to the WithNamespace namespace. Release is reserved: it is deep merged over all other values,
+// WithSetValues pairs included, in every ValuesMode, so its keys win over user values under
+// Release. It is merged after values templating and schema validation, so schemas
+// (WithValuesSchema) only describe user values. A later WithReleaseInfo replaces an earlier one.
+func WithReleaseInfo(info ReleaseInfo) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Release = &info
+	})
+}
+
+// WithCapabilities exposes the target cluster capabilities to templates as .Capabilities, like
+// Helm does, so chart templates can branch on what the cluster supports:
+//
+//	{{ if .Capabilities.APIVersions.Has "policy/v1" }}
+//	{{ if semverCompare ">=1.28-0" .Capabilities.KubeVersion.Version }}
+//
+// KubeVersion is normalized with a "v" prefix and missing components ("1.29" is "v1.29.0"); New
+// fails with ErrInvalidKubeVersion when it is not a semantic version. Like Release (see
+// WithReleaseInfo), Capabilities is reserved and merged over all other values in every
+// ValuesMode, after schema validation. A later WithCapabilities replaces an earlier one.
+func WithCapabilities(info CapabilitiesInfo) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Capabilities = &info
+	})
+}
+
+// WithValueProvenance enables RenderWithProvenance, which reports the layer (default values, a
+// values file, the Source values or the render-time values) supplying each merged value, for
+// debugging complex merges. Other rendering methods are unaffected. Default: disabled.
+func WithValueProvenance() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ValueProvenance = true
+	})
+}
+
+// WithValuesSchema validates the fully merged values (defaults, Source and render-time values)
+// against the given JSON Schema before any template is executed. The schema is compiled in New,
+// failing with ErrInvalidValuesSchema if it is malformed. Values that do not satisfy it fail
+// rendering with a *ValuesValidationError listing every violation.
+func WithValuesSchema(schema []byte) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ValuesSchema = schema
+	})
+}
+
+// WithParallelism renders up to n Sources concurrently in Process using a bounded worker pool.
+// Output is merged in Source order, so results are identical to sequential rendering.
+// The first failing Source cancels the remaining work and all failures are returned joined.
+// Values below 2 keep rendering sequential.
+func WithParallelism(n int) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Parallelism = n
+	})
+}
+
+// WithContinueOnError makes Process render every Source even when some fail, returning the
+// objects of the successful Sources together with an error joining each failure (see errors.Join).
+// Every joined error identifies its Source pattern. Intended for "render everything and report"
+// validation workflows; callers must check the error even when objects are returned.
+func WithContinueOnError() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.ContinueOnError = true
+	})
+}
+
+// WithNamespace sets ns as metadata.namespace on every rendered namespaced object that has
+// no namespace yet, so namespace-less manifests can be targeted at render time. Cluster-scoped
+// kinds (ClusterRole, Namespace, CustomResourceDefinition, ...) are left untouched; scope is
+// decided from a built-in list of Kubernetes kinds, adjustable via WithClusterScopedKinds.
+// The namespace is stamped before renderer filters and transformers run. Templates read it with
+// the namespace function, e.g. for RoleBinding subjects, which returns "" without WithNamespace.
+func WithNamespace(ns string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Namespace = ns
+		opts.ForceNamespace = false
+	})
+}
+
+// WithForceNamespace is like WithNamespace but also replaces namespaces set by the templates.
+func WithForceNamespace(ns string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Namespace = ns
+		opts.ForceNamespace = true
+	})
+}
+
+// WithClusterScopedKinds overrides the scope of kinds for WithNamespace and WithForceNamespace,
+// typically to declare cluster-scoped custom resources. true marks a kind as cluster-scoped,
+// false as namespaced. Multiple WithClusterScopedKinds options are merged, later ones winning.
+func WithClusterScopedKinds(kinds map[string]bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		if opts.ClusterScopedKinds == nil {
+			opts.ClusterScopedKinds = make(map[string]bool, len(kinds))
+		}
+
+		maps.Copy(opts.ClusterScopedKinds, kinds)
+	})
+}
+
+// WithCommonLabels adds labels to every rendered object, e.g. app.kubernetes.io/managed-by.
+// Labels set by the templates are kept; only missing keys are added. Labels are applied
+// before renderer filters and transformers run. Multiple WithCommonLabels options are merged,
+// later ones winning.
+func WithCommonLabels(labels map[string]string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		if opts.CommonLabels == nil {
+			opts.CommonLabels = make(map[string]string, len(labels))
+		}
+
+		maps.Copy(opts.CommonLabels, labels)
+	})
+}
+
+// WithCommonAnnotations adds annotations to every rendered object, keeping annotations set
+// by the templates. Multiple WithCommonAnnotations options are merged, later ones winning.
+func WithCommonAnnotations(annotations map[string]string) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		if opts.CommonAnnotations == nil {
+			opts.CommonAnnotations = make(map[string]string, len(annotations))
+		}
+
+		maps.Copy(opts.CommonAnnotations, annotations)
+	})
+}
+
+// WithPropagatePodLabels enables or disables adding WithCommonLabels labels to the pod templates
+// of workload kinds (Deployment, StatefulSet, DaemonSet, ReplicaSet, ReplicationController, Job
+// and CronJob), so the labels reach the created pods. Selectors are never modified.
+// Default: false (disabled).
+func WithPropagatePodLabels(enabled bool) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.PropagatePodLabels = enabled
+	})
+}
+
+// WithTracerProvider enables OpenTelemetry tracing of Process: every Source is rendered within a
+// SpanRender span, around a nested SpanLoadTemplates span, carrying the Source path and name and,
+// with the render cache enabled, whether it was hit. Spans are children of the span in the context
+// passed to Process. Without this option no tracing code runs.
+func WithTracerProvider(provider trace.TracerProvider) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.TracerProvider = provider
+	})
+}
+
+// WithLogger sets the logger receiving debug logs (V(1)): when templates are parsed or reused,
+// on render cache hits and misses, and when a Source is rendered, with the Source name, path and
+// durations. Values are never logged unless WithVerboseValueLogging is set.
+// Default: logr.Discard().
+func WithLogger(log logr.Logger) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.Logger = log
+	})
+}
+
+// WithVerboseValueLogging adds the merged values to the render logs of WithLogger, for debugging.
+// Values often carry secrets, so this should not be enabled in production.
+func WithVerboseValueLogging() RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.VerboseValueLogging = true
+	})
+}
+
+// WithMetricsRegisterer registers Prometheus metrics updated by Process, labeled by "source" (the
+// Source name, or its path pattern if unnamed): gotemplate_renders_total, gotemplate_render_errors_total,
+// gotemplate_cache_hits_total, gotemplate_cache_misses_total and the gotemplate_render_duration_seconds
+// histogram. Renderers sharing a registerer share the collectors, so creating several is safe.
+func WithMetricsRegisterer(reg prometheus.Registerer) RendererOption {
+	return util.FunctionalOption[RendererOptions](func(opts *RendererOptions) {
+		opts.MetricsRegisterer = reg
+	})
+}
@@ -0,0 +1,99 @@
+package gotemplate
+
+import (
+	"cmp"
+	"errors"
+	"io/fs"
+	"maps"
+	"slices"
+
+	"github.com/go-logr/logr"
+)
+
+// overlayFS serves the files of overlay in place of the files of base with the same path, and
+// the files of base otherwise. Directory listings (ReadDir) merge both FSes sorted by name, so
+// globbing the union is deterministic and matches files of either.
+type overlayFS struct {
+	base    fs.FS
+	overlay fs.FS
+	log     logr.Logger
+}
+
+func newOverlayFS(base fs.FS, overlay fs.FS, log logr.Logger) *overlayFS {
+	return &overlayFS{
+		base:    base,
+		overlay: overlay,
+		log:     log,
+	}
+}
+
+// Open opens name from overlay when it exists there, from base otherwise.
+func (o *overlayFS) Open(name string) (fs.File, error) {
+	if !fs.ValidPath(name) {
+		return nil, &fs.PathError{Op: "open", Path: name, Err: fs.ErrInvalid}
+	}
+
+	f, err := o.overlay.Open(name)
+	switch {
+	case err == nil:
+		o.logOverride(name, f)
+
+		return f, nil
+	case errors.Is(err, fs.ErrNotExist):
+		return o.base.Open(name)
+	default:
+		return nil, err
+	}
+}
+
+// logOverride logs files of overlay shadowing a file of base.
+func (o *overlayFS) logOverride(name string, f fs.File) {
+	info, err := f.Stat()
+	if err != nil || info.IsDir() {
+		return
+	}
+
+	if _, err := fs.Stat(o.base, name); err == nil {
+		o.log.V(1).Info("overlay overrides file", "file", name)
+	}
+}
+
+// Stat returns the file info of name from overlay when it exists there, from base otherwise.
+func (o *overlayFS) Stat(name string) (fs.FileInfo, error) {
+	info, err := fs.Stat(o.overlay, name)
+	if err == nil || !errors.Is(err, fs.ErrNotExist) {
+		return info, err
+	}
+
+	return fs.Stat(o.base, name)
+}
+
+// ReadDir returns the entries of the directory name in either FS, sorted by name, taking the
+// entries of overlay over those of base with the same name.
+func (o *overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	baseEntries, baseErr := fs.ReadDir(o.base, name)
+	if baseErr != nil && !errors.Is(baseErr, fs.ErrNotExist) {
+		return nil, baseErr
+	}
+
+	overlayEntries, overlayErr := fs.ReadDir(o.overlay, name)
+	if overlayErr != nil && !errors.Is(overlayErr, fs.ErrNotExist) {
+		return nil, overlayErr
+	}
+
+	if baseErr != nil && overlayErr != nil {
+		return nil, baseErr
+	}
+
+	entries := make(map[string]fs.DirEntry, len(baseEntries)+len(overlayEntries))
+	for _, e := range baseEntries {
+		entries[e.Name()] = e
+	}
+	for _, e := range overlayEntries {
+		entries[e.Name()] = e
+	}
+
+	return slices.SortedFunc(maps.Values(entries), func(a, b fs.DirEntry) int {
+		return cmp.Compare(a.Name(), b.Name())
+	}), nil
+}
@@ -0,0 +1,179 @@
+package gotemplate
+
+import (
+	"bytes"
+	"context"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// ValueLayer identifies the layer of merged values a value comes from.
+type ValueLayer string
+
+const (
+	// LayerDefaults is the renderer-wide default values (WithDefaultValues).
+	LayerDefaults ValueLayer = "defaults"
+
+	// LayerEnv is the allowlisted environment variables (WithEnvValues).
+	LayerEnv ValueLayer = "env"
+
+	// LayerValuesFunc is the values returned by the WithValuesFunc provider.
+	LayerValuesFunc ValueLayer = "valuesFunc"
+
+	// LayerValuesFile is a values file of the Source (Source.ValuesFiles).
+	LayerValuesFile ValueLayer = "valuesFile"
+
+	// LayerSource is the values returned by Source.Values.
+	LayerSource ValueLayer = "source"
+
+	// LayerRenderTime is the values passed to the rendering method.
+	LayerRenderTime ValueLayer = "renderTime"
+
+	// LayerSet is the values set by WithSetValues pairs.
+	LayerSet ValueLayer = "set"
+
+	// LayerRelease is the release metadata (WithReleaseInfo), under the Release key.
+	LayerRelease ValueLayer = "release"
+
+	// LayerCapabilities is the cluster capabilities (WithCapabilities), under the Capabilities key.
+	LayerCapabilities ValueLayer = "capabilities"
+)
+
+// valuesLayer is one of the value maps deep merged into the values of a Source.
+type valuesLayer struct {
+	layer  ValueLayer
+	file   string
+	values map[string]any
+
+	// WithSetValues pairs of the LayerSet layer, applied to the values merged so far
+	set []string
+}
+
+// ProvenanceReport attributes the merged values of every Source to the layer supplying them.
+type ProvenanceReport struct {
+	// Values lists the leaf values of every Source, in Source order and then key order.
+	Values []ValueProvenance
+}
+
+// ValueProvenance identifies the layer supplying a single merged value.
+type ValueProvenance struct {
+	// Path identifies the Source, as its patterns joined with ",".
+	Path string
+
+	// Key is the dotted path of the value, e.g. "image.tag". Maps are broken down into their
+	// entries, while slices and scalars are attributed as a whole, as they replace each other.
+	Key string
+
+	// Layer is the highest precedence layer setting the value.
+	Layer ValueLayer
+
+	// File is the values file setting the value, for LayerValuesFile.
+	File string
+}
+
+// Lookup returns the provenance of the value at key for the Source identified by path.
+func (r ProvenanceReport) Lookup(path string, key string) (ValueProvenance, bool) {
+	for _, v := range r.Values {
+		if v.Path == path && v.Key == key {
+			return v, true
+		}
+	}
+
+	return ValueProvenance{}, false
+}
+
+// RenderWithProvenance renders like RenderTo into a buffer and returns the output together with
+// a ProvenanceReport telling, for each Source, which layer (default values, a values file, the
+// Source values or the render-time values) supplied each merged value, which pinpoints where
+// an unexpectedly overridden value comes from. The value of templated values (WithValuesTemplating)
+// is attributed to the layer supplying the template. Requires WithValueProvenance, otherwise
+// ErrProvenanceDisabled is returned.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderWithProvenance(
+	ctx context.Context,
+	values map[string]any,
+) ([]byte, ProvenanceReport, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, ProvenanceReport{}, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	if !r.opts.ValueProvenance {
+		return nil, ProvenanceReport{}, ErrProvenanceDisabled
+	}
+
+	report := ProvenanceReport{
+		Values: make([]ValueProvenance, 0),
+	}
+
+	var buf bytes.Buffer
+	if err := r.renderTo(ctx, &buf, values, &report); err != nil {
+		return nil, ProvenanceReport{}, err
+	}
+
+	return buf.Bytes(), report, nil
+}
+
+// add appends the provenance of the values merged from layers for a Source.
+func (r *ProvenanceReport) add(holder *sourceHolder, layers []valuesLayer) {
+	origins := make(map[string]valuesLayer)
+
+	var merged map[string]any
+	for _, l := range layers {
+		recordOrigins(origins, merged, l.values, "", l)
+		merged = mergeLayer(merged, l)
+	}
+
+	for _, key := range slices.Sorted(maps.Keys(origins)) {
+		r.Values = append(r.Values, ValueProvenance{
+			Path:  holder.pathPattern(),
+			Key:   key,
+			Layer: origins[key].layer,
+			File:  origins[key].file,
+		})
+	}
+}
+
+// recordOrigins attributes the leaf values of overlay, merged over base, to layer, following
+// the deep merge semantics: maps merge recursively, anything else replaces the base value
+// and whatever was attributed below it.
+func recordOrigins(
+	origins map[string]valuesLayer,
+	base map[string]any,
+	overlay map[string]any,
+	prefix string,
+	layer valuesLayer,
+) {
+	for k, v := range overlay {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+
+		baseMap, baseIsMap := base[k].(map[string]any)
+		overlayMap, overlayIsMap := v.(map[string]any)
+
+		if baseIsMap && overlayIsMap {
+			recordOrigins(origins, baseMap, overlayMap, key, layer)
+
+			continue
+		}
+
+		for existing := range origins {
+			if existing == key || strings.HasPrefix(existing, key+".") {
+				delete(origins, existing)
+			}
+		}
+
+		switch {
+		case v == nil && layer.layer == LayerSet:
+			// Unset by a null set value
+		case overlayIsMap && len(overlayMap) > 0:
+			recordOrigins(origins, nil, overlayMap, key, layer)
+		default:
+			origins[key] = layer
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRenderWithProvenance(t *testing.T) {
+
+	valuesFS := fstest.MapFS{
+		"configmap.yaml": &fstest.MapFile{Data: []byte(defaultValuesTemplate)},
+		"values.yaml": &fstest.MapFile{Data: []byte(`image:
+  tag: "1.0"
+ports: [8080]
+logLevel: warn
+`)},
+		"values-prod.yaml": &fstest.MapFile{Data: []byte("logLevel: error\n")},
+	}
+
+	newRenderer := func(t *testing.T, mode gotemplate.ValuesMode, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:          valuesFS,
+					Path:        "configmap.yaml",
+					ValuesFiles: []string{"values.yaml", "values-prod.yaml"},
+					Values:      gotemplate.Values(map[string]any{"name": "from-source"}),
+					ValuesMode:  mode,
+				},
+			},
+			append([]gotemplate.RendererOption{
+				gotemplate.WithDefaultValues(map[string]any{
+					"name":     "default-name",
+					"image":    map[string]any{"repository": "nginx", "tag": "latest"},
+					"ports":    []any{80, 443},
+					"logLevel": "info",
+				}),
+			}, opts...)...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	origin := func(report gotemplate.ProvenanceReport, key string) gotemplate.ValueProvenance {
+		t.Helper()
+
+		v, ok := report.Lookup("configmap.yaml", key)
+		NewWithT(t).Expect(ok).To(BeTrue(), "no provenance for %s", key)
+
+		return v
+	}
+
+	t.Run("should attribute values overridden across layers", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.ValuesModeMerge, gotemplate.WithValueProvenance())
+
+		out, report, err := renderer.RenderWithProvenance(t.Context(), map[string]any{
+			"image": map[string]any{"tag": "2.0"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(ContainSubstring(`tag: "2.0"`))
+
+		g.Expect(origin(report, "name").Layer).To(Equal(gotemplate.LayerSource))
+		g.Expect(origin(report, "image.repository").Layer).To(Equal(gotemplate.LayerDefaults))
+		g.Expect(origin(report, "image.tag").Layer).To(Equal(gotemplate.LayerRenderTime))
+		g.Expect(origin(report, "ports")).To(Equal(gotemplate.ValueProvenance{
+			Path:  "configmap.yaml",
+			Key:   "ports",
+			Layer: gotemplate.LayerValuesFile,
+			File:  "values.yaml",
+		}))
+		g.Expect(origin(report, "logLevel").File).To(Equal("values-prod.yaml"))
+		g.Expect(report.Values).To(HaveLen(5))
+	})
+
+	t.Run("should follow the values mode", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.ValuesModeOverlay, gotemplate.WithValueProvenance())
+
+		_, report, err := renderer.RenderWithProvenance(t.Context(), map[string]any{
+			"name":  "from-render",
+			"image": map[string]any{"tag": "2.0"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(origin(report, "name").Layer).To(Equal(gotemplate.LayerSource))
+		g.Expect(origin(report, "image.tag").File).To(Equal("values.yaml"))
+	})
+
+	t.Run("should attribute replaced values to the replacing layer", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.ValuesModeMerge, gotemplate.WithValueProvenance())
+
+		_, report, err := renderer.RenderWithProvenance(t.Context(), map[string]any{
+			"image": map[string]any{"repository": map[string]any{"host": "quay.io"}},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(origin(report, "image.repository.host").Layer).To(Equal(gotemplate.LayerRenderTime))
+
+		_, found := report.Lookup("configmap.yaml", "image.repository")
+		g.Expect(found).To(BeFalse())
+	})
+
+	t.Run("should require WithValueProvenance", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.ValuesModeMerge)
+
+		_, _, err := renderer.RenderWithProvenance(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrProvenanceDisabled))
+	})
+}
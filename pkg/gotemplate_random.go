@@ -0,0 +1,115 @@
+package gotemplate
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"maps"
+	"strconv"
+	"text/template"
+)
+
+const (
+	alphaChars   = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+	numericChars = "0123456789"
+)
+
+// randomFuncMap returns the random string functions, drawing their bytes from src.
+func randomFuncMap(src io.Reader) template.FuncMap {
+	return template.FuncMap{
+		"randAlphaNum": func(n int) (string, error) { return randString(src, n, alphaChars+numericChars) },
+		"randAlpha":    func(n int) (string, error) { return randString(src, n, alphaChars) },
+		"randNumeric":  func(n int) (string, error) { return randString(src, n, numericChars) },
+	}
+}
+
+// randString returns n characters picked uniformly from chars using bytes read from src.
+func randString(src io.Reader, n int, chars string) (string, error) {
+	// Bytes beyond the largest multiple of len(chars) are dropped, avoiding modulo bias
+	limit := 256 - 256%len(chars)
+
+	result := make([]byte, 0, max(n, 0))
+	buf := make([]byte, max(n, 0))
+
+	for len(result) < n {
+		if _, err := io.ReadFull(src, buf); err != nil {
+			return "", fmt.Errorf("failed to read random bytes: %w", err)
+		}
+
+		for _, b := range buf {
+			if int(b) < limit && len(result) < n {
+				result = append(result, chars[int(b)%len(chars)])
+			}
+		}
+	}
+
+	return string(result), nil
+}
+
+// seededRandomFuncs returns the constructor of the WithRandomSeed random functions for a key,
+// or nil without a seed. User functions keep precedence, so names in opts.FuncMap are left out.
+func seededRandomFuncs(opts RendererOptions) func(key string) template.FuncMap {
+	if opts.RandomSeed == nil {
+		return nil
+	}
+
+	seed := *opts.RandomSeed
+	user := maps.Clone(opts.FuncMap)
+
+	return func(key string) template.FuncMap {
+		funcs := randomFuncMap(newSeededStream(seed, key))
+		for name := range user {
+			delete(funcs, name)
+		}
+
+		return funcs
+	}
+}
+
+// executionFuncs returns the functions for one execution of the template or value identified
+// by name: the Source functions, with the random ones seeded for it under WithRandomSeed.
+func (h *sourceHolder) executionFuncs(name string) template.FuncMap {
+	if h.randomFuncs == nil {
+		return h.funcs
+	}
+
+	funcs := maps.Clone(h.funcs)
+	maps.Copy(funcs, h.randomFuncs(h.Name+"\x00"+h.pathPattern()+"\x00"+name))
+
+	return funcs
+}
+
+// seededStream is a deterministic stream of bytes: SHA-256 in counter mode, keyed by the
+// seed and the name of the execution. Not safe for concurrent use, one serves one execution.
+type seededStream struct {
+	key     [sha256.Size]byte
+	counter uint64
+	buf     []byte
+}
+
+func newSeededStream(seed int64, name string) *seededStream {
+	return &seededStream{
+		key: sha256.Sum256([]byte(strconv.FormatInt(seed, 10) + "\x00" + name)),
+	}
+}
+
+func (s *seededStream) Read(p []byte) (int, error) {
+	for n := 0; n < len(p); {
+		if len(s.buf) == 0 {
+			var block [sha256.Size + 8]byte
+			copy(block[:], s.key[:])
+			binary.BigEndian.PutUint64(block[sha256.Size:], s.counter)
+			s.counter++
+
+			sum := sha256.Sum256(block[:])
+			s.buf = sum[:]
+		}
+
+		c := copy(p[n:], s.buf)
+		s.buf = s.buf[c:]
+		n += c
+	}
+
+	return len(p), nil
+}
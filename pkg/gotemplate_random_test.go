@@ -0,0 +1,117 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const secretTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: {{ .name }}
+stringData:
+  password: "{{ randAlphaNum 24 }}"
+  pin: "{{ randNumeric 6 }}"
+  token: "{{ tpl "{{ randAlpha 12 }}" . }}"
+`
+
+func TestRandomSeed(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"a.yaml": &fstest.MapFile{Data: []byte(secretTemplate)},
+						"b.yaml": &fstest.MapFile{Data: []byte(secretTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "db"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	render := func(t *testing.T, renderer *gotemplate.Renderer) []map[string]any {
+		t.Helper()
+
+		objects, err := renderer.Process(t.Context(), nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		result := make([]map[string]any, 0, len(objects))
+		for _, obj := range objects {
+			data, _, _ := unstructured.NestedMap(obj.Object, "stringData")
+			result = append(result, data)
+		}
+
+		return result
+	}
+
+	t.Run("should yield identical strings across seeded renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := render(t, newRenderer(t, gotemplate.WithRandomSeed(42)))
+		second := render(t, newRenderer(t, gotemplate.WithRandomSeed(42)))
+
+		g.Expect(first).To(HaveLen(2))
+		g.Expect(first[0]["password"]).To(MatchRegexp(`^[a-zA-Z0-9]{24}$`))
+		g.Expect(first[0]["pin"]).To(MatchRegexp(`^[0-9]{6}$`))
+		g.Expect(first[0]["token"]).To(MatchRegexp(`^[a-zA-Z]{12}$`))
+		g.Expect(second).To(Equal(first))
+	})
+
+	t.Run("should yield identical strings across renders of one renderer", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, gotemplate.WithRandomSeed(42))
+		g.Expect(render(t, renderer)).To(Equal(render(t, renderer)))
+	})
+
+	t.Run("should draw a distinct stream per template", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects := render(t, newRenderer(t, gotemplate.WithRandomSeed(42)))
+		g.Expect(objects[0]["password"]).ToNot(Equal(objects[1]["password"]))
+	})
+
+	t.Run("should yield different strings for different seeds", func(t *testing.T) {
+		g := NewWithT(t)
+
+		first := render(t, newRenderer(t, gotemplate.WithRandomSeed(1)))
+		second := render(t, newRenderer(t, gotemplate.WithRandomSeed(2)))
+		g.Expect(second[0]["password"]).ToNot(Equal(first[0]["password"]))
+	})
+
+	t.Run("should draw from crypto/rand without a seed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t)
+
+		first := render(t, renderer)
+		second := render(t, renderer)
+		g.Expect(first[0]["password"]).To(MatchRegexp(`^[a-zA-Z0-9]{24}$`))
+		g.Expect(second[0]["password"]).ToNot(Equal(first[0]["password"]))
+	})
+
+	t.Run("should keep user functions over seeded ones", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			gotemplate.WithRandomSeed(42),
+			gotemplate.WithFuncMap(map[string]any{"randAlphaNum": func(int) string { return "fixed" }}),
+		)
+		g.Expect(render(t, renderer)[0]["password"]).To(Equal("fixed"))
+	})
+}
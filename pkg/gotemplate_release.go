@@ -0,0 +1,56 @@
+package gotemplate
+
+import (
+	"cmp"
+)
+
+// ReleaseInfo is the release metadata exposed to templates as .Release (WithReleaseInfo),
+// mirroring the Helm release object.
+type ReleaseInfo struct {
+	// Name is the release name, as .Release.Name.
+	Name string
+
+	// Namespace is the release namespace, as .Release.Namespace. Empty = the WithNamespace
+	// namespace.
+	Namespace string
+
+	// Service is the service rendering the release, as .Release.Service, e.g. "Helm".
+	Service string
+
+	// Revision is the release revision, as .Release.Revision.
+	Revision int
+}
+
+// values returns the values exposing the release under the Release key, defaulting the
+// namespace to ns.
+func (ri ReleaseInfo) values(ns string) map[string]any {
+	return map[string]any{
+		"Release": map[string]any{
+			"Name":      ri.Name,
+			"Namespace": cmp.Or(ri.Namespace, ns),
+			"Service":   ri.Service,
+			"Revision":  ri.Revision,
+		},
+	}
+}
+
+// reservedLayers returns the value layers of the reserved top-level keys, Release and
+// Capabilities, merged over all other values in every ValuesMode.
+func reservedLayers(opts RendererOptions) ([]valuesLayer, error) {
+	layers := make([]valuesLayer, 0)
+
+	if opts.Release != nil {
+		layers = append(layers, valuesLayer{layer: LayerRelease, values: opts.Release.values(opts.Namespace)})
+	}
+
+	if opts.Capabilities != nil {
+		values, err := opts.Capabilities.values()
+		if err != nil {
+			return nil, err
+		}
+
+		layers = append(layers, valuesLayer{layer: LayerCapabilities, values: values})
+	}
+
+	return layers, nil
+}
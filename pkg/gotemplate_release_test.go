@@ -0,0 +1,121 @@
+package gotemplate_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const releaseTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Release.Name }}-config
+  labels:
+    app.kubernetes.io/managed-by: {{ .Release.Service }}
+  annotations:
+    revision: {{ .Release.Revision | quote }}
+data:
+  namespace: {{ .Release.Namespace | quote }}
+  replicas: {{ .replicas | quote }}
+`
+
+func TestReleaseInfo(t *testing.T) {
+
+	newRenderer := func(t *testing.T, values map[string]any, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(releaseTemplate)}},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(values),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	info := gotemplate.ReleaseInfo{Name: "web", Namespace: "apps", Service: "Helm", Revision: 3}
+
+	t.Run("should expose the release to templates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, map[string]any{"replicas": 2}, gotemplate.WithReleaseInfo(info))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "web-config"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.annotations.revision == "3"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.labels["app.kubernetes.io/managed-by"] == "Helm"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.namespace == "apps"`))
+	})
+
+	t.Run("should win over user values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			map[string]any{"replicas": 2, "Release": map[string]any{"Name": "user", "Revision": 9}},
+			gotemplate.WithReleaseInfo(info),
+		)
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"Release": map[string]any{"Name": "render"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "web-config"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.annotations.revision == "3"`))
+	})
+
+	t.Run("should default the namespace to WithNamespace", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, map[string]any{"replicas": 2},
+			gotemplate.WithNamespace("prod"),
+			gotemplate.WithReleaseInfo(gotemplate.ReleaseInfo{Name: "web", Revision: 1}),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.namespace == "prod"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.annotations.revision == "1"`))
+	})
+
+	t.Run("should fail on .Release without release info", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := newRenderer(t, map[string]any{"replicas": 2}).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "Release"`)))
+	})
+
+	t.Run("should keep the release out of schema validation", func(t *testing.T) {
+		g := NewWithT(t)
+
+		schema := []byte(`{
+			"type": "object",
+			"additionalProperties": false,
+			"properties": {"replicas": {"type": "integer"}}
+		}`)
+
+		renderer := newRenderer(t, map[string]any{"replicas": 2},
+			gotemplate.WithReleaseInfo(info),
+			gotemplate.WithValuesSchema(schema),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "web-config"`))
+
+		_, err = renderer.Process(t.Context(), map[string]any{"extra": true})
+		var validationErr *gotemplate.ValuesValidationError
+		g.Expect(errors.As(err, &validationErr)).To(BeTrue())
+	})
+}
@@ -0,0 +1,125 @@
+package gotemplate
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"sigs.k8s.io/yaml"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// RenderResult is the output of one Source rendered by RenderDetailed, with render metadata.
+type RenderResult struct {
+	// Name is the Source name, empty for unnamed Sources.
+	Name string
+
+	// Path is the Source patterns joined with ",".
+	Path string
+
+	// Output holds the rendered objects of the Source, one encoded document per object in the
+	// configured OutputFormat: YAML, or a JSON object followed by a newline with FormatJSON.
+	Output [][]byte
+
+	// Duration is how long rendering the Source took, including filters and transformers.
+	Duration time.Duration
+
+	// CacheHit tells whether the objects came from the render cache rather than executing the
+	// Source templates.
+	CacheHit bool
+}
+
+// RenderDetailed renders every Source like Process and returns one RenderResult per Source, in
+// Source order, carrying the encoded objects together with the time the Source took and whether
+// its objects came from the render cache, e.g. to feed dashboards. The objects are the same as
+// those of Process before the WithObjectsTransformer transformers and duplicate detection
+// (WithDetectDuplicates), which span Sources and do not apply. Sources render sequentially.
+// With WithContinueOnError, every Source is attempted and the results of the successful ones
+// are returned together with the joined errors of the failed ones.
+// This method is safe for concurrent use.
+func (r *Renderer) RenderDetailed(ctx context.Context, values map[string]any) ([]RenderResult, error) {
+	if err := r.checkOpen(); err != nil {
+		return nil, err
+	}
+
+	ctx = r.withOutputBudget(ctx)
+
+	results := make([]RenderResult, 0, len(r.inputs))
+	errs := make([]error, 0)
+
+	for _, holder := range r.inputs {
+		result, err := r.renderDetailed(ctx, holder, values)
+		if err != nil {
+			if !r.opts.ContinueOnError {
+				return nil, err
+			}
+
+			errs = append(errs, err)
+
+			continue
+		}
+
+		results = append(results, result)
+	}
+
+	if len(errs) > 0 {
+		return results, errors.Join(errs...)
+	}
+
+	return results, nil
+}
+
+// renderDetailed renders a single input into its RenderResult.
+func (r *Renderer) renderDetailed(
+	ctx context.Context,
+	holder *sourceHolder,
+	values map[string]any,
+) (RenderResult, error) {
+	start := r.opts.Clock()
+
+	objects, cached, err := r.processSource(ctx, holder, values)
+	if err != nil {
+		return RenderResult{}, err
+	}
+
+	output, err := r.encodeObjects(holder, objects)
+	if err != nil {
+		return RenderResult{}, err
+	}
+
+	return RenderResult{
+		Name:     holder.Name,
+		Path:     holder.pathPattern(),
+		Output:   output,
+		Duration: r.opts.Clock().Sub(start),
+		CacheHit: cached,
+	}, nil
+}
+
+// encodeObjects encodes every object as a document in the configured OutputFormat.
+func (r *Renderer) encodeObjects(holder *sourceHolder, objects []unstructured.Unstructured) ([][]byte, error) {
+	output := make([][]byte, 0, len(objects))
+
+	for i := range objects {
+		var data []byte
+		var err error
+
+		if r.opts.OutputFormat == FormatJSON {
+			data, err = json.Marshal(objects[i].Object)
+			data = append(data, '\n')
+		} else {
+			data, err = yaml.Marshal(objects[i].Object)
+		}
+
+		if err != nil {
+			return nil, fmt.Errorf("failed to encode object %d of gotemplate pattern %s: %w", i, holder.describe(), err)
+		}
+
+		output = append(output, data)
+	}
+
+	return output, nil
+}
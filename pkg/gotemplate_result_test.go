@@ -0,0 +1,101 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+	"time"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestRenderDetailed(t *testing.T) {
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .name }}\n"
+
+	sources := []gotemplate.Source{
+		{
+			Name:   "app",
+			FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+			Path:   "*.yaml",
+			Values: gotemplate.Values(map[string]any{"name": "app"}),
+		},
+		{
+			FS:     fstest.MapFS{"templates/configmap.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+			Path:   "templates/*.yaml",
+			Values: gotemplate.Values(map[string]any{"name": "worker"}),
+		},
+	}
+
+	t.Run("should report cache misses then hits per Source", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(sources, gotemplate.WithCache())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		first, err := renderer.RenderDetailed(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(first).To(HaveLen(2))
+		g.Expect(first[0].Name).To(Equal("app"))
+		g.Expect(first[0].Path).To(Equal("*.yaml"))
+		g.Expect(first[0].CacheHit).To(BeFalse())
+		g.Expect(first[1].Path).To(Equal("templates/*.yaml"))
+		g.Expect(first[1].CacheHit).To(BeFalse())
+
+		second, err := renderer.RenderDetailed(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(second).To(HaveLen(2))
+		g.Expect(second[0].CacheHit).To(BeTrue())
+		g.Expect(second[1].CacheHit).To(BeTrue())
+		g.Expect(second[0].Output).To(Equal(first[0].Output))
+	})
+
+	t.Run("should return the encoded objects", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(sources)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		results, err := renderer.RenderDetailed(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(results[0].Output).To(HaveExactElements(
+			MatchYAML("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\n"),
+		))
+		g.Expect(results[1].Output).To(HaveExactElements(
+			MatchYAML("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: worker\n"),
+		))
+		g.Expect(results[1].CacheHit).To(BeFalse())
+	})
+
+	t.Run("should encode JSON with FormatJSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(sources[:1], gotemplate.WithOutputFormat(gotemplate.FormatJSON))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		results, err := renderer.RenderDetailed(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(results[0].Output).To(HaveExactElements(
+			MatchJSON(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "app"}}`),
+		))
+	})
+
+	t.Run("should measure the render duration with the clock", func(t *testing.T) {
+		g := NewWithT(t)
+
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		clock := func() time.Time {
+			now = now.Add(time.Second)
+
+			return now
+		}
+
+		renderer, err := gotemplate.New(sources[:1], gotemplate.WithClock(clock))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		results, err := renderer.RenderDetailed(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(results[0].Duration).To(BeNumerically(">=", time.Second))
+	})
+}
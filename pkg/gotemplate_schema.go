@@ -0,0 +1,92 @@
+package gotemplate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// valuesSchemaURL is the synthetic location the values schema is registered under.
+const valuesSchemaURL = "values.schema.json"
+
+// compileValuesSchema compiles a JSON Schema document used to validate merged values.
+func compileValuesSchema(schema []byte) (*jsonschema.Schema, error) {
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(schema))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidValuesSchema, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(valuesSchemaURL, doc); err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidValuesSchema, err)
+	}
+
+	compiled, err := compiler.Compile(valuesSchemaURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidValuesSchema, err)
+	}
+
+	return compiled, nil
+}
+
+// validateValues validates values against schema, returning a *ValuesValidationError
+// that aggregates every violation when validation fails.
+func validateValues(schema *jsonschema.Schema, values map[string]any) error {
+	// Round-trip through JSON so Go types (ints, typed maps, structs) are
+	// presented to the validator as their JSON equivalents
+	data, err := json.Marshal(values)
+	if err != nil {
+		return fmt.Errorf("failed to encode values for schema validation: %w", err)
+	}
+
+	instance, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to decode values for schema validation: %w", err)
+	}
+
+	err = schema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	var verr *jsonschema.ValidationError
+	if !errors.As(err, &verr) {
+		return fmt.Errorf("failed to validate values: %w", err)
+	}
+
+	result := &ValuesValidationError{}
+	collectViolations(verr, message.NewPrinter(language.English), &result.Violations)
+
+	return result
+}
+
+// collectViolations appends the leaf causes of err, which carry the actual violations.
+func collectViolations(err *jsonschema.ValidationError, printer *message.Printer, out *[]ValuesViolation) {
+	if len(err.Causes) == 0 {
+		*out = append(*out, ValuesViolation{
+			Path:    fieldPath(err.InstanceLocation),
+			Message: err.ErrorKind.LocalizedString(printer),
+		})
+
+		return
+	}
+
+	for _, cause := range err.Causes {
+		collectViolations(cause, printer, out)
+	}
+}
+
+// fieldPath renders an instance location as a dotted field path, "(root)" for the values root.
+func fieldPath(location []string) string {
+	if len(location) == 0 {
+		return "(root)"
+	}
+
+	return strings.Join(location, ".")
+}
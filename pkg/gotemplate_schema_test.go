@@ -0,0 +1,109 @@
+package gotemplate_test
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const valuesSchema = `{
+  "$schema": "https://json-schema.org/draft/2020-12/schema",
+  "type": "object",
+  "required": ["name", "replicas"],
+  "properties": {
+    "name": {"type": "string"},
+    "replicas": {"type": "number", "minimum": 1}
+  }
+}`
+
+const schemaTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+spec:
+  replicas: {{ .replicas }}
+`
+
+func TestValuesSchema(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"deployment.yaml": &fstest.MapFile{Data: []byte(schemaTemplate)},
+					},
+					Path: "*.yaml",
+				},
+			},
+			append([]gotemplate.RendererOption{gotemplate.WithValuesSchema([]byte(valuesSchema))}, opts...)...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should render values matching the schema", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "app", "replicas": 3})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should report all violations together", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": 42, "replicas": "three"})
+		g.Expect(err).To(HaveOccurred())
+
+		var verr *gotemplate.ValuesValidationError
+		g.Expect(errors.As(err, &verr)).To(BeTrue())
+		g.Expect(verr.Violations).To(ConsistOf(
+			HaveField("Path", "name"),
+			HaveField("Path", "replicas"),
+		))
+		g.Expect(err).To(MatchError(ContainSubstring("name: got number, want string")))
+		g.Expect(err).To(MatchError(ContainSubstring("replicas: got string, want number")))
+	})
+
+	t.Run("should report missing required fields", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		_, err := renderer.Process(t.Context(), nil)
+
+		var verr *gotemplate.ValuesValidationError
+		g.Expect(errors.As(err, &verr)).To(BeTrue())
+		g.Expect(verr.Violations).To(HaveLen(1))
+		g.Expect(verr.Violations[0].Path).To(Equal("(root)"))
+		g.Expect(verr.Violations[0].Message).To(And(ContainSubstring("name"), ContainSubstring("replicas")))
+	})
+
+	t.Run("should validate after merging default values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithDefaultValues(map[string]any{"replicas": 1}))
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should reject an invalid schema in New", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New(
+			[]gotemplate.Source{{FS: fstest.MapFS{}, Path: "*.yaml"}},
+			gotemplate.WithValuesSchema([]byte(`{"type": 42}`)),
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidValuesSchema))
+	})
+}
@@ -0,0 +1,44 @@
+package gotemplate
+
+import (
+	"fmt"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// semverVersion parses a semantic version, e.g. "v1.29.3-gke.1200" or "1.29", for templates
+// inspecting its parts or comparing it: {{ (semver .Capabilities.KubeVersion.Version).Minor }}.
+// Missing components are 0 and a leading "v" is allowed, as in Sprig's semver.
+func semverVersion(s string) (*semver.Version, error) {
+	v, err := semver.NewVersion(s)
+	if err != nil {
+		return nil, fmt.Errorf("invalid semantic version %q: %w", s, err)
+	}
+
+	return v, nil
+}
+
+// semverCompare reports whether version satisfies constraint, following the Sprig function of
+// the same name for chart portability:
+//
+//	{{ if semverCompare ">=1.21-0" .Capabilities.KubeVersion.Version }}
+//
+// Constraints are comparisons (=, !=, >, <, >=, <=), tilde (~1.2: >=1.2.0 <1.3.0) and caret
+// (^1.2: >=1.2.0 <2.0.0) ranges, with partial versions and wildcards matching whole releases;
+// comparisons separated by "," or spaces must all hold, and "||" separates alternatives. As in
+// Sprig, versions with a pre-release, such as v1.29.3-gke.1, only satisfy comparisons against
+// versions with a pre-release, which is why charts commonly append "-0" to their constraints.
+// Malformed versions and constraints fail the render rather than reporting false.
+func semverCompare(constraint string, version string) (bool, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return false, fmt.Errorf("invalid constraint %q: %w", constraint, err)
+	}
+
+	v, err := semverVersion(version)
+	if err != nil {
+		return false, err
+	}
+
+	return c.Check(v), nil
+}
@@ -0,0 +1,282 @@
+package gotemplate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// maxSetIndex bounds the list indexes of WithSetValues, as Helm does, so a typo such as
+// a[10000000]=x cannot allocate a huge list.
+const maxSetIndex = 65536
+
+// parseSetValues parses the WithSetValues pairs into a values map, following the Helm --set
+// syntax: each pair holds comma separated key=value assignments, keys are dotted paths whose
+// segments may index lists (a.b[0].c=x), values in braces are lists (a={x,y}) and a backslash
+// escapes the next character. Values are coerced like Helm does: true and false become bools,
+// integers not starting with 0 (and 0 itself) become int64, null becomes nil and anything else
+// stays a string.
+func parseSetValues(pairs []string) (map[string]any, error) {
+	values := make(map[string]any)
+
+	for _, pair := range pairs {
+		if err := applySetPair(values, pair); err != nil {
+			return nil, err
+		}
+	}
+
+	return values, nil
+}
+
+// applySetPair parses the assignments of a WithSetValues pair into values, like Helm
+// strvals.ParseInto: maps and lists along the keys are updated in place, keeping their other
+// keys and elements.
+func applySetPair(values map[string]any, pair string) error {
+	p := &setParser{input: []rune(pair)}
+
+	for !p.done() {
+		if err := p.key(values); err != nil {
+			return fmt.Errorf("%w %q: %w", ErrInvalidSetValue, pair, err)
+		}
+	}
+
+	return nil
+}
+
+// copyValues returns a deep copy of the maps and lists of values, so applying set pairs
+// leaves the merged layers untouched.
+func copyValues(values map[string]any) map[string]any {
+	result := make(map[string]any, len(values))
+	for k, v := range values {
+		result[k] = copyValue(v)
+	}
+
+	return result
+}
+
+func copyValue(v any) any {
+	switch v := v.(type) {
+	case map[string]any:
+		return copyValues(v)
+	case []any:
+		list := make([]any, len(v))
+		for i, e := range v {
+			list[i] = copyValue(e)
+		}
+
+		return list
+	default:
+		return v
+	}
+}
+
+// unsetNullValues deletes from values the keys set to null by set, which deep merging left
+// as nil, so a.b=null unsets a.b like Helm does.
+func unsetNullValues(values map[string]any, set map[string]any) {
+	for k, v := range set {
+		if v == nil {
+			delete(values, k)
+
+			continue
+		}
+
+		setMap, setIsMap := v.(map[string]any)
+		valuesMap, valuesIsMap := values[k].(map[string]any)
+
+		if setIsMap && valuesIsMap {
+			unsetNullValues(valuesMap, setMap)
+		}
+	}
+}
+
+// setParser parses a single WithSetValues pair.
+type setParser struct {
+	input []rune
+	pos   int
+}
+
+func (p *setParser) done() bool {
+	return p.pos >= len(p.input)
+}
+
+// next returns the next unescaped rune, 0 at the end of the input.
+func (p *setParser) next() rune {
+	if p.done() {
+		return 0
+	}
+
+	r := p.input[p.pos]
+	p.pos++
+
+	return r
+}
+
+// readUntil reads up to the first unescaped rune in stops, which is consumed and returned,
+// 0 when the input ends first.
+func (p *setParser) readUntil(stops string) (string, rune) {
+	var sb strings.Builder
+
+	for !p.done() {
+		r := p.next()
+
+		switch {
+		case r == '\\' && !p.done():
+			sb.WriteRune(p.next())
+		case strings.ContainsRune(stops, r):
+			return sb.String(), r
+		default:
+			sb.WriteRune(r)
+		}
+	}
+
+	return sb.String(), 0
+}
+
+// key parses an assignment into data, starting at the first key segment.
+func (p *setParser) key(data map[string]any) error {
+	k, stop := p.readUntil(".=[,")
+	if k == "" {
+		return errors.New("key cannot be empty")
+	}
+
+	switch stop {
+	case '=':
+		v, err := p.value()
+		if err != nil {
+			return err
+		}
+
+		data[k] = v
+
+		return nil
+	case '.':
+		child, ok := data[k].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+		}
+
+		data[k] = child
+
+		return p.key(child)
+	case '[':
+		list, _ := data[k].([]any)
+
+		list, err := p.index(list)
+		if err != nil {
+			return fmt.Errorf("key %q: %w", k, err)
+		}
+
+		data[k] = list
+
+		return nil
+	default:
+		return fmt.Errorf("key %q has no value", k)
+	}
+}
+
+// index parses a list index, after its opening bracket, and what follows it into list.
+func (p *setParser) index(list []any) ([]any, error) {
+	s, stop := p.readUntil("]")
+	if stop != ']' {
+		return nil, errors.New("unterminated list index")
+	}
+
+	i, err := strconv.Atoi(s)
+	if err != nil || i < 0 || i > maxSetIndex {
+		return nil, fmt.Errorf("invalid list index %q (must be between 0 and %d)", s, maxSetIndex)
+	}
+
+	for len(list) <= i {
+		list = append(list, nil)
+	}
+
+	switch p.next() {
+	case '=':
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+
+		list[i] = v
+	case '.':
+		child, ok := list[i].(map[string]any)
+		if !ok {
+			child = make(map[string]any)
+		}
+
+		list[i] = child
+
+		if err := p.key(child); err != nil {
+			return nil, err
+		}
+	case '[':
+		nested, _ := list[i].([]any)
+
+		nested, err := p.index(nested)
+		if err != nil {
+			return nil, err
+		}
+
+		list[i] = nested
+	default:
+		return nil, fmt.Errorf("list index %d has no value", i)
+	}
+
+	return list, nil
+}
+
+// value parses the value of an assignment, a list in braces or a scalar, and the comma
+// ending it.
+func (p *setParser) value() (any, error) {
+	if p.done() || p.input[p.pos] != '{' {
+		v, _ := p.readUntil(",")
+
+		return typedSetValue(v), nil
+	}
+
+	p.pos++
+
+	list := make([]any, 0)
+
+	for {
+		v, stop := p.readUntil(",}")
+		if stop == 0 {
+			return nil, errors.New("unterminated list value")
+		}
+
+		if v != "" || stop == ',' || len(list) > 0 {
+			list = append(list, typedSetValue(v))
+		}
+
+		if stop == '}' {
+			break
+		}
+	}
+
+	if r := p.next(); r != ',' && r != 0 {
+		return nil, fmt.Errorf("unexpected %q after list value", r)
+	}
+
+	return list, nil
+}
+
+// typedSetValue coerces a value like Helm --set does.
+func typedSetValue(v string) any {
+	switch {
+	case strings.EqualFold(v, "true"):
+		return true
+	case strings.EqualFold(v, "false"):
+		return false
+	case strings.EqualFold(v, "null"):
+		return nil
+	case v == "0":
+		return int64(0)
+	case v != "" && v[0] != '0':
+		if i, err := strconv.ParseInt(v, 10, 64); err == nil {
+			return i
+		}
+	}
+
+	return v
+}
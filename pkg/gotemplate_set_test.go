@@ -0,0 +1,126 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+const setConfigMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app
+data:
+  values: {{ toJson . | quote }}
+`
+
+func TestSetValues(t *testing.T) {
+
+	newRenderer := func(t *testing.T, values map[string]any, pairs ...string) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(setConfigMapTemplate)}},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(values),
+				},
+			},
+			gotemplate.WithSetValues(pairs...),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should set nested values over all other values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			map[string]any{"image": map[string]any{"repository": "nginx", "tag": "v1"}},
+			"image.tag=v2,replicas=3", "debug=true", `image.pullPolicy=Always`, "zone=01",
+		)
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"replicas": 1})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.values | fromjson == %s`, `{
+			"image": {"repository": "nginx", "tag": "v2", "pullPolicy": "Always"},
+			"replicas": 3,
+			"debug": true,
+			"zone": "01"
+		}`))
+	})
+
+	t.Run("should set list elements by index", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, nil,
+			"ports[1].port=8080", "ports[1].name=http", "args={--verbose,--debug}", `path=a\,b`,
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.values | fromjson == %s`, `{
+			"ports": [null, {"port": 8080, "name": "http"}],
+			"args": ["--verbose", "--debug"],
+			"path": "a,b"
+		}`))
+	})
+
+	t.Run("should set list elements over existing lists", func(t *testing.T) {
+		g := NewWithT(t)
+
+		values := map[string]any{"a": []any{
+			map[string]any{"b": 1, "c": 2},
+			map[string]any{"b": 3},
+		}}
+		renderer := newRenderer(t, values, "a[0].b=x", "a[2]=z")
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.values | fromjson == %s`, `{
+			"a": [{"b": "x", "c": 2}, {"b": 3}, "z"]
+		}`))
+
+		// The Source values are left untouched
+		g.Expect(values["a"]).To(Equal([]any{
+			map[string]any{"b": 1, "c": 2},
+			map[string]any{"b": 3},
+		}))
+	})
+
+	t.Run("should unset null values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			map[string]any{"image": map[string]any{"repository": "nginx", "tag": "v1"}, "name": "app"},
+			"image.tag=null", "name=null", "missing.key=null",
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.values | fromjson == %s`, `{
+			"image": {"repository": "nginx"},
+			"missing": {}
+		}`))
+	})
+
+	t.Run("should reject malformed pairs", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, pair := range []string{"name", "=value", "ports[x]=1", "ports[0", "args={a,b"} {
+			_, err := gotemplate.New(
+				[]gotemplate.Source{{FS: fstest.MapFS{"a.yaml": &fstest.MapFile{}}, Path: "*.yaml"}},
+				gotemplate.WithSetValues(pair),
+			)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidSetValue), pair)
+		}
+	})
+}
@@ -2,12 +2,19 @@ package gotemplate
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io/fs"
+	"os"
+	"slices"
 	"strings"
 	"sync"
 	"text/template"
+	"time"
 
+	"github.com/go-logr/logr"
 	utilerrors "github.com/k8s-manifest-kit/pkg/util/errors"
+	"sigs.k8s.io/yaml"
 )
 
 // Values returns a Values function that always returns the provided static values.
@@ -18,6 +25,27 @@ func Values(values map[string]any) func(context.Context) (map[string]any, error)
 	}
 }
 
+// SubFS returns the subtree of fsys rooted at dir, so Source patterns can be written relative
+// to a subdirectory, e.g. of an embed.FS whose root also holds unrelated files. Unlike fs.Sub,
+// it fails if dir does not exist or is not a directory.
+func SubFS(fsys fs.FS, dir string) (fs.FS, error) {
+	info, err := fs.Stat(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scope FS to %s: %w", dir, err)
+	}
+
+	if !info.IsDir() {
+		return nil, fmt.Errorf("failed to scope FS to %s: %w", dir, ErrNotADirectory)
+	}
+
+	sub, err := fs.Sub(fsys, dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to scope FS to %s: %w", dir, err)
+	}
+
+	return sub, nil
+}
+
 // sourceHolder wraps a Source with internal state for lazy loading and thread-safety.
 type sourceHolder struct {
 	Source
@@ -25,8 +53,62 @@ type sourceHolder struct {
 	// Mutex protects concurrent access to templates field
 	mu *sync.RWMutex
 
+	// Functions attached to the template set before parsing
+	funcs template.FuncMap
+
+	// Action delimiters; empty values select the text/template defaults
+	leftDelim  string
+	rightDelim string
+
+	// Behavior for references to missing map keys
+	missingKey MissingKeyMode
+
+	// Base template executed instead of every template; empty = no layout
+	layout string
+
+	// How long parsed templates are reused (0 = forever) and the clock used to check it
+	ttl time.Duration
+	now func() time.Time
+
+	// Re-parse templates on every load instead of keeping them
+	noCache bool
+
+	// Strip byte order marks and convert CRLF line endings before parsing
+	normalize bool
+
+	// Patterns of the files emitted verbatim instead of being parsed (WithPassthrough)
+	passthrough []string
+
+	// How deeply include and tpl calls may nest (WithMaxIncludeDepth)
+	maxDepth int
+
+	// Debug logger carrying the Source name and path
+	log logr.Logger
+
+	// Identity of the Source FS in render cache keys
+	fsID string
+
+	// Source.Fallback chain, in order; nil = no fallbacks
+	fallbacks []*Source
+
+	// Random functions seeded for an execution (WithRandomSeed); nil = crypto/rand
+	randomFuncs func(key string) template.FuncMap
+
+	// Bind checksumOf on every execution (WithChecksums)
+	checksums bool
+
+	// Resolver imageDigest is bound to on every execution (WithImageResolver); nil = no rebinding
+	imageResolver ImageResolver
+
+	// Namespace the namespace function is bound to on every execution, for templates shared by
+	// Clone from a renderer with another WithNamespace; empty = no rebinding
+	namespace string
+
 	// Parsed templates (lazy-loaded on first Process call, protected by mu)
 	templates *template.Template
+
+	// When templates were parsed (protected by mu)
+	loadedAt time.Time
 }
 
 // Validate checks if the Source configuration is valid.
@@ -34,31 +116,237 @@ func (h *sourceHolder) Validate() error {
 	if h.FS == nil {
 		return utilerrors.ErrFsRequired
 	}
-	if strings.TrimSpace(h.Path) == "" {
+	if len(h.patterns()) == 0 {
 		return utilerrors.ErrPathEmpty
 	}
 
+	switch h.ValuesMode {
+	case ValuesModeMerge, ValuesModeOverlay, ValuesModeReplace:
+	default:
+		return fmt.Errorf(
+			"%w: %q (supported: %q, %q, %q)",
+			ErrInvalidValuesMode,
+			h.ValuesMode,
+			ValuesModeMerge,
+			ValuesModeOverlay,
+			ValuesModeReplace,
+		)
+	}
+
 	return nil
 }
 
+// checkPatterns checks that every pattern of the Source is well formed and matches at least
+// one file, failing with ErrNoMatchingTemplates otherwise.
+func (h *sourceHolder) checkPatterns() error {
+	for _, pattern := range h.patterns() {
+		files, err := globFiles(h.FS, pattern)
+		if err != nil {
+			return err
+		}
+		if len(files) == 0 {
+			return fmt.Errorf("%w: %s", ErrNoMatchingTemplates, pattern)
+		}
+	}
+
+	return nil
+}
+
+// rootNesting returns the nesting of the template sets executed by renders, before any include
+// or tpl call.
+func (h *sourceHolder) rootNesting() nesting {
+	return nesting{limit: h.maxDepth}
+}
+
+// patterns returns the non-empty glob patterns of the Source, Path first followed by Paths.
+func (h *sourceHolder) patterns() []string {
+	result := make([]string, 0, 1+len(h.Paths))
+	for _, p := range append([]string{h.Path}, h.Paths...) {
+		if strings.TrimSpace(p) != "" {
+			result = append(result, p)
+		}
+	}
+
+	return result
+}
+
+// pathPattern returns the Source patterns as a single string, used to identify
+// the Source in error messages, source annotations and cache keys.
+func (h *sourceHolder) pathPattern() string {
+	return strings.Join(h.patterns(), ",")
+}
+
+// logger returns log annotated with the Source path, and name if set.
+func (h *sourceHolder) logger(log logr.Logger) logr.Logger {
+	log = log.WithValues("path", h.pathPattern())
+	if h.Name != "" {
+		log = log.WithValues("source", h.Name)
+	}
+
+	return log
+}
+
+// describe returns the Source patterns followed by the Source name, if set, for error messages.
+func (h *sourceHolder) describe() string {
+	if h.Name == "" {
+		return h.pathPattern()
+	}
+
+	return h.pathPattern() + " (source " + h.Name + ")"
+}
+
+// sourceError returns err prefixed with action and the Source, e.g. "error rendering gotemplate
+// pattern *.yaml: ...", or err itself when it wraps a *RenderError, which names the Source already.
+func sourceError(action string, holder *sourceHolder, err error) error {
+	var renderErr *RenderError
+	if errors.As(err, &renderErr) {
+		return err
+	}
+
+	return fmt.Errorf("%s gotemplate pattern %s: %w", action, holder.describe(), err)
+}
+
+// LoadValuesFiles reads the Source ValuesFiles from its FS, returning one values layer per file
+// in order. Parse errors name the offending file and include the line reported by the YAML parser.
+func (h *sourceHolder) LoadValuesFiles() ([]valuesLayer, error) {
+	layers := make([]valuesLayer, 0, len(h.ValuesFiles))
+
+	for _, name := range h.ValuesFiles {
+		data, err := fs.ReadFile(h.FS, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read values file %s: %w", name, err)
+		}
+
+		values := map[string]any{}
+		if err := yaml.Unmarshal(data, &values); err != nil {
+			return nil, fmt.Errorf("failed to parse values file %s: %w", name, err)
+		}
+
+		layers = append(layers, valuesLayer{layer: LayerValuesFile, file: name, values: values})
+	}
+
+	return layers, nil
+}
+
 // LoadTemplates returns parsed templates, loading them lazily if needed.
 // Thread-safe for concurrent use.
 func (h *sourceHolder) LoadTemplates() (*template.Template, error) {
+	// Without caching every call gets its own template set, so no shared state is touched
+	if h.noCache {
+		return h.parseTemplates()
+	}
+
 	h.mu.Lock()
 	defer h.mu.Unlock()
 
-	if h.templates != nil {
+	if h.templates != nil && !h.expired() {
+		h.log.V(1).Info("reusing parsed templates")
+
 		return h.templates, nil
 	}
 
-	tmpl, err := template.ParseFS(h.FS, h.Path)
+	tmpl, err := h.parseTemplates()
 	if err != nil {
-		return nil, fmt.Errorf("failed to parse templates (path: %s): %w", h.Path, err)
+		return nil, err
 	}
 
-	// Set missingkey=error to fail fast when templates reference undefined values
-	// This catches template bugs early rather than silently rendering empty strings
-	h.templates = tmpl.Option("missingkey=error")
+	h.templates = tmpl
+	h.loadedAt = h.now()
 
 	return h.templates, nil
 }
+
+// parseTemplates parses the Source templates from its FS into a new template set.
+func (h *sourceHolder) parseTemplates() (*template.Template, error) {
+	// Funcs must be attached before parsing, otherwise templates referencing
+	// custom functions fail to parse with "function not defined".
+	// include and tpl close over the set being parsed and the file functions over the
+	// Source FS; h.funcs is applied last so WithFuncMap can still override them.
+	start := h.now()
+
+	tmpl := template.New("").Delims(h.leftDelim, h.rightDelim)
+	tmpl.Funcs(setFuncMap(tmpl, h.funcs, h.rootNesting())).Funcs(filesFuncMap(h.FS)).Funcs(h.funcs)
+
+	if err := parseFiles(tmpl, h.FS, h.patterns(), h.layout, h.normalize, h.passthrough); err != nil {
+		return nil, fmt.Errorf("failed to parse templates (path: %s): %w", h.pathPattern(), err)
+	}
+
+	h.log.V(1).Info("parsed templates", "templates", templateNames(tmpl), "duration", h.now().Sub(start))
+
+	// missingkey defaults to error to fail fast when templates reference undefined values,
+	// catching template bugs early rather than silently rendering empty strings
+	return tmpl.Option("missingkey=" + string(h.missingKey)), nil
+}
+
+// expired reports whether the parsed templates are older than the configured TTL.
+// Must be called with mu held.
+func (h *sourceHolder) expired() bool {
+	return h.ttl > 0 && h.now().Sub(h.loadedAt) >= h.ttl
+}
+
+// Reset discards parsed templates so the next LoadTemplates call re-parses them from the FS.
+// Thread-safe for concurrent use.
+func (h *sourceHolder) Reset() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.templates = nil
+}
+
+// executableTemplates returns the named templates of a parsed set sorted by name,
+// skipping the unnamed root so that rendering order is deterministic.
+func executableTemplates(templates *template.Template) []*template.Template {
+	result := make([]*template.Template, 0, len(templates.Templates()))
+	for _, t := range templates.Templates() {
+		if t.Name() != "" {
+			result = append(result, t)
+		}
+	}
+
+	slices.SortFunc(result, func(a *template.Template, b *template.Template) int {
+		return strings.Compare(a.Name(), b.Name())
+	})
+
+	return result
+}
+
+// entryTemplates returns the templates executed to render the Source: the layout template
+// if one is configured, otherwise every named template sorted by name.
+func (h *sourceHolder) entryTemplates(templates *template.Template) ([]*template.Template, error) {
+	if h.layout == "" {
+		return executableTemplates(templates), nil
+	}
+
+	t := templates.Lookup(h.layout)
+	if t == nil {
+		return nil, &TemplateNotFoundError{
+			Name:      h.layout,
+			Available: templateNames(templates),
+		}
+	}
+
+	return []*template.Template{t}, nil
+}
+
+// templateNames returns the sorted names of the named templates of a parsed set.
+func templateNames(templates *template.Template) []string {
+	names := make([]string, 0, len(templates.Templates()))
+	for _, t := range executableTemplates(templates) {
+		names = append(names, t.Name())
+	}
+
+	return names
+}
+
+// envValues returns the WithEnvValues environment variables that are set, under the Env key.
+func (r *Renderer) envValues() map[string]any {
+	env := make(map[string]any, len(r.opts.EnvAllow))
+
+	for _, name := range r.opts.EnvAllow {
+		if value, ok := os.LookupEnv(r.opts.EnvPrefix + name); ok {
+			env[name] = value
+		}
+	}
+
+	return map[string]any{"Env": env}
+}
@@ -0,0 +1,94 @@
+package gotemplate
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+
+	"github.com/k8s-manifest-kit/pkg/util"
+)
+
+// defaultTarGzMaxSize bounds the uncompressed size of a TarGzSource archive, 64 MiB.
+const defaultTarGzMaxSize = 64 << 20
+
+// TarGzOption is a generic option for TarGzOptions.
+type TarGzOption = util.Option[TarGzOptions]
+
+// TarGzOptions configures how TarGzSource reads an archive.
+type TarGzOptions struct {
+	// Path is the glob pattern matching templates within the archive. Empty = "**/*.yaml".
+	Path string
+
+	// MaxSize bounds the uncompressed size of the archive in bytes. Zero = 64 MiB.
+	MaxSize int64
+}
+
+// ApplyTo applies the tar.gz options to the target configuration.
+func (opts TarGzOptions) ApplyTo(target *TarGzOptions) {
+	if opts.Path != "" {
+		target.Path = opts.Path
+	}
+
+	if opts.MaxSize > 0 {
+		target.MaxSize = opts.MaxSize
+	}
+}
+
+// WithTarGzPath sets the glob pattern matching templates within the archive, e.g. "templates/*.yaml".
+// Default: "**/*.yaml", every YAML file named by its path within the archive.
+func WithTarGzPath(pattern string) TarGzOption {
+	return util.FunctionalOption[TarGzOptions](func(opts *TarGzOptions) {
+		opts.Path = pattern
+	})
+}
+
+// WithTarGzMaxSize bounds the uncompressed size of the archive, tar headers included, so a small
+// compressed archive cannot expand to exhaust memory. Default: 64 MiB.
+func WithTarGzMaxSize(n int64) TarGzOption {
+	return util.FunctionalOption[TarGzOptions](func(opts *TarGzOptions) {
+		opts.MaxSize = n
+	})
+}
+
+// TarGzSource reads a gzip-compressed tar archive (e.g. a CI artifact) from r and returns a
+// Source serving its regular files from memory, at their paths within the archive, so templates
+// matched by the default "**/*.yaml" pattern are named after them.
+//
+// Paths escaping the archive root fail with ErrInvalidArtifact, and archives expanding to more
+// than the maximum size (WithTarGzMaxSize) with ErrArchiveTooLarge. Each archive has its own
+// FS, which keeps render cache entries apart; the Source has no Name, so set one to tell
+// archives sharing a pattern apart in errors and logs.
+func TarGzSource(r io.Reader, opts ...TarGzOption) (Source, error) {
+	tarOpts := TarGzOptions{
+		Path:    "**/*.yaml",
+		MaxSize: defaultTarGzMaxSize,
+	}
+	for _, opt := range opts {
+		opt.ApplyTo(&tarOpts)
+	}
+
+	gz, err := gzip.NewReader(r)
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to open archive: %w", err)
+	}
+
+	defer func() { _ = gz.Close() }()
+
+	// Read one byte past the limit, so reaching it tells exceeding archives apart
+	limited := &io.LimitedReader{R: gz, N: tarOpts.MaxSize + 1}
+	files := memFS{}
+
+	err = extractTar(limited, "", files)
+	if limited.N <= 0 {
+		return Source{}, fmt.Errorf("%w: more than %d bytes uncompressed", ErrArchiveTooLarge, tarOpts.MaxSize)
+	}
+
+	if err != nil {
+		return Source{}, fmt.Errorf("failed to extract archive: %w", err)
+	}
+
+	return Source{
+		FS:   files,
+		Path: tarOpts.Path,
+	}, nil
+}
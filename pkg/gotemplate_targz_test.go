@@ -0,0 +1,121 @@
+package gotemplate_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"strings"
+	"testing"
+
+	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
+	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+// tarGz returns a gzip-compressed tar archive holding files, in the given order.
+func tarGz(t *testing.T, files ...[2]string) *bytes.Buffer {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	for _, file := range files {
+		hdr := &tar.Header{Name: file[0], Mode: 0o600, Size: int64(len(file[1])), Typeflag: tar.TypeReg}
+		NewWithT(t).Expect(tw.WriteHeader(hdr)).To(Succeed())
+
+		_, err := tw.Write([]byte(file[1]))
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+	}
+
+	NewWithT(t).Expect(tw.Close()).To(Succeed())
+
+	NewWithT(t).Expect(gz.Close()).To(Succeed())
+
+	return &buf
+}
+
+func TestTarGzSource(t *testing.T) {
+
+	const serviceTemplate = "apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .name }}\n"
+
+	archive := func(t *testing.T) *bytes.Buffer {
+		t.Helper()
+
+		return tarGz(t,
+			[2]string{"configmap.yaml", remoteTemplate},
+			[2]string{"templates/service.yaml", serviceTemplate},
+			[2]string{"README.md", "not a template"},
+		)
+	}
+
+	render := func(t *testing.T, source gotemplate.Source) map[string]string {
+		t.Helper()
+
+		source.Values = gotemplate.Values(map[string]any{"name": "bundle"})
+
+		renderer, err := gotemplate.New([]gotemplate.Source{source}, gotemplate.WithSourceAnnotations(true))
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		files := make(map[string]string, len(objects))
+		for _, obj := range objects {
+			NewWithT(t).Expect(obj.Object).To(jqmatcher.Match(`.metadata.name == "bundle"`))
+			files[obj.GetAnnotations()[pkgtypes.AnnotationSourceFile]] = obj.GetKind()
+		}
+
+		return files
+	}
+
+	t.Run("should render templates named by archive path", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := gotemplate.TarGzSource(archive(t))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal(map[string]string{
+			"configmap.yaml":         "ConfigMap",
+			"templates/service.yaml": "Service",
+		}))
+	})
+
+	t.Run("should select templates with a pattern", func(t *testing.T) {
+		g := NewWithT(t)
+
+		source, err := gotemplate.TarGzSource(archive(t), gotemplate.WithTarGzPath("templates/*.yaml"))
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(render(t, source)).To(Equal(map[string]string{"service.yaml": "Service"}))
+	})
+
+	t.Run("should reject path traversal", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, name := range []string{"../escape.yaml", "templates/../../escape.yaml"} {
+			_, err := gotemplate.TarGzSource(tarGz(t, [2]string{name, remoteTemplate}))
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidArtifact), name)
+		}
+	})
+
+	t.Run("should reject archives exceeding the maximum size", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Highly compressible content, a few KiB compressed
+		bomb := tarGz(t, [2]string{"bomb.yaml", strings.Repeat("a", 1<<20)})
+		g.Expect(bomb.Len()).To(BeNumerically("<", 1<<14))
+
+		_, err := gotemplate.TarGzSource(bomb, gotemplate.WithTarGzMaxSize(1<<16))
+		g.Expect(err).To(MatchError(gotemplate.ErrArchiveTooLarge))
+	})
+
+	t.Run("should reject data that is not gzip-compressed", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.TarGzSource(strings.NewReader("plain text"))
+		g.Expect(err).To(HaveOccurred())
+	})
+}
@@ -1,20 +1,38 @@
 package gotemplate_test
 
 import (
+	"bytes"
 	"context"
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"testing/fstest"
+	"text/template"
+	"time"
 
+	"github.com/go-logr/logr/funcr"
 	"github.com/k8s-manifest-kit/engine/pkg/filter/meta/gvk"
 	"github.com/k8s-manifest-kit/engine/pkg/transformer/meta/labels"
 	pkgtypes "github.com/k8s-manifest-kit/engine/pkg/types"
 	"github.com/k8s-manifest-kit/pkg/util/cache"
+	"github.com/k8s-manifest-kit/pkg/util/k8s"
 	jqmatcher "github.com/lburgazzoli/gomega-matchers/pkg/matchers/jq"
 	"github.com/onsi/gomega/types"
 	"github.com/rs/xid"
 
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8slabels "k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/yaml"
 
 	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
 
@@ -206,6 +224,7 @@ func TestRenderer(t *testing.T) {
 					"Repo": "test-app",
 				}),
 			},
+			opts:          []gotemplate.RendererOption{gotemplate.WithLazyValidation()},
 			expectedCount: 0,
 			validation:    nil,
 		},
@@ -276,7 +295,9 @@ func TestNew(t *testing.T) {
 		{
 			name: "should accept valid input",
 			inputs: []gotemplate.Source{{
-				FS:   fstest.MapFS{},
+				FS: fstest.MapFS{
+					"templates/pod.yaml": &fstest.MapFile{Data: []byte(podTemplate)},
+				},
 				Path: "templates/*.yaml",
 			}},
 			expectError: false,
@@ -820,3 +841,4200 @@ func TestSourceAnnotations(t *testing.T) {
 		}
 	})
 }
+
+const upperTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name | upper }}
+data:
+  greeting: "{{ greet .name }}"`
+
+func TestFuncMap(t *testing.T) {
+
+	t.Run("should render template using custom function", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.yaml": &fstest.MapFile{Data: []byte(upperTemplate)},
+					},
+					Path: "*.yaml",
+					Values: gotemplate.Values(map[string]any{
+						"name": "app",
+					}),
+				},
+			},
+			gotemplate.WithFuncMap(template.FuncMap{
+				"upper": strings.ToUpper,
+				"greet": func(s string) string { return "hello " + s },
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "APP"`),
+			jqmatcher.Match(`.data.greeting == "hello app"`),
+		))
+	})
+
+	t.Run("should let later function maps override earlier ones", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.yaml": &fstest.MapFile{Data: []byte(upperTemplate)},
+					},
+					Path: "*.yaml",
+					Values: gotemplate.Values(map[string]any{
+						"name": "app",
+					}),
+				},
+			},
+			gotemplate.WithFuncMap(template.FuncMap{
+				"upper": strings.ToUpper,
+				"greet": func(s string) string { return "hello " + s },
+			}),
+			gotemplate.WithFuncMap(template.FuncMap{
+				"greet": func(s string) string { return "bye " + s },
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "APP"`),
+			jqmatcher.Match(`.data.greeting == "bye app"`),
+		))
+	})
+
+	t.Run("should fail to parse template referencing unregistered function", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"template.yaml": &fstest.MapFile{Data: []byte(upperTemplate)},
+					},
+					Path: "*.yaml",
+					Values: gotemplate.Values(map[string]any{
+						"name": "app",
+					}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(`function "upper" not defined`)))
+	})
+}
+
+const customDelimsTemplate = `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: [[ .Name ]]
+spec:
+  groups:
+  - name: [[ .Name ]].rules
+    rules:
+    - alert: HighLatency
+      annotations:
+        summary: "Latency is {{ $value }}"`
+
+func TestDelimiters(t *testing.T) {
+
+	t.Run("should render template with custom delimiters", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"rule.yaml": &fstest.MapFile{Data: []byte(customDelimsTemplate)},
+					},
+					Path: "*.yaml",
+					Values: gotemplate.Values(map[string]any{
+						"Name": "api",
+					}),
+				},
+			},
+			gotemplate.WithDelimiters("[[", "]]"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "api"`),
+			jqmatcher.Match(`.spec.groups[0].name == "api.rules"`),
+			jqmatcher.Match(`.spec.groups[0].rules[0].annotations.summary == "Latency is {{ $value }}"`),
+		))
+	})
+
+	invalid := []struct {
+		name  string
+		left  string
+		right string
+	}{
+		{name: "empty left", left: "", right: "]]"},
+		{name: "empty right", left: "[[", right: ""},
+		{name: "identical", left: "%%", right: "%%"},
+	}
+
+	for _, tt := range invalid {
+		t.Run("should reject "+tt.name+" delimiters", func(t *testing.T) {
+			g := NewWithT(t)
+			renderer, err := gotemplate.New(
+				[]gotemplate.Source{{
+					FS:   fstest.MapFS{},
+					Path: "*.yaml",
+				}},
+				gotemplate.WithDelimiters(tt.left, tt.right),
+			)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidDelimiters))
+			g.Expect(renderer).To(BeNil())
+		})
+	}
+}
+
+const missingKeyTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+data:
+  top: "{{ .missing }}"
+  nested: "{{ .labels.missing }}"`
+
+func TestMissingKeyMode(t *testing.T) {
+
+	tests := []struct {
+		name        string
+		opts        []gotemplate.RendererOption
+		expectError bool
+		validation  types.GomegaMatcher
+	}{
+		{
+			name:        "should fail on missing key by default",
+			expectError: true,
+		},
+		{
+			name:        "should fail on missing key in error mode",
+			opts:        []gotemplate.RendererOption{gotemplate.WithMissingKeyMode(gotemplate.MissingKeyError)},
+			expectError: true,
+		},
+		{
+			name: "should render zero value in zero mode",
+			opts: []gotemplate.RendererOption{gotemplate.WithMissingKeyMode(gotemplate.MissingKeyZero)},
+			validation: And(
+				jqmatcher.Match(`.data.top == "<no value>"`),
+				jqmatcher.Match(`.data.nested == ""`),
+			),
+		},
+		{
+			name: "should render no value in invalid mode",
+			opts: []gotemplate.RendererOption{gotemplate.WithMissingKeyMode(gotemplate.MissingKeyInvalid)},
+			validation: And(
+				jqmatcher.Match(`.data.top == "<no value>"`),
+				jqmatcher.Match(`.data.nested == "<no value>"`),
+			),
+		},
+		{
+			name: "should render no value in default mode",
+			opts: []gotemplate.RendererOption{gotemplate.WithMissingKeyMode(gotemplate.MissingKeyDefault)},
+			validation: And(
+				jqmatcher.Match(`.data.top == "<no value>"`),
+				jqmatcher.Match(`.data.nested == "<no value>"`),
+			),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			g := NewWithT(t)
+			renderer, err := gotemplate.New(
+				[]gotemplate.Source{
+					{
+						FS: fstest.MapFS{
+							"template.yaml": &fstest.MapFile{Data: []byte(missingKeyTemplate)},
+						},
+						Path: "*.yaml",
+						Values: gotemplate.Values(map[string]any{
+							"labels": map[string]string{},
+						}),
+					},
+				},
+				tt.opts...,
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := renderer.Process(t.Context(), nil)
+			if tt.expectError {
+				g.Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "missing"`)))
+
+				return
+			}
+
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(HaveLen(1))
+			g.Expect(objects[0].Object).To(tt.validation)
+		})
+	}
+
+	t.Run("should reject unknown mode", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{
+				FS:   fstest.MapFS{},
+				Path: "*.yaml",
+			}},
+			gotemplate.WithMissingKeyMode("ignore"),
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidMissingKeyMode))
+		g.Expect(err).To(MatchError(ContainSubstring(`"ignore"`)))
+		g.Expect(renderer).To(BeNil())
+	})
+}
+
+const missingValuesTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+spec:
+  template:
+    spec:
+      containers:
+        - image: {{ .image.repository }}:{{ .image.tag }}
+{{- if .ingress.enabled }}
+---
+apiVersion: networking.k8s.io/v1
+kind: Ingress
+metadata:
+  name: {{ .name }}
+spec:
+  rules:
+    - host: {{ $.ingress.host }}
+{{- end }}
+`
+
+func TestMissingValues(t *testing.T) {
+
+	newRenderer := func(t *testing.T, content string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"app.yaml": &fstest.MapFile{Data: []byte(content)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should report all missing values of a template", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, missingValuesTemplate)
+
+		missing, err := renderer.MissingValues(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(Equal([]string{"image.repository", "image.tag", "ingress.enabled", "ingress.host"}))
+	})
+
+	t.Run("should only report values not given", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, missingValuesTemplate)
+
+		missing, err := renderer.MissingValues(t.Context(), map[string]any{
+			"image":   map[string]any{"repository": "nginx"},
+			"ingress": map[string]any{"enabled": false},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(Equal([]string{"image.tag"}))
+	})
+
+	t.Run("should report nothing for complete values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, missingValuesTemplate)
+
+		missing, err := renderer.MissingValues(t.Context(), map[string]any{
+			"image":   map[string]any{"repository": "nginx", "tag": "1.25"},
+			"ingress": map[string]any{"enabled": true, "host": "example.com"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(BeEmpty())
+	})
+
+	t.Run("should report missing values regardless of the missing key mode", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, missingValuesTemplate, gotemplate.WithMissingKeyMode(gotemplate.MissingKeyZero))
+
+		missing, err := renderer.MissingValues(t.Context(), map[string]any{"ingress": map[string]any{"enabled": false}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(Equal([]string{"image.repository", "image.tag"}))
+	})
+
+	t.Run("should locate keys repeating along the path", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "c: {{ .a.b.c }}\na: {{ .a.b.a }}\nname: {{ .user.user }}\n")
+
+		missing, err := renderer.MissingValues(t.Context(), map[string]any{
+			"a":    map[string]any{"b": map[string]any{"c": "x"}},
+			"user": map[string]any{"id": 1},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(Equal([]string{"a.b.a", "user.user"}))
+	})
+
+	t.Run("should report references relative to a block as is", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "{{ with .image }}tag: {{ .tag }}{{ end }}\n")
+
+		missing, err := renderer.MissingValues(t.Context(), map[string]any{"image": map[string]any{"repository": "nginx"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(missing).To(Equal([]string{"tag"}))
+	})
+
+	t.Run("should return other failures", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, `{{ index .name 5 }}`)
+
+		_, err := renderer.MissingValues(t.Context(), nil)
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring("out of range")))
+	})
+}
+
+func TestCacheTTL(t *testing.T) {
+
+	newFS := func(value string) fstest.MapFS {
+		return fstest.MapFS{
+			"template.yaml": &fstest.MapFile{Data: []byte(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: test-config
+data:
+  value: "` + value + `"`)},
+		}
+	}
+
+	t.Run("should re-parse templates once the TTL has elapsed", func(t *testing.T) {
+		g := NewWithT(t)
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		templateFS := newFS("v1")
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: templateFS, Path: "*.yaml"}},
+			gotemplate.WithCacheTTL(time.Minute),
+			gotemplate.WithClock(func() time.Time { return now }),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.value == "v1"`))
+
+		templateFS["template.yaml"] = newFS("v2")["template.yaml"]
+
+		// Still within the TTL - previously parsed templates are reused
+		now = now.Add(30 * time.Second)
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.value == "v1"`))
+
+		// TTL elapsed - templates are re-parsed from the FS
+		now = now.Add(30 * time.Second)
+		objects, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.value == "v2"`))
+	})
+
+	t.Run("should never expire parsed templates without a TTL", func(t *testing.T) {
+		g := NewWithT(t)
+		now := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+		templateFS := newFS("v1")
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: templateFS, Path: "*.yaml"}},
+			gotemplate.WithClock(func() time.Time { return now }),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		templateFS["template.yaml"] = newFS("v2")["template.yaml"]
+		now = now.Add(24 * time.Hour)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.value == "v1"`))
+	})
+}
+
+func TestRecursivePatterns(t *testing.T) {
+
+	nestedPodTemplate := strings.ReplaceAll(podTemplate, "-pod", "-nested-pod")
+	nestedFS := fstest.MapFS{
+		"templates/pod.yaml.tmpl":               &fstest.MapFile{Data: []byte(podTemplate)},
+		"templates/config/configmap.yaml.tmpl":  &fstest.MapFile{Data: []byte(configMapTemplate)},
+		"templates/config/nested/pod.yaml.tmpl": &fstest.MapFile{Data: []byte(nestedPodTemplate)},
+		"templates/config/nested/README.md":     &fstest.MapFile{Data: []byte("not a template")},
+		"other/ignored.yaml.tmpl":               &fstest.MapFile{Data: []byte(invalidTemplate)},
+	}
+
+	values := gotemplate.Values(map[string]any{
+		"Repo":      "test-app",
+		"Component": "frontend",
+		"Port":      8080,
+	})
+
+	t.Run("should load templates from nested directories", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: nestedFS, Path: "templates/**/*.tmpl", Values: values}},
+			gotemplate.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		files := make(map[string]string, len(objects))
+		for _, obj := range objects {
+			files[obj.GetAnnotations()[pkgtypes.AnnotationSourceFile]] = obj.GetName()
+		}
+
+		// Template names are relative paths, so same-named files in different directories don't collide
+		g.Expect(files).To(Equal(map[string]string{
+			"templates/pod.yaml.tmpl":               "test-app-pod",
+			"templates/config/configmap.yaml.tmpl":  "test-app-config",
+			"templates/config/nested/pod.yaml.tmpl": "test-app-nested-pod",
+		}))
+	})
+
+	t.Run("should match templates at any depth with leading doublestar", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{{FS: nestedFS, Path: "**/pod.yaml.tmpl", Values: values}})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should keep single-level globs unchanged", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: nestedFS, Path: "templates/*.tmpl", Values: values}},
+			gotemplate.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceFile, "pod.yaml.tmpl"))
+	})
+
+	t.Run("should fail when no files match", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := gotemplate.New([]gotemplate.Source{{FS: nestedFS, Path: "missing/**/*.tmpl", Values: values}})
+		g.Expect(err).To(MatchError(gotemplate.ErrNoMatchingTemplates))
+	})
+
+	t.Run("should fail on malformed pattern", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := gotemplate.New([]gotemplate.Source{{FS: nestedFS, Path: "templates/**/[.tmpl", Values: values}})
+		g.Expect(err).To(MatchError(path.ErrBadPattern))
+	})
+}
+
+func TestMultiplePaths(t *testing.T) {
+
+	bundleFS := fstest.MapFS{
+		"crds/pod.yaml":                 &fstest.MapFile{Data: []byte(podTemplate)},
+		"templates/configmap.yaml.tmpl": &fstest.MapFile{Data: []byte(configMapTemplate)},
+		"templates/pod.yaml":            &fstest.MapFile{Data: []byte(podTemplate)},
+	}
+
+	values := gotemplate.Values(map[string]any{
+		"Repo":      "test-app",
+		"Component": "frontend",
+		"Port":      8080,
+	})
+
+	t.Run("should parse files from all patterns into one set", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Paths: []string{"crds/*.yaml", "templates/*.tmpl"}, Values: values}},
+			gotemplate.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+
+		for _, obj := range objects {
+			g.Expect(obj.GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourcePath, "crds/*.yaml,templates/*.tmpl"))
+		}
+	})
+
+	t.Run("should combine Path and Paths", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Path: "crds/*.yaml", Paths: []string{"templates/*.tmpl"}, Values: values}},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should parse a file matched by several patterns once", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Paths: []string{"crds/*.yaml", "crds/pod.yaml"}, Values: values}},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should reject distinct files with the same template name", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Paths: []string{"crds/*.yaml", "templates/*.yaml"}, Values: values}},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrDuplicateTemplate))
+		g.Expect(err).To(MatchError(ContainSubstring("crds/pod.yaml")))
+		g.Expect(err).To(MatchError(ContainSubstring("templates/pod.yaml")))
+	})
+
+	t.Run("should fail when one of the patterns matches no files", func(t *testing.T) {
+		g := NewWithT(t)
+		_, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Paths: []string{"crds/*.yaml", "missing/*.yaml"}, Values: values}},
+		)
+		g.Expect(err).To(MatchError(gotemplate.ErrNoMatchingTemplates))
+		g.Expect(err).To(MatchError(ContainSubstring("missing/*.yaml")))
+	})
+
+	t.Run("should reject source without any non-empty pattern", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: bundleFS, Path: " ", Paths: []string{"", "  "}}},
+		)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(renderer).To(BeNil())
+	})
+}
+
+const namedTemplates = `{{ define "labels" -}}
+app: {{ .app }}
+tier: {{ .tier | default "backend" }}
+{{- end }}
+{{ define "selector" }}matchLabels: {{ .app }}{{ end }}
+`
+
+func TestLazyValidation(t *testing.T) {
+
+	values := gotemplate.Values(map[string]any{"Repo": "test-app", "Component": "web"})
+
+	t.Run("should fail in New when a pattern matches no files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New([]gotemplate.Source{{FS: fstest.MapFS{}, Path: "templates/*.yaml", Values: values}})
+		g.Expect(err).To(MatchError(gotemplate.ErrNoMatchingTemplates))
+		g.Expect(err).To(MatchError(ContainSubstring("templates/*.yaml")))
+	})
+
+	t.Run("should defer matching to the first render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		files := fstest.MapFS{}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: files, Path: "templates/*.yaml", Values: values}},
+			gotemplate.WithLazyValidation(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrNoMatchingTemplates))
+
+		// The files appear after the renderer is created
+		files["templates/pod.yaml"] = &fstest.MapFile{Data: []byte(podTemplate)}
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should still validate the Source configuration", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New(
+			[]gotemplate.Source{{FS: fstest.MapFS{}, Values: values}},
+			gotemplate.WithLazyValidation(),
+		)
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestNormalizeLineEndings(t *testing.T) {
+
+	crlfTemplate := "\uFEFFapiVersion: v1\r\nkind: ConfigMap\r\nmetadata:\r\n  name: {{ .name }}\r\n" +
+		"data:\r\n  script: |\r\n    echo {{ .name }}\r\n"
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{
+				FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(crlfTemplate)}},
+				Path:   "*.yaml",
+				Values: gotemplate.Values(map[string]any{"name": "web"}),
+			}},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should strip the BOM and convert CRLF line endings", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t)
+
+		out, err := renderer.RenderTemplate(t.Context(), "configmap.yaml", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: web\ndata:\n  script: |\n    echo web\n",
+		))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.script == "echo web\n"`))
+	})
+
+	t.Run("should keep the raw bytes when disabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, gotemplate.WithNormalizeLineEndings(false))
+
+		out, err := renderer.RenderTemplate(t.Context(), "configmap.yaml", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(HavePrefix("\uFEFFapiVersion: v1\r\n"))
+		g.Expect(string(out)).To(ContainSubstring("echo web\r\n"))
+	})
+}
+
+func TestOverlayFS(t *testing.T) {
+
+	configMap := func(name string, level string) []byte {
+		return []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\ndata:\n  level: " + level + "\n")
+	}
+
+	base := fstest.MapFS{
+		"templates/app.yaml":    &fstest.MapFile{Data: configMap("app", "info")},
+		"templates/worker.yaml": &fstest.MapFile{Data: configMap("worker", "info")},
+	}
+
+	newRenderer := func(t *testing.T, overlay fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: base, Path: "templates/*.yaml"}},
+			append(opts, gotemplate.WithOverlayFS(overlay))...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should replace one of two base templates", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, fstest.MapFS{
+			"templates/worker.yaml": &fstest.MapFile{Data: configMap("worker", "debug")},
+		})
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "app" and .data.level == "info"`))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.metadata.name == "worker" and .data.level == "debug"`))
+	})
+
+	t.Run("should add templates only present in the overlay", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, fstest.MapFS{
+			"templates/extra.yaml": &fstest.MapFile{Data: configMap("extra", "info")},
+		})
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].GetName()).To(Equal("app"))
+		g.Expect(objects[1].GetName()).To(Equal("extra"))
+		g.Expect(objects[2].GetName()).To(Equal("worker"))
+	})
+
+	t.Run("should log overrides", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		log := funcr.New(func(_ string, args string) {
+			lines = append(lines, args)
+		}, funcr.Options{Verbosity: 1})
+
+		renderer := newRenderer(t,
+			fstest.MapFS{"templates/worker.yaml": &fstest.MapFile{Data: configMap("worker", "debug")}},
+			gotemplate.WithLogger(log),
+		)
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(lines).To(ContainElement(And(
+			ContainSubstring(`"msg"="overlay overrides file"`),
+			ContainSubstring(`"file"="templates/worker.yaml"`),
+		)))
+		g.Expect(lines).ToNot(ContainElement(ContainSubstring(`"file"="templates/app.yaml"`)))
+	})
+}
+
+func TestPassthrough(t *testing.T) {
+
+	const rendered = "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .name }}\n"
+
+	const static = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: static
+data:
+  greeting: "Hello {{ .name }}"
+---
+apiVersion: v1
+kind: Secret
+metadata:
+  name: static
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{
+				FS: fstest.MapFS{
+					"manifests/app.yaml.tmpl": &fstest.MapFile{Data: []byte(rendered)},
+					"manifests/static.yaml":   &fstest.MapFile{Data: []byte(static)},
+				},
+				Path:   "manifests/*",
+				Values: gotemplate.Values(map[string]any{"name": "app"}),
+			}},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should mix static and rendered files", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			gotemplate.WithPassthrough("*.yaml"),
+			gotemplate.WithTransformer(labels.Set(map[string]string{"env": "test"})),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].GetName()).To(Equal("app"))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.data.greeting == "Hello {{ .name }}"`))
+		g.Expect(objects[2].GetKind()).To(Equal("Secret"))
+
+		for _, obj := range objects {
+			g.Expect(obj.GetLabels()).To(HaveKeyWithValue("env", "test"))
+		}
+	})
+
+	t.Run("should match file paths with patterns containing a slash", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, gotemplate.WithPassthrough("manifests/static.yaml"))
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(3))
+		g.Expect(string(documents[1])).To(ContainSubstring(`greeting: "Hello {{ .name }}"`))
+	})
+
+	t.Run("should parse files not matching a passthrough pattern", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, gotemplate.WithPassthrough("**/other/*.yaml"))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.data.greeting == "Hello app"`))
+	})
+
+	t.Run("should reject malformed patterns", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New(
+			[]gotemplate.Source{{FS: fstest.MapFS{}, Path: "*.yaml"}},
+			gotemplate.WithPassthrough("[*.yaml"),
+		)
+		g.Expect(err).To(MatchError(path.ErrBadPattern))
+	})
+}
+
+func TestRenderSelected(t *testing.T) {
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .name }}\n"
+
+	source := func(name string, sourceLabels map[string]string) gotemplate.Source {
+		return gotemplate.Source{
+			Name:   name,
+			FS:     fstest.MapFS{"configmap.yaml": &fstest.MapFile{Data: []byte(configMap)}},
+			Path:   "*.yaml",
+			Values: gotemplate.Values(map[string]any{"name": name}),
+			Labels: sourceLabels,
+		}
+	}
+
+	renderer, err := gotemplate.New([]gotemplate.Source{
+		source("crds", map[string]string{"tier": "crds", "team": "platform"}),
+		source("app", nil),
+		source("webhooks", map[string]string{"tier": "crds"}),
+	})
+	NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+	names := func(g Gomega, selector k8slabels.Selector) []string {
+		objects, err := renderer.RenderSelected(t.Context(), selector, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.GetName())
+		}
+
+		return result
+	}
+
+	t.Run("should render only the tagged Sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		selector := k8slabels.SelectorFromSet(k8slabels.Set{"tier": "crds"})
+		g.Expect(names(g, selector)).To(Equal([]string{"crds", "webhooks"}))
+	})
+
+	t.Run("should support set-based requirements", func(t *testing.T) {
+		g := NewWithT(t)
+
+		selector, err := k8slabels.Parse("tier in (crds),team notin (platform)")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(g, selector)).To(Equal([]string{"webhooks"}))
+
+		selector, err = k8slabels.Parse("!tier")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(g, selector)).To(Equal([]string{"app"}))
+	})
+
+	t.Run("should render every Source with an empty selector", func(t *testing.T) {
+		g := NewWithT(t)
+
+		g.Expect(names(g, nil)).To(Equal([]string{"crds", "app", "webhooks"}))
+		g.Expect(names(g, k8slabels.Everything())).To(Equal([]string{"crds", "app", "webhooks"}))
+	})
+
+	t.Run("should render nothing when no Source matches", func(t *testing.T) {
+		g := NewWithT(t)
+
+		selector := k8slabels.SelectorFromSet(k8slabels.Set{"tier": "missing"})
+		g.Expect(names(g, selector)).To(BeEmpty())
+	})
+}
+
+func TestRenderTemplate(t *testing.T) {
+
+	newRenderer := func(t *testing.T) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(configMapTemplate)},
+					},
+					Path: "*.yaml",
+				},
+				{
+					FS: fstest.MapFS{
+						"_helpers.tpl": &fstest.MapFile{Data: []byte(namedTemplates)},
+					},
+					Path:   "*.tpl",
+					Values: gotemplate.Values(map[string]any{"app": "web", "tier": "frontend"}),
+				},
+			},
+			gotemplate.WithSprigFunctions(),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should execute a named define block", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		out, err := renderer.RenderTemplate(t.Context(), "labels", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal("app: web\ntier: frontend"))
+	})
+
+	t.Run("should merge render-time values over source values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		out, err := renderer.RenderTemplate(t.Context(), "selector", map[string]any{"app": "api"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal("matchLabels: api"))
+	})
+
+	t.Run("should return typed error listing available templates", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		_, err := renderer.RenderTemplate(t.Context(), "missing", nil)
+		g.Expect(err).To(HaveOccurred())
+
+		var notFound *gotemplate.TemplateNotFoundError
+		g.Expect(errors.As(err, &notFound)).To(BeTrue())
+		g.Expect(notFound.Name).To(Equal("missing"))
+		g.Expect(notFound.Available).To(Equal([]string{"_helpers.tpl", "configmap.yaml", "labels", "selector"}))
+		g.Expect(err).To(MatchError(ContainSubstring("labels, selector")))
+	})
+}
+
+const multiDocumentTemplate = `---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}-script
+data:
+  script.sh: |
+    echo "start"
+    ---
+    echo "end"
+---
+# only a comment
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: {{ .name }}
+...
+--- # trailing separator
+`
+
+func TestRenderDocuments(t *testing.T) {
+
+	t.Run("should split multi-document templates", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"multi.yaml": &fstest.MapFile{Data: []byte(multiDocumentTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(2))
+		g.Expect(string(documents[0])).To(HavePrefix("apiVersion: v1\n"))
+		g.Expect(string(documents[0])).To(ContainSubstring("    ---\n    echo \"end\"\n"))
+		g.Expect(string(documents[1])).To(Equal("apiVersion: v1\nkind: Service\nmetadata:\n  name: app\n"))
+	})
+
+	t.Run("should keep block scalar content intact", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"multi.yaml": &fstest.MapFile{Data: []byte(multiDocumentTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).ToNot(BeEmpty())
+
+		objects, err := k8s.DecodeYAML(documents[0])
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(
+			jqmatcher.Match(`.data["script.sh"] == "echo \"start\"\n---\necho \"end\"\n"`),
+		)
+	})
+
+	t.Run("should return documents in source and template order", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"b.yaml": &fstest.MapFile{Data: []byte("kind: B\n---\nkind: C\n")},
+						"a.yaml": &fstest.MapFile{Data: []byte("kind: A\n")},
+					},
+					Path: "*.yaml",
+				},
+				{
+					FS: fstest.MapFS{
+						"d.yaml": &fstest.MapFile{Data: []byte("---\nkind: D\n")},
+					},
+					Path: "*.yaml",
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, 0, len(documents))
+		for _, doc := range documents {
+			result = append(result, string(doc))
+		}
+
+		g.Expect(result).To(Equal([]string{"kind: A\n", "kind: B\n", "kind: C\n", "kind: D\n"}))
+	})
+}
+
+func TestKeepEmptyDocuments(t *testing.T) {
+
+	const optionalTemplate = `{{- if .enabled }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: optional
+{{- end }}
+---
+# reviewed by the platform team
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: always
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"optional.yaml": &fstest.MapFile{Data: []byte(optionalTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"enabled": false}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should drop empty and comment-only documents by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(1))
+		g.Expect(string(documents[0])).To(ContainSubstring("name: always"))
+
+		objects, err := renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+
+	t.Run("should keep empty and comment-only documents", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithKeepEmptyDocuments())
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(3))
+		g.Expect(documents[0]).To(BeEmpty())
+		g.Expect(string(documents[1])).To(Equal("# reviewed by the platform team\n"))
+		g.Expect(string(documents[2])).To(ContainSubstring("name: always"))
+	})
+
+	t.Run("should decode kept documents to no objects", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithKeepEmptyDocuments(), gotemplate.WithRequireGVK())
+
+		objects, err := renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		processed, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(processed).To(HaveLen(1))
+	})
+}
+
+const defaultValuesTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+data:
+  repository: {{ .image.repository }}
+  tag: "{{ .image.tag }}"
+  ports: "{{ range $i, $p := .ports }}{{ if $i }},{{ end }}{{ $p }}{{ end }}"
+  logLevel: {{ .logLevel }}
+`
+
+func TestDefaultValues(t *testing.T) {
+
+	defaults := map[string]any{
+		"name": "default-name",
+		"image": map[string]any{
+			"repository": "nginx",
+			"tag":        "latest",
+		},
+		"ports":    []any{80, 443},
+		"logLevel": "info",
+	}
+
+	newRenderer := func(t *testing.T, sourceValues map[string]any) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(defaultValuesTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(sourceValues),
+				},
+			},
+			gotemplate.WithDefaultValues(defaults),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should override nested default values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, map[string]any{"name": "from-source"})
+
+		objects, err := renderer.Process(t.Context(), map[string]any{
+			"image": map[string]any{"tag": "1.25"},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "from-source"`),
+			jqmatcher.Match(`.data.repository == "nginx"`),
+			jqmatcher.Match(`.data.tag == "1.25"`),
+		))
+	})
+
+	t.Run("should replace slices instead of merging them", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, nil)
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"ports": []any{8080}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.ports == "8080"`))
+	})
+
+	t.Run("should use keys present only in defaults", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, map[string]any{"name": "from-source"})
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.data.logLevel == "info"`),
+			jqmatcher.Match(`.data.ports == "80,443"`),
+		))
+	})
+
+	t.Run("should not mutate the default values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, nil)
+
+		_, err := renderer.Process(t.Context(), map[string]any{"image": map[string]any{"tag": "1.25"}})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(defaults["image"]).To(HaveKeyWithValue("tag", "latest"))
+	})
+}
+
+type requestIDKey struct{}
+
+var errValuesUnavailable = errors.New("values unavailable")
+
+func TestValuesFunc(t *testing.T) {
+
+	requestID := func(ctx context.Context) (map[string]any, error) {
+		id, _ := ctx.Value(requestIDKey{}).(string)
+		if id == "" {
+			return nil, errValuesUnavailable
+		}
+
+		return map[string]any{"name": "request-" + id, "logLevel": "debug"}, nil
+	}
+
+	newRenderer := func(t *testing.T, values map[string]any, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(defaultValuesTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(values),
+				},
+			},
+			append([]gotemplate.RendererOption{gotemplate.WithValuesFunc(requestID)}, opts...)...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	base := map[string]any{
+		"image": map[string]any{"repository": "nginx", "tag": "1.25"},
+		"ports": []any{80},
+	}
+
+	t.Run("should inject values derived from the context", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, base, gotemplate.WithCache())
+
+		for _, id := range []string{"1", "2"} {
+			ctx := context.WithValue(t.Context(), requestIDKey{}, id)
+
+			objects, err := renderer.Process(ctx, nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(HaveLen(1))
+			g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.name == "request-%s"`, id))
+		}
+	})
+
+	t.Run("should merge over defaults and under Source values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			map[string]any{"name": "from-source", "image": base["image"]},
+			gotemplate.WithDefaultValues(map[string]any{"logLevel": "info", "ports": []any{443}}),
+		)
+
+		ctx := context.WithValue(t.Context(), requestIDKey{}, "1")
+
+		objects, err := renderer.Process(ctx, nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "from-source"`),
+			jqmatcher.Match(`.data.logLevel == "debug"`),
+			jqmatcher.Match(`.data.ports == "443"`),
+		))
+	})
+
+	t.Run("should abort the render on provider errors", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, base)
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errValuesUnavailable))
+		g.Expect(err).To(MatchError(ContainSubstring(`failed to get renderer values for template pattern "*.yaml"`)))
+	})
+}
+
+func TestEnvValues(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{
+							Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: app\ndata: {{ toJson .Env }}\n"),
+						},
+					},
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	data := func(g Gomega, renderer *gotemplate.Renderer) map[string]any {
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		result, _, _ := unstructured.NestedMap(objects[0].Object, "data")
+
+		return result
+	}
+
+	t.Run("should expose only allowlisted variables", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("CI_COMMIT_SHA", "abc123")
+		t.Setenv("CI_BRANCH", "main")
+		t.Setenv("CI_TOKEN", "secret")
+		t.Setenv("COMMIT_SHA", "unprefixed")
+
+		renderer := newRenderer(t, gotemplate.WithEnvValues("CI_", []string{"COMMIT_SHA", "BRANCH", "UNSET"}))
+		g.Expect(data(g, renderer)).To(Equal(map[string]any{"COMMIT_SHA": "abc123", "BRANCH": "main"}))
+	})
+
+	t.Run("should read variables without prefix", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("COMMIT_SHA", "abc123")
+		t.Setenv("HOME_DIR", "/root")
+
+		renderer := newRenderer(t, gotemplate.WithEnvValues("", []string{"COMMIT_SHA"}))
+		g.Expect(data(g, renderer)).To(Equal(map[string]any{"COMMIT_SHA": "abc123"}))
+	})
+
+	t.Run("should read variables on every render", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("CI_COMMIT_SHA", "first")
+
+		renderer := newRenderer(t, gotemplate.WithEnvValues("CI_", []string{"COMMIT_SHA"}))
+		g.Expect(data(g, renderer)).To(HaveKeyWithValue("COMMIT_SHA", "first"))
+
+		t.Setenv("CI_COMMIT_SHA", "second")
+		g.Expect(data(g, renderer)).To(HaveKeyWithValue("COMMIT_SHA", "second"))
+	})
+
+	t.Run("should let Source values override the environment", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("CI_COMMIT_SHA", "abc123")
+
+		renderer := newRenderer(t,
+			gotemplate.WithEnvValues("CI_", []string{"COMMIT_SHA"}),
+			gotemplate.WithValuesFunc(func(context.Context) (map[string]any, error) {
+				return map[string]any{"Env": map[string]any{"COMMIT_SHA": "pinned"}}, nil
+			}),
+		)
+		g.Expect(data(g, renderer)).To(HaveKeyWithValue("COMMIT_SHA", "pinned"))
+	})
+
+	t.Run("should not expose the environment by default", func(t *testing.T) {
+		g := NewWithT(t)
+		t.Setenv("CI_COMMIT_SHA", "abc123")
+
+		_, err := newRenderer(t).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "Env"`)))
+	})
+}
+
+func TestValuesFiles(t *testing.T) {
+
+	valuesFS := fstest.MapFS{
+		"configmap.yaml": &fstest.MapFile{Data: []byte(defaultValuesTemplate)},
+		"values.yaml": &fstest.MapFile{Data: []byte(`name: base
+image:
+  repository: nginx
+  tag: latest
+ports: [80, 443]
+logLevel: info
+`)},
+		"values-prod.json": &fstest.MapFile{Data: []byte(`{"image": {"tag": "1.25"}, "ports": [8443]}`)},
+		"broken.yaml":      &fstest.MapFile{Data: []byte("name: ok\nimage:\n  tag: [unclosed\n")},
+	}
+
+	t.Run("should merge values files in order before in-memory values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:          valuesFS,
+				Path:        "configmap.yaml",
+				ValuesFiles: []string{"values.yaml", "values-prod.json"},
+				Values:      gotemplate.Values(map[string]any{"name": "from-source"}),
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"logLevel": "debug"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "from-source"`),
+			jqmatcher.Match(`.data.repository == "nginx"`),
+			jqmatcher.Match(`.data.tag == "1.25"`),
+			jqmatcher.Match(`.data.ports == "8443"`),
+			jqmatcher.Match(`.data.logLevel == "debug"`),
+		))
+	})
+
+	t.Run("should report the offending file and line on parse errors", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:          valuesFS,
+				Path:        "configmap.yaml",
+				ValuesFiles: []string{"values.yaml", "broken.yaml"},
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("broken.yaml")))
+		g.Expect(err).To(MatchError(ContainSubstring("line 3")))
+	})
+
+	t.Run("should fail when a values file does not exist", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:          valuesFS,
+				Path:        "configmap.yaml",
+				ValuesFiles: []string{"missing.yaml"},
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(fs.ErrNotExist))
+		g.Expect(err).To(MatchError(ContainSubstring("missing.yaml")))
+	})
+}
+
+// blockingWriter blocks every Write until release is closed, signalling started on the first one.
+type blockingWriter struct {
+	started chan struct{}
+	release chan struct{}
+	once    sync.Once
+}
+
+func (w *blockingWriter) Write(p []byte) (int, error) {
+	w.once.Do(func() { close(w.started) })
+	<-w.release
+
+	return len(p), nil
+}
+
+// failingWriter accepts limit bytes and then fails every Write.
+type failingWriter struct {
+	limit int
+	buf   bytes.Buffer
+}
+
+var errWriteFailed = errors.New("write failed")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if w.buf.Len()+len(p) > w.limit {
+		return 0, errWriteFailed
+	}
+
+	return w.buf.Write(p)
+}
+
+func TestRenderTo(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"a.yaml": &fstest.MapFile{Data: []byte("kind: A\nname: {{ .name }}")},
+						"b.yaml": &fstest.MapFile{Data: []byte("kind: B\n")},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "source"}),
+				},
+				{
+					FS: fstest.MapFS{
+						"c.yaml": &fstest.MapFile{Data: []byte("kind: C\nname: {{ .name }}\n")},
+					},
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should stream all documents with separators", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		var buf bytes.Buffer
+		err := renderer.RenderTo(t.Context(), &buf, map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(buf.String()).To(Equal("kind: A\nname: app\n---\nkind: B\n---\nkind: C\nname: app\n"))
+	})
+
+	t.Run("should write a custom separator between documents", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithDocumentSeparator("\n...\n"))
+
+		var buf bytes.Buffer
+		err := renderer.RenderTo(t.Context(), &buf, map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(buf.String()).To(Equal("kind: A\nname: app\n...\nkind: B\n\n...\nkind: C\nname: app\n"))
+	})
+
+	t.Run("should concatenate documents with an empty separator", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithDocumentSeparator(""))
+
+		var buf bytes.Buffer
+		err := renderer.RenderTo(t.Context(), &buf, map[string]any{"name": "app"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(buf.String()).To(Equal("kind: A\nname: appkind: B\nkind: C\nname: app\n"))
+	})
+
+	t.Run("should return write errors after partial output", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		w := &failingWriter{limit: 20}
+		err := renderer.RenderTo(t.Context(), w, map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(errWriteFailed))
+		g.Expect(w.buf.String()).To(HavePrefix("kind: A"))
+	})
+
+	t.Run("should stop promptly when the context is cancelled", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		w := &blockingWriter{started: make(chan struct{}), release: make(chan struct{})}
+		done := make(chan error, 1)
+
+		go func() {
+			done <- renderer.RenderTo(ctx, w, map[string]any{"name": "app"})
+		}()
+
+		<-w.started
+		cancel()
+		close(w.release)
+
+		g.Eventually(done).Should(Receive(MatchError(context.Canceled)))
+	})
+}
+
+func TestOutputFormat(t *testing.T) {
+
+	newRenderer := func(t *testing.T, content string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"manifests.yaml": &fstest.MapFile{Data: []byte(content)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should encode the same documents as JSON", func(t *testing.T) {
+		g := NewWithT(t)
+
+		yamlDocs, err := newRenderer(t, multiDocumentTemplate).RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		jsonDocs, err := newRenderer(t, multiDocumentTemplate, gotemplate.WithOutputFormat(gotemplate.FormatJSON)).
+			RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(jsonDocs).To(HaveLen(len(yamlDocs)))
+
+		for i := range yamlDocs {
+			var fromYAML, fromJSON map[string]any
+			g.Expect(yaml.Unmarshal(yamlDocs[i], &fromYAML)).To(Succeed())
+			g.Expect(json.Unmarshal(jsonDocs[i], &fromJSON)).To(Succeed())
+			g.Expect(fromJSON).To(Equal(fromYAML))
+		}
+
+		g.Expect(jsonDocs[0]).To(jqmatcher.Match(`.data."script.sh" | contains("---")`))
+	})
+
+	t.Run("should sort keys deterministically", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			"kind: ConfigMap\napiVersion: v1\nmetadata:\n  name: {{ .name }}\n  labels: {b: '2', a: '1'}\n",
+			gotemplate.WithOutputFormat(gotemplate.FormatJSON),
+		)
+
+		for range 3 {
+			docs, err := renderer.RenderDocuments(t.Context())
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(docs).To(HaveLen(1))
+			g.Expect(string(docs[0])).To(Equal(
+				`{"apiVersion":"v1","kind":"ConfigMap","metadata":{"labels":{"a":"1","b":"2"},"name":"app"}}` + "\n",
+			))
+		}
+	})
+
+	t.Run("should stream one JSON object per line", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "kind: A\nname: {{ .name }}\n---\nkind: B\n",
+			gotemplate.WithOutputFormat(gotemplate.FormatJSON),
+		)
+
+		var buf bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(`{"kind":"A","name":"app"}` + "\n" + `{"kind":"B"}` + "\n"))
+	})
+
+	t.Run("should write a custom separator between JSON objects", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "kind: A\n---\nkind: B\n",
+			gotemplate.WithOutputFormat(gotemplate.FormatJSON),
+			gotemplate.WithDocumentSeparator("---\n"),
+		)
+
+		var buf bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(`{"kind":"A"}` + "\n---\n" + `{"kind":"B"}` + "\n"))
+	})
+
+	t.Run("should fail for documents that are not YAML mappings", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "hello {{ .name }}\n", gotemplate.WithOutputFormat(gotemplate.FormatJSON))
+
+		_, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+		g.Expect(err).To(MatchError(ContainSubstring(`starting with "hello app"`)))
+
+		err = renderer.RenderTo(t.Context(), &bytes.Buffer{}, nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+	})
+
+	t.Run("should fail for unparsable documents", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "kind: [A\n", gotemplate.WithOutputFormat(gotemplate.FormatJSON))
+
+		_, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+		g.Expect(err).To(MatchError(ContainSubstring("cannot convert to JSON")))
+	})
+
+	t.Run("should drop empty documents", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, "# comment\n---\nkind: A\n",
+			gotemplate.WithOutputFormat(gotemplate.FormatJSON),
+			gotemplate.WithKeepEmptyDocuments(),
+		)
+
+		docs, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(docs).To(Equal([][]byte{[]byte(`{"kind":"A"}` + "\n")}))
+	})
+
+	t.Run("should reject unknown formats", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New(nil, gotemplate.WithOutputFormat("toml"))
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidOutputFormat))
+	})
+}
+
+func TestContextCancellation(t *testing.T) {
+
+	t.Run("should not render when the context is already cancelled", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, executions := newCountingRenderer(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		_, err := renderer.Process(ctx, map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(context.Canceled))
+		g.Expect(err).To(MatchError(ContainSubstring("*.yaml")))
+		g.Expect(executions.Load()).To(BeZero())
+	})
+
+	t.Run("should stop before the next source once cancelled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		defer cancel()
+
+		executed := make([]string, 0)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:   fstest.MapFS{"first.yaml": &fstest.MapFile{Data: []byte(`kind: {{ cancel "first" }}`)}},
+					Path: "first.yaml",
+				},
+				{
+					FS:   fstest.MapFS{"second.yaml": &fstest.MapFile{Data: []byte(`kind: {{ cancel "second" }}`)}},
+					Path: "second.yaml",
+				},
+			},
+			gotemplate.WithFuncMap(template.FuncMap{
+				"cancel": func(name string) string {
+					executed = append(executed, name)
+					cancel()
+
+					return name
+				},
+			}),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		done := make(chan error, 1)
+		go func() {
+			_, err := renderer.Process(ctx, nil)
+			done <- err
+		}()
+
+		var renderErr error
+		g.Eventually(done, time.Second).Should(Receive(&renderErr))
+		g.Expect(renderErr).To(MatchError(context.Canceled))
+		g.Expect(renderErr).To(MatchError(ContainSubstring("second.yaml")))
+		g.Expect(executed).To(Equal([]string{"first"}))
+	})
+}
+
+func TestParallelism(t *testing.T) {
+
+	newSources := func(count int, failing ...int) []gotemplate.Source {
+		sources := make([]gotemplate.Source, count)
+		for i := range sources {
+			tmpl := fmt.Sprintf("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: cm-%02d-{{ track }}\n", i)
+			if slices.Contains(failing, i) {
+				tmpl = fmt.Sprintf("kind: {{ .missing%02d }}", i)
+			}
+
+			sources[i] = gotemplate.Source{
+				FS:   fstest.MapFS{fmt.Sprintf("cm-%02d.yaml", i): &fstest.MapFile{Data: []byte(tmpl)}},
+				Path: "*.yaml",
+			}
+		}
+
+		return sources
+	}
+
+	// track records the peak number of concurrently executing templates
+	newTracker := func() (template.FuncMap, *atomic.Int64) {
+		active := &atomic.Int64{}
+		peak := &atomic.Int64{}
+
+		return template.FuncMap{
+			"track": func() string {
+				current := active.Add(1)
+				defer active.Add(-1)
+
+				for {
+					p := peak.Load()
+					if current <= p || peak.CompareAndSwap(p, current) {
+						break
+					}
+				}
+
+				time.Sleep(5 * time.Millisecond)
+
+				return "x"
+			},
+		}, peak
+	}
+
+	t.Run("should preserve source order", func(t *testing.T) {
+		g := NewWithT(t)
+		funcs, _ := newTracker()
+
+		sequential, err := gotemplate.New(newSources(20), gotemplate.WithFuncMap(funcs))
+		g.Expect(err).ToNot(HaveOccurred())
+		parallel, err := gotemplate.New(newSources(20), gotemplate.WithFuncMap(funcs), gotemplate.WithParallelism(4))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		expected, err := sequential.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(expected).To(HaveLen(20))
+
+		for range 5 {
+			objects, err := parallel.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(Equal(expected))
+		}
+	})
+
+	t.Run("should bound concurrency", func(t *testing.T) {
+		g := NewWithT(t)
+		funcs, peak := newTracker()
+
+		renderer, err := gotemplate.New(newSources(12), gotemplate.WithFuncMap(funcs), gotemplate.WithParallelism(3))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(peak.Load()).To(BeNumerically("<=", 3))
+		g.Expect(peak.Load()).To(BeNumerically(">", 1))
+	})
+
+	t.Run("should surface an error from one source", func(t *testing.T) {
+		g := NewWithT(t)
+		funcs, _ := newTracker()
+
+		renderer, err := gotemplate.New(newSources(8, 5), gotemplate.WithFuncMap(funcs), gotemplate.WithParallelism(4))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("missing05")))
+		g.Expect(err).ToNot(MatchError(context.Canceled))
+		g.Expect(objects).To(BeNil())
+	})
+
+	t.Run("should aggregate errors from concurrently failing sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		// Both sources fail only once both are executing, so neither is skipped by cancellation
+		var started sync.WaitGroup
+		started.Add(2)
+
+		failing := func(name string) gotemplate.Source {
+			return gotemplate.Source{
+				FS:   fstest.MapFS{name: &fstest.MapFile{Data: []byte(`kind: {{ fail "` + name + `" }}`)}},
+				Path: name,
+			}
+		}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{failing("a.yaml"), newSources(1)[0], failing("b.yaml")},
+			gotemplate.WithFuncMap(template.FuncMap{
+				"track": func() string { return "x" },
+				"fail": func(name string) (string, error) {
+					started.Done()
+					started.Wait()
+
+					return "", fmt.Errorf("%s: intentional failure", name)
+				},
+			}),
+			gotemplate.WithParallelism(3),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("a.yaml: intentional failure")))
+		g.Expect(err).To(MatchError(ContainSubstring("b.yaml: intentional failure")))
+	})
+}
+
+func TestRenderError(t *testing.T) {
+
+	const failingTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+data:
+  value: {{ .missing }}
+`
+
+	t.Run("should include source path, template name and line", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS: fstest.MapFS{
+					"templates/configmap.yaml": &fstest.MapFile{Data: []byte(failingTemplate)},
+				},
+				Path: "templates/*.yaml",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(ContainSubstring("configmap.yaml")))
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(renderErr.Path).To(Equal("templates/*.yaml"))
+		g.Expect(renderErr.TemplateName).To(Equal("configmap.yaml"))
+		g.Expect(renderErr.Line).To(Equal(6))
+
+		var execErr template.ExecError
+		g.Expect(errors.As(err, &execErr)).To(BeTrue())
+	})
+
+	t.Run("should be returned by RenderTemplate", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS: fstest.MapFS{
+					"helpers.tpl": &fstest.MapFile{Data: []byte(`{{ define "name" }}{{ .missing }}{{ end }}`)},
+				},
+				Path: "*.tpl",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.RenderTemplate(t.Context(), "name", nil)
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(renderErr.TemplateName).To(Equal("name"))
+		g.Expect(renderErr.Line).To(Equal(1))
+	})
+
+	t.Run("should name the source and location in the message", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS: fstest.MapFS{
+					"templates/configmap.yaml": &fstest.MapFile{Data: []byte(failingTemplate)},
+				},
+				Path: "templates/*.yaml",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		const message = `gotemplate pattern templates/*.yaml: template: configmap.yaml:6:12: ` +
+			`executing "configmap.yaml" at <.missing>: map has no entry for key "missing"`
+
+		_, err = renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+
+		var buf bytes.Buffer
+		err = renderer.RenderTo(t.Context(), &buf, map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+
+		_, err = renderer.RenderTemplate(t.Context(), "configmap.yaml", map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+
+		g.Expect(renderer.Validate(t.Context(), map[string]any{"name": "app"})).To(MatchError(message))
+	})
+
+	t.Run("should name the entry template of failing includes", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS: fstest.MapFS{
+					"helpers.tpl": &fstest.MapFile{Data: []byte(`{{ define "value" }}{{ .missing }}{{ end }}`)},
+					"cm.yaml":     &fstest.MapFile{Data: []byte("value: {{ template \"value\" . }}\n")},
+				},
+				Path:  "*.yaml",
+				Paths: []string{"*.tpl"},
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(`gotemplate pattern *.yaml,*.tpl: template cm.yaml: template: helpers.tpl:1:23: ` +
+			`executing "value" at <.missing>: map has no entry for key "missing"`))
+	})
+
+	t.Run("should include the source name", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				Name: "app-chart",
+				FS: fstest.MapFS{
+					"templates/configmap.yaml": &fstest.MapFile{Data: []byte(failingTemplate)},
+				},
+				Path: "templates/*.yaml",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		const message = `gotemplate pattern templates/*.yaml (source app-chart): template: configmap.yaml:6:12: ` +
+			`executing "configmap.yaml" at <.missing>: map has no entry for key "missing"`
+
+		_, err = renderer.Process(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(renderErr.Name).To(Equal("app-chart"))
+		g.Expect(renderErr.Path).To(Equal("templates/*.yaml"))
+
+		var buf bytes.Buffer
+		err = renderer.RenderTo(t.Context(), &buf, map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+
+		_, err = renderer.RenderTemplate(t.Context(), "configmap.yaml", map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(message))
+	})
+}
+
+func TestSourceName(t *testing.T) {
+
+	configMap := func(name string) fstest.MapFS {
+		return fstest.MapFS{
+			"configmap.yaml": &fstest.MapFile{
+				Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n"),
+			},
+		}
+	}
+
+	t.Run("should keep cache entries of Sources sharing a pattern apart", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{Name: "first", FS: configMap("first"), Path: "*.yaml"},
+				{Name: "second", FS: configMap("second"), Path: "*.yaml"},
+			},
+			gotemplate.WithCache(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(objects).To(HaveLen(2))
+			g.Expect(objects[0].GetName()).To(Equal("first"))
+			g.Expect(objects[1].GetName()).To(Equal("second"))
+		}
+
+		g.Expect(renderer.Stats().Entries).To(Equal(2))
+		g.Expect(renderer.CacheKey(gotemplate.TemplateSpec{Name: "first", Path: "*.yaml"})).ToNot(
+			Equal(renderer.CacheKey(gotemplate.TemplateSpec{Name: "second", Path: "*.yaml"})),
+		)
+	})
+
+	t.Run("should name the Source in validation errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New([]gotemplate.Source{{Name: "broken", Path: "*.yaml"}})
+		g.Expect(err).To(MatchError(ContainSubstring("(source broken)")))
+	})
+}
+
+func TestContinueOnError(t *testing.T) {
+
+	sources := []gotemplate.Source{
+		{
+			FS:   fstest.MapFS{"bad-exec.yaml": &fstest.MapFile{Data: []byte("kind: {{ .missing }}")}},
+			Path: "bad-exec.yaml",
+		},
+		{
+			FS:   fstest.MapFS{"good.yaml": &fstest.MapFile{Data: []byte(configMapTemplate)}},
+			Path: "good.yaml",
+			Values: gotemplate.Values(map[string]any{
+				"Repo":      "test-app",
+				"Component": "frontend",
+				"Port":      8080,
+			}),
+		},
+		{
+			FS:   fstest.MapFS{"bad-field.yaml": &fstest.MapFile{Data: []byte(invalidTemplate)}},
+			Path: "bad-field.yaml",
+		},
+	}
+
+	for _, parallelism := range []int{1, 3} {
+		name := fmt.Sprintf("should report all errors and return good output with parallelism %d", parallelism)
+		t.Run(name, func(t *testing.T) {
+			g := NewWithT(t)
+			renderer, err := gotemplate.New(sources,
+				gotemplate.WithContinueOnError(),
+				gotemplate.WithParallelism(parallelism),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(ContainSubstring("gotemplate pattern bad-exec.yaml")))
+			g.Expect(err).To(MatchError(ContainSubstring("gotemplate pattern bad-field.yaml")))
+			g.Expect(err).ToNot(MatchError(ContainSubstring("good.yaml")))
+			g.Expect(objects).To(HaveLen(1))
+			g.Expect(objects[0].GetKind()).To(Equal("ConfigMap"))
+		})
+	}
+
+	t.Run("should fail fast by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(sources)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("bad-exec.yaml")))
+		g.Expect(err).ToNot(MatchError(ContainSubstring("bad-field.yaml")))
+		g.Expect(objects).To(BeNil())
+	})
+}
+
+var errTransformFailed = errors.New("transform failed")
+
+func TestObjectsTransformer(t *testing.T) {
+
+	sources := []gotemplate.Source{
+		{
+			FS: fstest.MapFS{
+				"configmap.yaml": &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+				"secret.yaml":    &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: Secret\nmetadata:\n  name: b\n")},
+			},
+			Path: "*.yaml",
+		},
+		{
+			FS: fstest.MapFS{
+				"service.yaml": &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: c\n")},
+			},
+			Path: "service.yaml",
+		},
+	}
+
+	injectLabel := func(_ context.Context, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+		for _, obj := range objects {
+			obj.SetLabels(map[string]string{"injected": "true"})
+		}
+
+		return objects, nil
+	}
+
+	dropSecrets := func(_ context.Context, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+		return slices.DeleteFunc(objects, func(obj *unstructured.Unstructured) bool {
+			return obj.GetKind() == "Secret"
+		}), nil
+	}
+
+	kinds := func(objects []unstructured.Unstructured) []string {
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.GetKind())
+		}
+
+		return result
+	}
+
+	t.Run("should inject labels and drop kinds across Sources", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(sources,
+			gotemplate.WithObjectsTransformer(injectLabel),
+			gotemplate.WithObjectsTransformer(dropSecrets),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(kinds(objects)).To(Equal([]string{"ConfigMap", "Service"}))
+
+		for _, obj := range objects {
+			g.Expect(obj.Object).To(jqmatcher.Match(`.metadata.labels.injected == "true"`))
+		}
+	})
+
+	t.Run("should compose in registration order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var seen []int
+		counting := func(_ context.Context, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+			seen = append(seen, len(objects))
+
+			return objects, nil
+		}
+
+		renderer, err := gotemplate.New(sources,
+			gotemplate.WithObjectsTransformer(counting),
+			gotemplate.WithObjectsTransformer(dropSecrets),
+			gotemplate.WithObjectsTransformer(counting),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(seen).To(Equal([]int{3, 2}))
+	})
+
+	t.Run("should add objects", func(t *testing.T) {
+		g := NewWithT(t)
+		addNamespace := func(_ context.Context, objects []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+			ns := &unstructured.Unstructured{}
+			ns.SetAPIVersion("v1")
+			ns.SetKind("Namespace")
+			ns.SetName("apps")
+
+			return append([]*unstructured.Unstructured{ns}, objects...), nil
+		}
+
+		renderer, err := gotemplate.New(sources, gotemplate.WithObjectsTransformer(addNamespace))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(kinds(objects)).To(Equal([]string{"Namespace", "ConfigMap", "Secret", "Service"}))
+	})
+
+	t.Run("should identify the failing transformer", func(t *testing.T) {
+		g := NewWithT(t)
+		failing := func(_ context.Context, _ []*unstructured.Unstructured) ([]*unstructured.Unstructured, error) {
+			return nil, errTransformFailed
+		}
+
+		renderer, err := gotemplate.New(sources,
+			gotemplate.WithObjectsTransformer(injectLabel),
+			gotemplate.WithObjectsTransformer(failing),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(errTransformFailed))
+		g.Expect(err).To(MatchError(ContainSubstring("objects transformer 1 failed")))
+		g.Expect(objects).To(BeNil())
+
+		g.Expect(renderer.Validate(t.Context(), nil)).To(MatchError(errTransformFailed))
+
+		_, err = renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).To(MatchError(errTransformFailed))
+	})
+
+	t.Run("should apply to RenderObjects", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(sources, gotemplate.WithObjectsTransformer(dropSecrets))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].GetKind()).To(Equal("ConfigMap"))
+		g.Expect(objects[1].GetKind()).To(Equal("Service"))
+	})
+
+	t.Run("should not modify cached results", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(sources,
+			gotemplate.WithCache(),
+			gotemplate.WithObjectsTransformer(injectLabel),
+			gotemplate.WithObjectsTransformer(dropSecrets),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 2 {
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+			g.Expect(kinds(objects)).To(Equal([]string{"ConfigMap", "Service"}))
+		}
+	})
+}
+
+const deploymentTemplate = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+`
+
+func TestDetectDuplicates(t *testing.T) {
+
+	source := func(dir string, name string) gotemplate.Source {
+		return gotemplate.Source{
+			FS:     fstest.MapFS{dir + "/deployment.yaml": &fstest.MapFile{Data: []byte(deploymentTemplate)}},
+			Path:   dir + "/*.yaml",
+			Values: gotemplate.Values(map[string]any{"name": name}),
+		}
+	}
+
+	for _, parallelism := range []int{1, 2} {
+		t.Run(fmt.Sprintf("should report duplicates across Sources with parallelism %d", parallelism), func(t *testing.T) {
+			g := NewWithT(t)
+			renderer, err := gotemplate.New(
+				[]gotemplate.Source{source("a", "web"), source("b", "web")},
+				gotemplate.WithDetectDuplicates(),
+				gotemplate.WithParallelism(parallelism),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			objects, err := renderer.Process(t.Context(), nil)
+			g.Expect(objects).To(BeNil())
+
+			var dupErr *gotemplate.DuplicateError
+			g.Expect(errors.As(err, &dupErr)).To(BeTrue())
+			g.Expect(dupErr.Duplicates).To(Equal([]gotemplate.DuplicateResource{
+				{
+					APIVersion: "apps/v1",
+					Kind:       "Deployment",
+					Name:       "web",
+					Sources:    []string{"a/*.yaml", "b/*.yaml"},
+				},
+			}))
+			g.Expect(err).To(MatchError(ContainSubstring("apps/v1 Deployment web (sources: a/*.yaml, b/*.yaml)")))
+		})
+	}
+
+	t.Run("should accept distinct identities", func(t *testing.T) {
+		g := NewWithT(t)
+		other := source("c", "web")
+		other.FS = fstest.MapFS{"c/service.yaml": &fstest.MapFile{
+			Data: []byte("apiVersion: v1\nkind: Service\nmetadata:\n  name: {{ .name }}\n"),
+		}}
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{source("a", "web"), source("b", "api"), other},
+			gotemplate.WithDetectDuplicates(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+	})
+
+	t.Run("should identify duplicates after namespace defaulting", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{source("a", "web"), source("b", "web")},
+			gotemplate.WithDetectDuplicates(),
+			gotemplate.WithNamespace("apps"),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("apps/v1 Deployment apps/web")))
+	})
+
+	t.Run("should report duplicates within a Source from RenderObjects", func(t *testing.T) {
+		g := NewWithT(t)
+		twice := source("a", "web")
+		twice.FS = fstest.MapFS{"a/deployment.yaml": &fstest.MapFile{
+			Data: []byte(deploymentTemplate + "---\n" + deploymentTemplate),
+		}}
+
+		renderer, err := gotemplate.New([]gotemplate.Source{twice}, gotemplate.WithDetectDuplicates())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.RenderObjects(t.Context(), nil)
+
+		var dupErr *gotemplate.DuplicateError
+		g.Expect(errors.As(err, &dupErr)).To(BeTrue())
+		g.Expect(dupErr.Duplicates).To(HaveLen(1))
+		g.Expect(dupErr.Duplicates[0].Sources).To(Equal([]string{"a/*.yaml", "a/*.yaml"}))
+	})
+
+	t.Run("should return objects with the error on continue on error", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{source("a", "web"), source("b", "web")},
+			gotemplate.WithDetectDuplicates(),
+			gotemplate.WithContinueOnError(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("duplicate resources")))
+		g.Expect(objects).To(HaveLen(2))
+	})
+
+	t.Run("should not check by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{source("a", "web"), source("b", "web")})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+	})
+}
+
+func TestValidate(t *testing.T) {
+
+	goodSource := gotemplate.Source{
+		FS:   fstest.MapFS{"good.yaml": &fstest.MapFile{Data: []byte(simpleKeyTemplate)}},
+		Path: "good.yaml",
+	}
+
+	t.Run("should succeed for valid templates without populating the cache", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{goodSource}, gotemplate.WithCache())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.Validate(t.Context(), map[string]any{"key": "value"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats()).To(Equal(gotemplate.CacheStats{}))
+	})
+
+	t.Run("should report a syntax error", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:   fstest.MapFS{"broken.yaml": &fstest.MapFile{Data: []byte("kind: {{ .name ")}},
+				Path: "broken.yaml",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.Validate(t.Context(), map[string]any{"name": "app"})
+		g.Expect(err).To(MatchError(ContainSubstring("failed to parse templates")))
+		g.Expect(err).To(MatchError(ContainSubstring("broken.yaml")))
+	})
+
+	t.Run("should report a missing key", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{goodSource})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.Validate(t.Context(), nil)
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "key"`)))
+	})
+
+	t.Run("should not be masked by a cached render", func(t *testing.T) {
+		g := NewWithT(t)
+		templateFS := fstest.MapFS{"good.yaml": &fstest.MapFile{Data: []byte(simpleKeyTemplate)}}
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: templateFS, Path: "good.yaml"}},
+			gotemplate.WithCache(),
+			gotemplate.WithCacheTTL(time.Nanosecond),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = renderer.Process(t.Context(), map[string]any{"key": "value"})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		templateFS["good.yaml"] = &fstest.MapFile{Data: []byte("kind: {{ .other }}")}
+
+		err = renderer.Validate(t.Context(), map[string]any{"key": "value"})
+		g.Expect(err).To(MatchError(ContainSubstring(`map has no entry for key "other"`)))
+	})
+
+	t.Run("should report every failing source", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer, err := gotemplate.New([]gotemplate.Source{
+			{
+				FS:   fstest.MapFS{"first.yaml": &fstest.MapFile{Data: []byte("kind: {{ .first }}")}},
+				Path: "first.yaml",
+			},
+			goodSource,
+			{
+				FS:   fstest.MapFS{"second.yaml": &fstest.MapFile{Data: []byte("kind: {{ .second }}")}},
+				Path: "second.yaml",
+			},
+		})
+		g.Expect(err).ToNot(HaveOccurred())
+
+		err = renderer.Validate(t.Context(), map[string]any{"key": "value"})
+		g.Expect(err).To(MatchError(ContainSubstring("gotemplate pattern first.yaml")))
+		g.Expect(err).To(MatchError(ContainSubstring("gotemplate pattern second.yaml")))
+		g.Expect(err).ToNot(MatchError(ContainSubstring("good.yaml")))
+	})
+}
+
+func TestNamespace(t *testing.T) {
+
+	const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+---
+apiVersion: rbac.authorization.k8s.io/v1
+kind: ClusterRole
+metadata:
+  name: app-reader
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  namespace: templated
+---
+apiVersion: example.com/v1
+kind: ClusterWidget
+metadata:
+  name: widget
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"manifests.yaml": &fstest.MapFile{Data: []byte(manifests)},
+					},
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should set the namespace on namespaced objects only", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithNamespace("prod"))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(4))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.namespace == "prod"`))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.metadata | has("namespace") | not`))
+		g.Expect(objects[2].Object).To(jqmatcher.Match(`.metadata.namespace == "templated"`))
+		g.Expect(objects[3].Object).To(jqmatcher.Match(`.metadata.namespace == "prod"`))
+	})
+
+	t.Run("should override existing namespaces when forced", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithForceNamespace("prod"))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(4))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.metadata | has("namespace") | not`))
+		g.Expect(objects[2].Object).To(jqmatcher.Match(`.metadata.namespace == "prod"`))
+	})
+
+	t.Run("should honor kind scope overrides", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			gotemplate.WithNamespace("prod"),
+			gotemplate.WithClusterScopedKinds(map[string]bool{"ClusterWidget": true, "ClusterRole": false}),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(4))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.metadata.namespace == "prod"`))
+		g.Expect(objects[3].Object).To(jqmatcher.Match(`.metadata | has("namespace") | not`))
+	})
+
+	t.Run("should leave objects untouched without a namespace", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(4))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata | has("namespace") | not`))
+	})
+}
+
+func TestNamespaceFunction(t *testing.T) {
+
+	const roleBinding = `apiVersion: rbac.authorization.k8s.io/v1
+kind: RoleBinding
+metadata:
+  name: app
+roleRef:
+  apiGroup: rbac.authorization.k8s.io
+  kind: Role
+  name: app
+subjects:
+- kind: ServiceAccount
+  name: app
+  namespace: {{ namespace | quote }}
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"rolebinding.yaml": &fstest.MapFile{Data: []byte(roleBinding)},
+					},
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should return the configured namespace", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithNamespace("prod"))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.metadata.namespace == "prod"`))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.subjects[0].namespace == "prod"`))
+	})
+
+	t.Run("should return empty without a namespace", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithMissingKeyMode(gotemplate.MissingKeyError))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.subjects[0].namespace == ""`))
+	})
+
+	t.Run("should follow the namespace of clones sharing templates", func(t *testing.T) {
+		g := NewWithT(t)
+		base := newRenderer(t, gotemplate.WithNamespace("base"))
+
+		_, err := base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		clone, err := base.Clone(gotemplate.WithNamespace("tenant-a"))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := clone.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.subjects[0].namespace == "tenant-a"`))
+
+		objects, err = base.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.subjects[0].namespace == "base"`))
+	})
+}
+
+func TestCommonMetadata(t *testing.T) {
+
+	const manifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+  labels:
+    app.kubernetes.io/managed-by: templates
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+        - name: web
+          image: nginx
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  schedule: "@daily"
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          restartPolicy: Never
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: app-config
+  annotations:
+    owner: templates
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"manifests.yaml": &fstest.MapFile{Data: []byte(manifests)},
+					},
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	labels := map[string]string{
+		"app.kubernetes.io/managed-by": "gotemplate",
+		"team":                         "platform",
+	}
+
+	t.Run("should merge labels and annotations without overwriting", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			gotemplate.WithCommonLabels(labels),
+			gotemplate.WithCommonAnnotations(map[string]string{"owner": "renderer", "docs": "https://example.com"}),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].GetLabels()).To(Equal(map[string]string{
+			"app.kubernetes.io/managed-by": "templates",
+			"team":                         "platform",
+		}))
+		g.Expect(objects[1].GetLabels()).To(Equal(labels))
+		g.Expect(objects[2].GetAnnotations()).To(Equal(map[string]string{
+			"owner": "templates",
+			"docs":  "https://example.com",
+		}))
+	})
+
+	t.Run("should not touch pod templates by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithCommonLabels(labels))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.spec.template.metadata.labels == {"app": "web"}`))
+		g.Expect(objects[1].Object).To(jqmatcher.Match(`.spec.jobTemplate.spec.template | has("metadata") | not`))
+	})
+
+	t.Run("should propagate labels to pod templates", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithCommonLabels(labels), gotemplate.WithPropagatePodLabels(true))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.spec.template.metadata.labels.app == "web"`),
+			jqmatcher.Match(`.spec.template.metadata.labels.team == "platform"`),
+			jqmatcher.Match(`.spec.template.spec.containers[0].image == "nginx"`),
+		))
+		g.Expect(objects[1].Object).To(
+			jqmatcher.Match(`.spec.jobTemplate.spec.template.metadata.labels.team == "platform"`),
+		)
+		g.Expect(objects[2].Object).To(jqmatcher.Match(`.metadata | has("spec") | not`))
+	})
+}
+
+func TestRenderObjects(t *testing.T) {
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "app"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should decode each document into an object", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, fstest.MapFS{
+			"multi.yaml": &fstest.MapFile{Data: []byte(multiDocumentTemplate)},
+		})
+
+		objects, err := renderer.RenderObjects(t.Context(), map[string]any{"name": "web"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[0].GroupVersionKind()).To(Equal(schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}))
+		g.Expect(objects[0].GetName()).To(Equal("web-script"))
+		g.Expect(objects[1].GroupVersionKind()).To(Equal(schema.GroupVersionKind{Version: "v1", Kind: "Service"}))
+		g.Expect(objects[1].GetName()).To(Equal("web"))
+	})
+
+	t.Run("should apply the renderer metadata options", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			fstest.MapFS{"multi.yaml": &fstest.MapFile{Data: []byte(multiDocumentTemplate)}},
+			gotemplate.WithNamespace("prod"),
+			gotemplate.WithSourceAnnotations(true),
+		)
+
+		objects, err := renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(objects[1].GetNamespace()).To(Equal("prod"))
+		g.Expect(objects[1].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceFile, "multi.yaml"))
+	})
+
+	t.Run("should report the Source and document index on decode errors", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, fstest.MapFS{
+			"a.yaml": &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: a\n")},
+			"b.yaml": &fstest.MapFile{Data: []byte("apiVersion: v1\nkind: ConfigMap\n---\nkey: [unclosed\n")},
+		})
+
+		_, err := renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to decode document 2 of gotemplate pattern *.yaml")))
+	})
+}
+
+//go:embed testdata/embedded
+var embeddedTemplates embed.FS
+
+func TestSubFS(t *testing.T) {
+
+	t.Run("should scope a Source to a nested folder", func(t *testing.T) {
+		g := NewWithT(t)
+
+		sub, err := gotemplate.SubFS(embeddedTemplates, "testdata/embedded/templates/nested")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     sub,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "embedded"}),
+				},
+			},
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("embedded"))
+	})
+
+	t.Run("should fail for a missing directory", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.SubFS(embeddedTemplates, "testdata/embedded/missing")
+		g.Expect(err).To(MatchError(fs.ErrNotExist))
+		g.Expect(err).To(MatchError(ContainSubstring("testdata/embedded/missing")))
+	})
+
+	t.Run("should fail for a file", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.SubFS(embeddedTemplates, "testdata/embedded/docs/notes.yaml")
+		g.Expect(err).To(MatchError(gotemplate.ErrNotADirectory))
+	})
+
+	t.Run("should reject invalid paths", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.SubFS(embeddedTemplates, "../testdata")
+		g.Expect(err).To(HaveOccurred())
+	})
+}
+
+func TestValuesMode(t *testing.T) {
+
+	const tmpl = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .name }}
+data:
+  env: {{ .env | default "none" }}
+  image: "{{ .image.repository }}:{{ .image.tag | default "latest" }}"
+`
+
+	source := func(dir string, mode gotemplate.ValuesMode, values map[string]any) gotemplate.Source {
+		return gotemplate.Source{
+			FS: fstest.MapFS{
+				dir + "/configmap.yaml": &fstest.MapFile{Data: []byte(tmpl)},
+			},
+			Path:       dir + "/*.yaml",
+			Values:     gotemplate.Values(values),
+			ValuesMode: mode,
+		}
+	}
+
+	newRenderer := func(t *testing.T, sources ...gotemplate.Source) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			sources,
+			gotemplate.WithSprigFunctions(),
+			gotemplate.WithMissingKeyMode(gotemplate.MissingKeyZero),
+			gotemplate.WithDefaultValues(map[string]any{
+				"env":   "dev",
+				"image": map[string]any{"repository": "nginx", "tag": "1.0"},
+			}),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	renderTime := map[string]any{"env": "prod", "image": map[string]any{"tag": "2.0"}}
+
+	t.Run("should give each Source its own values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			source("app", gotemplate.ValuesModeMerge, map[string]any{"name": "app", "env": "source"}),
+			source("crds", gotemplate.ValuesModeReplace, map[string]any{
+				"name":  "crds",
+				"image": map[string]any{"repository": "busybox"},
+			}),
+			source("overlay", gotemplate.ValuesModeOverlay, map[string]any{
+				"name":  "overlay",
+				"env":   "source",
+				"image": map[string]any{"tag": "3.0"},
+			}),
+		)
+
+		objects, err := renderer.Process(t.Context(), renderTime)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(3))
+
+		// Merge: defaults < source < render-time
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "app"`),
+			jqmatcher.Match(`.data.env == "prod"`),
+			jqmatcher.Match(`.data.image == "nginx:2.0"`),
+		))
+
+		// Replace: source only
+		g.Expect(objects[1].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "crds"`),
+			jqmatcher.Match(`.data.env == "none"`),
+			jqmatcher.Match(`.data.image == "busybox:latest"`),
+		))
+
+		// Overlay: defaults < render-time < source, merged deeply
+		g.Expect(objects[2].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "overlay"`),
+			jqmatcher.Match(`.data.env == "source"`),
+			jqmatcher.Match(`.data.image == "nginx:3.0"`),
+		))
+	})
+
+	t.Run("should reject unknown modes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		_, err := gotemplate.New([]gotemplate.Source{source("app", "bogus", nil)})
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidValuesMode))
+	})
+}
+
+func TestValuesTemplating(t *testing.T) {
+
+	const deploymentTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .fullname }}
+data:
+  image: {{ .image.ref }}
+  host: {{ index .hosts 0 }}
+`
+
+	newRenderer := func(t *testing.T, values map[string]any, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(deploymentTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(values),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	values := func() map[string]any {
+		return map[string]any{
+			"name":     "app",
+			"fullname": "{{ .name }}-web",
+			"image": map[string]any{
+				"tag": "1.25",
+				"ref": "nginx:{{ .image.tag }}",
+			},
+			"hosts": []any{"{{ .fullname }}.example.com"},
+		}
+	}
+
+	t.Run("should resolve references between values", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, values(), gotemplate.WithValuesTemplating())
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "override"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.metadata.name == "override-web"`),
+			jqmatcher.Match(`.data.image == "nginx:1.25"`),
+			jqmatcher.Match(`.data.host == "override-web.example.com"`),
+		))
+	})
+
+	t.Run("should leave values untouched by default", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := newRenderer(t, map[string]any{
+			"fullname": "app",
+			"image":    map[string]any{"ref": "'{{ .tag }}'"},
+			"hosts":    []any{"example.com"},
+		}).Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data.image == "{{ .tag }}"`))
+	})
+
+	t.Run("should fail on cyclic references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		v := values()
+		v["name"] = "{{ .fullname }}"
+
+		_, err := newRenderer(t, v, gotemplate.WithValuesTemplating()).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrValuesCycle))
+		g.Expect(err).To(MatchError(ContainSubstring("fullname, hosts[0], name")))
+	})
+
+	t.Run("should fail on references that keep growing", func(t *testing.T) {
+		g := NewWithT(t)
+
+		v := values()
+		v["name"] = "x{{ .fullname }}"
+
+		_, err := newRenderer(t, v, gotemplate.WithValuesTemplating()).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrValuesCycle))
+		g.Expect(err).To(MatchError(ContainSubstring("still changing")))
+	})
+
+	t.Run("should fail on missing references", func(t *testing.T) {
+		g := NewWithT(t)
+
+		v := values()
+		v["fullname"] = "{{ .missing }}"
+
+		_, err := newRenderer(t, v, gotemplate.WithValuesTemplating()).Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(ContainSubstring("failed to render value fullname")))
+	})
+}
+
+func TestClose(t *testing.T) {
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"pod.yaml": &fstest.MapFile{Data: []byte(podTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"Repo": "app", "Component": "web"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should fail renders after Close", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		g.Expect(renderer.Close()).To(Succeed())
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+
+		_, err = renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+
+		_, err = renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+
+		_, err = renderer.RenderTemplate(t.Context(), "pod.yaml", nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+
+		g.Expect(renderer.RenderTo(t.Context(), &bytes.Buffer{}, nil)).To(MatchError(gotemplate.ErrRendererClosed))
+		g.Expect(renderer.Validate(t.Context(), nil)).To(MatchError(gotemplate.ErrRendererClosed))
+
+		_, err = renderer.Lint(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+	})
+
+	t.Run("should clear the cache", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithCache())
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(renderer.Stats().Entries).To(Equal(1))
+
+		g.Expect(renderer.Close()).To(Succeed())
+		g.Expect(renderer.Stats().Entries).To(Equal(0))
+	})
+
+	t.Run("should be idempotent", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithWatch(time.Millisecond))
+
+		g.Expect(renderer.Close()).To(Succeed())
+		g.Expect(renderer.Close()).To(Succeed())
+	})
+
+	t.Run("should be safe to call concurrently with renders", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithCache(), gotemplate.WithWatch(time.Millisecond))
+
+		const workers = 8
+
+		var wg sync.WaitGroup
+		for range workers {
+			wg.Add(2)
+			go func() {
+				defer wg.Done()
+				_, err := renderer.Process(t.Context(), nil)
+				if err != nil && !errors.Is(err, gotemplate.ErrRendererClosed) {
+					t.Errorf("unexpected error: %v", err)
+				}
+			}()
+			go func() {
+				defer wg.Done()
+				_ = renderer.Close()
+			}()
+		}
+		wg.Wait()
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+	})
+}
+
+func TestLayout(t *testing.T) {
+
+	const base = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .name }}
+  labels:
+{{- block "labels" . }}
+    app: {{ .name }}
+{{- end }}
+spec:
+{{- block "spec" . }}
+  replicas: 1
+{{- end }}
+`
+
+	// Sorts before the base, so overrides must not depend on file names
+	const app = `{{ define "spec" }}
+  replicas: {{ .replicas }}
+{{- end }}`
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "web", "replicas": 3}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should execute the base with child overrides", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			fstest.MapFS{
+				"base.yaml": &fstest.MapFile{Data: []byte(base)},
+				"app.yaml":  &fstest.MapFile{Data: []byte(app)},
+			},
+			gotemplate.WithLayout("base.yaml"),
+			gotemplate.WithSourceAnnotations(true),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(And(
+			jqmatcher.Match(`.spec.replicas == 3`),
+			jqmatcher.Match(`.metadata.labels == {"app": "web"}`),
+		))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceFile, "base.yaml"))
+	})
+
+	t.Run("should fall back to the base block content", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			fstest.MapFS{
+				"base.yaml": &fstest.MapFile{Data: []byte(base)},
+			},
+			gotemplate.WithLayout("base.yaml"),
+		)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.spec.replicas == 1`))
+	})
+
+	t.Run("should fail when the base is not defined", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t,
+			fstest.MapFS{
+				"app.yaml": &fstest.MapFile{Data: []byte(app)},
+			},
+			gotemplate.WithLayout("base.yaml"),
+		)
+
+		_, err := renderer.Process(t.Context(), nil)
+
+		var notFound *gotemplate.TemplateNotFoundError
+		g.Expect(errors.As(err, &notFound)).To(BeTrue())
+		g.Expect(notFound.Name).To(Equal("base.yaml"))
+	})
+}
+
+func TestLogger(t *testing.T) {
+
+	const secretTemplate = `apiVersion: v1
+kind: Secret
+metadata:
+  name: app
+stringData:
+  password: {{ .password }}
+`
+
+	newRenderer := func(t *testing.T, lines *[]string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		var mu sync.Mutex
+
+		log := funcr.New(func(_ string, args string) {
+			mu.Lock()
+			defer mu.Unlock()
+
+			*lines = append(*lines, args)
+		}, funcr.Options{Verbosity: 1})
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name: "secrets",
+					FS: fstest.MapFS{
+						"secret.yaml": &fstest.MapFile{Data: []byte(secretTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"password": "s3cr3t"}),
+				},
+			},
+			append(opts, gotemplate.WithLogger(log))...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should log parsing, cache lookups and renders", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		renderer := newRenderer(t, &lines, gotemplate.WithCache())
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(lines).To(HaveExactElements(
+			And(ContainSubstring(`"msg"="parsed templates"`), ContainSubstring(`"templates"=["secret.yaml"]`)),
+			ContainSubstring(`"msg"="render cache miss"`),
+			And(ContainSubstring(`"msg"="rendered source"`), ContainSubstring(`"objects"=1`)),
+			ContainSubstring(`"msg"="reusing parsed templates"`),
+			ContainSubstring(`"msg"="render cache hit"`),
+		))
+
+		for _, line := range lines {
+			g.Expect(line).To(And(ContainSubstring(`"path"="*.yaml"`), ContainSubstring(`"source"="secrets"`)))
+			g.Expect(line).ToNot(ContainSubstring("s3cr3t"))
+		}
+	})
+
+	t.Run("should log values only when enabled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		renderer := newRenderer(t, &lines, gotemplate.WithVerboseValueLogging())
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(lines).To(ContainElement(And(
+			ContainSubstring(`"msg"="rendered source"`),
+			ContainSubstring("s3cr3t"),
+		)))
+	})
+}
+
+func TestWarm(t *testing.T) {
+
+	configMap := "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: {{ .name }}\n"
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, lines *[]string) *gotemplate.Renderer {
+		t.Helper()
+
+		log := funcr.New(func(_ string, args string) {
+			*lines = append(*lines, args)
+		}, funcr.Options{Verbosity: 1})
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{FS: files, Path: "a/*.yaml", Values: gotemplate.Values(map[string]any{"name": "a"})},
+				{FS: files, Path: "b/*.yaml", Values: gotemplate.Values(map[string]any{"name": "b"})},
+			},
+			gotemplate.WithLogger(log),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should reuse the warmed templates on the first render", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		renderer := newRenderer(t, fstest.MapFS{
+			"a/configmap.yaml": &fstest.MapFile{Data: []byte(configMap)},
+			"b/configmap.yaml": &fstest.MapFile{Data: []byte(configMap)},
+		}, &lines)
+
+		g.Expect(renderer.Warm(t.Context())).To(Succeed())
+		g.Expect(lines).To(HaveExactElements(
+			ContainSubstring(`"msg"="parsed templates"`),
+			ContainSubstring(`"msg"="parsed templates"`),
+		))
+
+		lines = nil
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(2))
+		g.Expect(lines).To(ContainElement(ContainSubstring(`"msg"="reusing parsed templates"`)))
+		g.Expect(lines).ToNot(ContainElement(ContainSubstring(`"msg"="parsed templates"`)))
+	})
+
+	t.Run("should join the parse errors of all Sources", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		renderer := newRenderer(t, fstest.MapFS{
+			"a/configmap.yaml": &fstest.MapFile{Data: []byte("{{ .name")},
+			"b/configmap.yaml": &fstest.MapFile{Data: []byte("{{ end }}")},
+		}, &lines)
+
+		err := renderer.Warm(t.Context())
+		g.Expect(err).To(MatchError(And(ContainSubstring("a/*.yaml"), ContainSubstring("b/*.yaml"))))
+	})
+
+	t.Run("should stop when the context is cancelled", func(t *testing.T) {
+		g := NewWithT(t)
+
+		var lines []string
+		renderer := newRenderer(t, fstest.MapFS{
+			"a/configmap.yaml": &fstest.MapFile{Data: []byte(configMap)},
+			"b/configmap.yaml": &fstest.MapFile{Data: []byte(configMap)},
+		}, &lines)
+
+		ctx, cancel := context.WithCancel(t.Context())
+		cancel()
+
+		g.Expect(renderer.Warm(ctx)).To(MatchError(context.Canceled))
+		g.Expect(lines).To(BeEmpty())
+	})
+}
+
+func TestRenderTimeout(t *testing.T) {
+
+	// Iterates len(items)^2 times without producing output
+	const slowTemplate = `{{ range .items }}{{ range $.items }}{{ end }}{{ end }}`
+
+	const podTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: fast
+`
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, timeout time.Duration) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name:   "slow",
+					FS:     files,
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"items": make([]any, 5000)}),
+				},
+			},
+			gotemplate.WithRenderTimeout(timeout),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should abort templates exceeding the timeout", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, fstest.MapFS{
+			"slow.yaml": &fstest.MapFile{Data: []byte(slowTemplate)},
+		}, 20*time.Millisecond)
+
+		start := time.Now()
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(time.Since(start)).To(BeNumerically("<", time.Second))
+		g.Expect(err).To(MatchError(gotemplate.ErrRenderTimeout))
+		g.Expect(err).To(MatchError(
+			"gotemplate pattern *.yaml (source slow): template slow.yaml: render timeout exceeded: did not render within 20ms",
+		))
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(renderErr.TemplateName).To(Equal("slow.yaml"))
+	})
+
+	t.Run("should render templates completing in time", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, fstest.MapFS{
+			"pod.yaml": &fstest.MapFile{Data: []byte(podTemplate)},
+		}, time.Minute)
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		var buf bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(Succeed())
+		g.Expect(buf.String()).To(Equal(podTemplate))
+	})
+
+	t.Run("should stop waiting on context cancellation", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, fstest.MapFS{
+			"slow.yaml": &fstest.MapFile{Data: []byte(slowTemplate)},
+		}, time.Minute)
+
+		ctx, cancel := context.WithTimeout(t.Context(), 20*time.Millisecond)
+		defer cancel()
+
+		_, err := renderer.Process(ctx, nil)
+		g.Expect(err).To(MatchError(context.DeadlineExceeded))
+	})
+}
+
+func TestMaxOutputBytes(t *testing.T) {
+
+	const configMapTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: large
+data:
+{{- range $i, $_ := .items }}
+  key{{ $i }}: value
+{{- end }}
+`
+
+	newRenderer := func(t *testing.T, count int, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(configMapTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"items": make([]any, count)}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should abort renders exceeding the cap", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, 1000, gotemplate.WithMaxOutputBytes(1024))
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrOutputTooLarge))
+
+		var renderErr *gotemplate.RenderError
+		g.Expect(errors.As(err, &renderErr)).To(BeTrue())
+		g.Expect(renderErr.TemplateName).To(Equal("configmap.yaml"))
+	})
+
+	t.Run("should abort streaming renders exceeding the cap", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, 1000, gotemplate.WithMaxOutputBytes(1024))
+
+		var buf bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(MatchError(gotemplate.ErrOutputTooLarge))
+		g.Expect(buf.Len()).To(BeNumerically("<=", 1024))
+	})
+
+	t.Run("should apply the cap with a render timeout", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, 1000, gotemplate.WithMaxOutputBytes(1024), gotemplate.WithRenderTimeout(time.Minute))
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrOutputTooLarge))
+	})
+
+	t.Run("should render output within the cap", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, 3, gotemplate.WithMaxOutputBytes(1024))
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].Object).To(jqmatcher.Match(`.data | length == 3`))
+	})
+
+	capped := func(t *testing.T, files fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{FS: files, Path: "*.yaml"}},
+			append(opts, gotemplate.WithMaxOutputBytes(1024))...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	padded := func(name string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(
+			"apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\ndata:\n  pad: " + strings.Repeat("x", 600) + "\n",
+		)}
+	}
+
+	t.Run("should cap the output of all templates together", func(t *testing.T) {
+		g := NewWithT(t)
+
+		objects, err := capped(t, fstest.MapFS{"a.yaml": padded("a")}).Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+
+		renderer := capped(t, fstest.MapFS{"a.yaml": padded("a"), "b.yaml": padded("b")})
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrOutputTooLarge))
+
+		var buf bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &buf, nil)).To(MatchError(gotemplate.ErrOutputTooLarge))
+
+		// Every call gets a budget of its own
+		_, err = capped(t, fstest.MapFS{"a.yaml": padded("a")}).Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+	})
+
+	t.Run("should charge include and tpl results", func(t *testing.T) {
+		g := NewWithT(t)
+
+		helpers := `{{- define "big" }}{{ range $i, $_ := .items }}0123456789{{ end }}{{ end -}}`
+		for _, discarded := range []string{`{{ $_ := include "big" . }}`, `{{ $_ := tpl "{{ include \"big\" . }}" . }}`} {
+			renderer, err := gotemplate.New(
+				[]gotemplate.Source{
+					{
+						FS: fstest.MapFS{
+							"_helpers.tpl": &fstest.MapFile{Data: []byte(helpers)},
+							"cm.yaml": &fstest.MapFile{Data: []byte(
+								discarded + "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: small\n",
+							)},
+						},
+						Path:   "*.yaml",
+						Paths:  []string{"_helpers.tpl"},
+						Values: gotemplate.Values(map[string]any{"items": make([]any, 200)}),
+					},
+				},
+				gotemplate.WithMaxOutputBytes(1024),
+			)
+			g.Expect(err).ToNot(HaveOccurred())
+
+			_, err = renderer.Process(t.Context(), nil)
+			g.Expect(err).To(MatchError(gotemplate.ErrOutputTooLarge), discarded)
+		}
+	})
+
+	t.Run("should refuse repeat results larger than the cap", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := capped(t,
+			fstest.MapFS{"cm.yaml": &fstest.MapFile{Data: []byte(`{{ $_ := repeat 1000000000 "x" }}`)}},
+			gotemplate.WithSprigFunctions(),
+		)
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrOutputTooLarge))
+		g.Expect(err).To(MatchError(ContainSubstring("repeat")))
+	})
+}
+
+func TestCanonicalize(t *testing.T) {
+
+	const compact = `apiVersion: v1
+kind: ConfigMap
+metadata: {name: '{{ .name }}', labels: {tier: "web", app: {{ .name }}}}
+data:
+  port: "8080"
+  list: [b, a]
+`
+
+	const verbose = `# The application config
+kind: "ConfigMap"
+apiVersion: 'v1'
+data:
+    list:
+        - b
+        - a
+    port: '8080'
+metadata:
+    labels:
+        app: "{{ .name }}"
+        tier: web
+    name: {{ .name }}
+`
+
+	newRenderer := func(t *testing.T, files fstest.MapFS, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{{
+				FS:     files,
+				Path:   "*.yaml",
+				Values: gotemplate.Values(map[string]any{"name": "app"}),
+			}},
+			append(opts, gotemplate.WithCanonicalize())...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should canonicalize differently formatted templates identically", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, fstest.MapFS{
+			"compact.yaml": &fstest.MapFile{Data: []byte(compact)},
+			"verbose.yaml": &fstest.MapFile{Data: []byte(verbose)},
+		})
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(2))
+		g.Expect(string(documents[0])).To(Equal(`apiVersion: v1
+data:
+  list:
+  - b
+  - a
+  port: "8080"
+kind: ConfigMap
+metadata:
+  labels:
+    app: app
+    tier: web
+  name: app
+`))
+		g.Expect(documents[1]).To(Equal(documents[0]))
+	})
+
+	t.Run("should write canonical documents with RenderTo", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, fstest.MapFS{
+			"compact.yaml": &fstest.MapFile{Data: []byte(compact)},
+			"verbose.yaml": &fstest.MapFile{Data: []byte(verbose)},
+		})
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var out bytes.Buffer
+		g.Expect(renderer.RenderTo(t.Context(), &out, nil)).To(Succeed())
+		g.Expect(out.String()).To(Equal(string(documents[0]) + "---\n" + string(documents[1])))
+	})
+
+	t.Run("should keep documents that are not YAML", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t, fstest.MapFS{
+			"invalid.yaml": &fstest.MapFile{Data: []byte("key: [unclosed\n")},
+			"text.yaml":    &fstest.MapFile{Data: []byte("just   some text\n")},
+		})
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(2))
+		g.Expect(string(documents[0])).To(Equal("key: [unclosed\n"))
+		g.Expect(string(documents[1])).To(Equal("just   some text\n"))
+	})
+
+	t.Run("should reject documents that are not YAML in strict mode", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer := newRenderer(t,
+			fstest.MapFS{"invalid.yaml": &fstest.MapFile{Data: []byte("key: [unclosed\n")}},
+			gotemplate.WithStrictYAML(),
+		)
+
+		err := renderer.RenderTo(t.Context(), &bytes.Buffer{}, nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+	})
+}
+
+func TestStrictYAML(t *testing.T) {
+
+	const duplicateKeyTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: valid
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: duplicate
+data:
+  key: {{ .first }}
+  key: {{ .second }}
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(duplicateKeyTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"first": "a", "second": "b"}),
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should reject duplicate keys", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithStrictYAML())
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+		g.Expect(err).To(MatchError(ContainSubstring("document 1")))
+		g.Expect(err).To(MatchError(ContainSubstring("*.yaml")))
+		g.Expect(err).To(MatchError(ContainSubstring("configmap.yaml")))
+	})
+
+	t.Run("should reject duplicate keys in documents and objects", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithStrictYAML())
+
+		_, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+
+		_, err = renderer.RenderObjects(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrInvalidYAML))
+		g.Expect(err).To(MatchError(ContainSubstring("document 1")))
+	})
+
+	t.Run("should not check documents by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(2))
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(HaveOccurred())
+		g.Expect(err).ToNot(MatchError(gotemplate.ErrInvalidYAML))
+	})
+
+	t.Run("should accept valid documents", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS: fstest.MapFS{
+						"templates/pod.yaml.tpl": &fstest.MapFile{Data: []byte(podTemplate)},
+					},
+					Path: "templates/*.tpl",
+					Values: gotemplate.Values(map[string]any{
+						"Repo":      "test-app",
+						"Component": "frontend",
+					}),
+				},
+			},
+			gotemplate.WithStrictYAML(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+	})
+}
+
+func TestRequireGVK(t *testing.T) {
+
+	const missingKindTemplate = `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: valid
+---
+# kind comes from a missing value
+apiVersion: v1
+kind: {{ .kind }}
+metadata:
+  name: partial
+`
+
+	newRenderer := func(t *testing.T, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name: "manifests",
+					FS: fstest.MapFS{
+						"configmap.yaml": &fstest.MapFile{Data: []byte(missingKindTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"kind": ""}),
+				},
+				{
+					Name: "notes",
+					FS: fstest.MapFS{
+						"notes.txt": &fstest.MapFile{Data: []byte("installed: true\n")},
+					},
+					Path: "*.txt",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should reject documents missing kind", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithRequireGVK())
+
+		_, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrMissingGVK))
+		g.Expect(err).To(MatchError(ContainSubstring("document 1")))
+		g.Expect(err).To(MatchError(ContainSubstring("source manifests")))
+		g.Expect(err).To(MatchError(ContainSubstring(`missing kind, starting with "apiVersion: v1"`)))
+
+		_, err = renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrMissingGVK))
+	})
+
+	t.Run("should reject non-manifest output of Sources that are not exempt", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithRequireGVK("manifests"))
+
+		_, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).To(MatchError(gotemplate.ErrMissingGVK))
+		g.Expect(err).To(MatchError(ContainSubstring("source notes")))
+		g.Expect(err).To(MatchError(ContainSubstring("missing apiVersion")))
+	})
+
+	t.Run("should skip exempt Sources by name or pattern", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t, gotemplate.WithRequireGVK("manifests", "*.txt"))
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(3))
+	})
+
+	t.Run("should not check documents by default", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(3))
+	})
+}
+
+func TestSourceOrder(t *testing.T) {
+
+	source := func(name string, kind string, order int) gotemplate.Source {
+		return gotemplate.Source{
+			Name: name,
+			FS: fstest.MapFS{
+				name + ".yaml": &fstest.MapFile{
+					Data: []byte("apiVersion: v1\nkind: " + kind + "\nmetadata:\n  name: " + name + "\n"),
+				},
+			},
+			Path:  "*.yaml",
+			Order: order,
+		}
+	}
+
+	sources := []gotemplate.Source{
+		source("app", "ConfigMap", 10),
+		source("crd", "CustomResourceDefinition", -1),
+		source("first", "ConfigMap", 0),
+		source("second", "ConfigMap", 0),
+	}
+
+	names := func(objects []unstructured.Unstructured) []string {
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.GetName())
+		}
+
+		return result
+	}
+
+	expected := []string{"crd", "first", "second", "app"}
+
+	t.Run("should render Sources in ascending order", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(sources)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal(expected))
+
+		documents, err := renderer.RenderDocuments(t.Context())
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(documents).To(HaveLen(4))
+		g.Expect(string(documents[0])).To(ContainSubstring("kind: CustomResourceDefinition"))
+		g.Expect(string(documents[3])).To(ContainSubstring("name: app"))
+	})
+
+	t.Run("should keep the order with parallel rendering", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.New(sources, gotemplate.WithParallelism(4))
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(names(objects)).To(Equal(expected))
+	})
+}
+
+func TestNewFromString(t *testing.T) {
+
+	const podTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .name | upper }}
+`
+
+	t.Run("should render a literal template with values", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.NewFromString("pod.yaml", podTemplate,
+			gotemplate.WithFuncMap(template.FuncMap{"upper": strings.ToUpper}),
+			gotemplate.WithDefaultValues(map[string]any{"name": "default"}),
+			gotemplate.WithSourceAnnotations(true),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		objects, err := renderer.Process(t.Context(), map[string]any{"name": "literal"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(objects).To(HaveLen(1))
+		g.Expect(objects[0].GetName()).To(Equal("LITERAL"))
+		g.Expect(objects[0].GetAnnotations()).To(HaveKeyWithValue(pkgtypes.AnnotationSourceFile, "pod.yaml"))
+
+		out, err := renderer.RenderTemplate(t.Context(), "pod.yaml", nil)
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(ContainSubstring("name: DEFAULT"))
+	})
+
+	t.Run("should cache render results", func(t *testing.T) {
+		g := NewWithT(t)
+
+		renderer, err := gotemplate.NewFromString("[pod].yaml", podTemplate,
+			gotemplate.WithFuncMap(template.FuncMap{"upper": strings.ToUpper}),
+			gotemplate.WithCache(),
+		)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		for range 3 {
+			_, err := renderer.Process(t.Context(), map[string]any{"name": "cached"})
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		g.Expect(renderer.Stats()).To(And(
+			HaveField("Hits", uint64(2)),
+			HaveField("Misses", uint64(1)),
+		))
+	})
+
+	t.Run("should reject invalid names", func(t *testing.T) {
+		g := NewWithT(t)
+
+		for _, name := range []string{"", ".", "/pod.yaml", "../pod.yaml"} {
+			_, err := gotemplate.NewFromString(name, podTemplate)
+			g.Expect(err).To(MatchError(gotemplate.ErrInvalidTemplateName), name)
+		}
+	})
+}
+
+func TestTemplates(t *testing.T) {
+
+	newRenderer := func(t *testing.T) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name: "app",
+					FS: fstest.MapFS{
+						"_helpers.tpl": &fstest.MapFile{Data: []byte(`{{ define "name" }}{{ .name | upper }}{{ end }}`)},
+						"pod.yaml":     &fstest.MapFile{Data: []byte(`name: {{ include "name" . }}`)},
+					},
+					Path: "*",
+				},
+			},
+			gotemplate.WithFuncMap(template.FuncMap{"upper": strings.ToUpper}),
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	t.Run("should list the parsed templates", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		templates, err := renderer.Templates("app")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		names := make([]string, 0)
+		for _, tmpl := range templates.Templates() {
+			if tmpl.Name() != "" {
+				names = append(names, tmpl.Name())
+			}
+		}
+
+		g.Expect(names).To(ConsistOf("_helpers.tpl", "name", "pod.yaml"))
+	})
+
+	t.Run("should execute templates with functions applied", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		templates, err := renderer.Templates("app")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		g.Expect(templates.ExecuteTemplate(&buf, "pod.yaml", map[string]any{"name": "web"})).To(Succeed())
+		g.Expect(buf.String()).To(Equal("name: WEB"))
+	})
+
+	t.Run("should return an independent clone", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		templates, err := renderer.Templates("app")
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = templates.New("extra.yaml").Parse(`name: {{ include "name" . }}-extra`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		_, err = templates.New("name").Parse(`overridden`)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		var buf bytes.Buffer
+		g.Expect(templates.ExecuteTemplate(&buf, "extra.yaml", nil)).To(Succeed())
+		g.Expect(buf.String()).To(Equal("name: overridden-extra"))
+
+		again, err := renderer.Templates("app")
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(again.Lookup("extra.yaml")).To(BeNil())
+
+		out, err := renderer.RenderTemplate(t.Context(), "pod.yaml", map[string]any{"name": "web"})
+		g.Expect(err).ToNot(HaveOccurred())
+		g.Expect(string(out)).To(Equal("name: WEB"))
+	})
+
+	t.Run("should fail for unknown sources", func(t *testing.T) {
+		g := NewWithT(t)
+		renderer := newRenderer(t)
+
+		_, err := renderer.Templates("missing")
+		g.Expect(err).To(MatchError(gotemplate.ErrSourceNotFound))
+	})
+}
@@ -0,0 +1,77 @@
+package gotemplate
+
+import (
+	"context"
+	"text/template"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	// tracerName is the instrumentation scope of the spans emitted by the renderer.
+	tracerName = "github.com/k8s-manifest-kit/renderer-gotemplate"
+
+	// SpanLoadTemplates is the name of the span covering the loading of the templates of a Source.
+	SpanLoadTemplates = "gotemplate.LoadTemplates"
+
+	// SpanRender is the name of the span covering the rendering of a Source by Process.
+	SpanRender = "gotemplate.Render"
+
+	// AttributeSourcePath is the span attribute carrying the Source path pattern.
+	AttributeSourcePath = "gotemplate.source.path"
+
+	// AttributeSourceName is the span attribute carrying the Source name, if set.
+	AttributeSourceName = "gotemplate.source.name"
+
+	// AttributeCacheHit is the span attribute reporting whether the render cache was hit.
+	// It is only set when the render cache is enabled.
+	AttributeCacheHit = "gotemplate.cache.hit"
+)
+
+// startSpan starts a span called name for the Source as a child of the span in ctx.
+// Without a tracer it returns ctx unchanged and a nil span, so tracing costs nothing.
+func (r *Renderer) startSpan(ctx context.Context, name string, holder *sourceHolder) (context.Context, trace.Span) {
+	if r.tracer == nil {
+		return ctx, nil
+	}
+
+	attrs := []attribute.KeyValue{attribute.String(AttributeSourcePath, holder.pathPattern())}
+	if holder.Name != "" {
+		attrs = append(attrs, attribute.String(AttributeSourceName, holder.Name))
+	}
+
+	return r.tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+// setCacheHit records on span whether the render cache was hit; a nil span is ignored.
+func setCacheHit(span trace.Span, hit bool) {
+	if span != nil {
+		span.SetAttributes(attribute.Bool(AttributeCacheHit, hit))
+	}
+}
+
+// endSpan records err, if any, and ends span; a nil span is ignored.
+func endSpan(span trace.Span, err error) {
+	if span == nil {
+		return
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// loadTemplates loads the templates of a Source within a SpanLoadTemplates span.
+func (r *Renderer) loadTemplates(ctx context.Context, holder *sourceHolder) (*template.Template, error) {
+	_, span := r.startSpan(ctx, SpanLoadTemplates, holder)
+
+	templates, err := holder.LoadTemplates()
+	endSpan(span, err)
+
+	return templates, err
+}
@@ -0,0 +1,124 @@
+package gotemplate_test
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestTracing(t *testing.T) {
+
+	const podTemplate = `apiVersion: v1
+kind: Pod
+metadata:
+  name: {{ .name }}
+`
+
+	newRenderer := func(
+		t *testing.T,
+		provider *sdktrace.TracerProvider,
+		opts ...gotemplate.RendererOption,
+	) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					Name: "pods",
+					FS: fstest.MapFS{
+						"pod.yaml": &fstest.MapFile{Data: []byte(podTemplate)},
+					},
+					Path:   "*.yaml",
+					Values: gotemplate.Values(map[string]any{"name": "traced"}),
+				},
+			},
+			append(opts, gotemplate.WithTracerProvider(provider))...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		return renderer
+	}
+
+	attributes := func(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+		result := make(map[attribute.Key]attribute.Value)
+		for _, kv := range span.Attributes() {
+			result[kv.Key] = kv.Value
+		}
+
+		return result
+	}
+
+	t.Run("should emit nested spans with source attributes", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		renderer := newRenderer(t, provider)
+
+		ctx, parent := provider.Tracer("test").Start(t.Context(), "reconcile")
+		_, err := renderer.Process(ctx, nil)
+		parent.End()
+		g.Expect(err).ToNot(HaveOccurred())
+
+		spans := recorder.Ended()
+		g.Expect(spans).To(HaveLen(3))
+
+		load, render := spans[0], spans[1]
+		g.Expect(load.Name()).To(Equal(gotemplate.SpanLoadTemplates))
+		g.Expect(render.Name()).To(Equal(gotemplate.SpanRender))
+
+		g.Expect(load.Parent().SpanID()).To(Equal(render.SpanContext().SpanID()))
+		g.Expect(render.Parent().SpanID()).To(Equal(parent.SpanContext().SpanID()))
+
+		g.Expect(attributes(render)).To(And(
+			HaveKeyWithValue(attribute.Key(gotemplate.AttributeSourcePath), attribute.StringValue("*.yaml")),
+			HaveKeyWithValue(attribute.Key(gotemplate.AttributeSourceName), attribute.StringValue("pods")),
+			Not(HaveKey(attribute.Key(gotemplate.AttributeCacheHit))),
+		))
+	})
+
+	t.Run("should report cache misses and hits", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		renderer := newRenderer(t, provider, gotemplate.WithCache())
+
+		for range 2 {
+			_, err := renderer.Process(t.Context(), nil)
+			g.Expect(err).ToNot(HaveOccurred())
+		}
+
+		hits := make([]bool, 0)
+		for _, span := range recorder.Ended() {
+			if span.Name() == gotemplate.SpanRender {
+				hits = append(hits, attributes(span)[attribute.Key(gotemplate.AttributeCacheHit)].AsBool())
+			}
+		}
+
+		g.Expect(hits).To(Equal([]bool{false, true}))
+	})
+
+	t.Run("should record render errors", func(t *testing.T) {
+		g := NewWithT(t)
+
+		recorder := tracetest.NewSpanRecorder()
+		provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+		renderer := newRenderer(t, provider)
+
+		_, err := renderer.Process(t.Context(), map[string]any{"name": "[unclosed"})
+		g.Expect(err).To(HaveOccurred())
+
+		spans := recorder.Ended()
+		g.Expect(spans).ToNot(BeEmpty())
+		g.Expect(spans[len(spans)-1].Name()).To(Equal(gotemplate.SpanRender))
+		g.Expect(spans[len(spans)-1].Status().Description).To(ContainSubstring("failed to decode YAML"))
+	})
+}
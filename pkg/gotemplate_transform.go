@@ -0,0 +1,70 @@
+package gotemplate
+
+import (
+	"context"
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// ObjectsTransformer mutates the complete set of rendered objects, returning the objects to
+// keep. Unlike a per-object types.Transformer it may add, remove or reorder objects, e.g. to
+// apply patches, pin images or drop kinds.
+type ObjectsTransformer func(
+	ctx context.Context,
+	objects []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error)
+
+// transformObjects runs the WithObjectsTransformer transformers over objects, in registration order.
+func (r *Renderer) transformObjects(
+	ctx context.Context,
+	objects []*unstructured.Unstructured,
+) ([]*unstructured.Unstructured, error) {
+	for i, transform := range r.opts.ObjectsTransformers {
+		if err := ctx.Err(); err != nil {
+			return nil, fmt.Errorf("rendering cancelled before objects transformer %d: %w", i, err)
+		}
+
+		transformed, err := transform(ctx, objects)
+		if err != nil {
+			return nil, fmt.Errorf("objects transformer %d failed: %w", i, err)
+		}
+
+		objects = transformed
+	}
+
+	if objects == nil {
+		objects = make([]*unstructured.Unstructured, 0)
+	}
+
+	return objects, nil
+}
+
+// transformValues runs transformObjects over objects held by value, as returned by Process.
+func (r *Renderer) transformValues(
+	ctx context.Context,
+	objects []unstructured.Unstructured,
+) ([]unstructured.Unstructured, error) {
+	if len(r.opts.ObjectsTransformers) == 0 {
+		return objects, nil
+	}
+
+	pointers := make([]*unstructured.Unstructured, len(objects))
+	for i := range objects {
+		pointers[i] = &objects[i]
+	}
+
+	transformed, err := r.transformObjects(ctx, pointers)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]unstructured.Unstructured, 0, len(transformed))
+	for _, obj := range transformed {
+		if obj != nil {
+			result = append(result, *obj)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,160 @@
+package gotemplate
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+)
+
+// maxValuesPasses bounds the WithValuesTemplating passes, so values whose references keep
+// producing new output (e.g. a: "x{{ .b }}" and b: "{{ .a }}") fail instead of looping.
+const maxValuesPasses = 16
+
+// valuesPass renders the templated string values of one WithValuesTemplating pass against
+// a snapshot of the values, recording the paths of the strings that changed and of those
+// still containing template actions.
+type valuesPass struct {
+	holder    *sourceHolder
+	snapshot  map[string]any
+	delim     string
+	changed   []string
+	templated []string
+}
+
+// templateValues renders the string values containing template actions against the values
+// themselves, pass after pass, until no value changes. References may chain through other
+// templated values; values never settling (references to themselves, directly or through
+// other values) fail with ErrValuesCycle, naming the values involved.
+func (h *sourceHolder) templateValues(values map[string]any) (map[string]any, error) {
+	delim := h.leftDelim
+	if delim == "" {
+		delim = "{{"
+	}
+
+	for range maxValuesPasses {
+		pass := valuesPass{
+			holder:   h,
+			snapshot: values,
+			delim:    delim,
+		}
+
+		next, err := pass.renderMap(values, "")
+		if err != nil {
+			return nil, err
+		}
+
+		if len(pass.changed) == 0 {
+			if len(pass.templated) > 0 {
+				return nil, fmt.Errorf("%w: %s", ErrValuesCycle, joinPaths(pass.templated))
+			}
+
+			return values, nil
+		}
+
+		values = next
+	}
+
+	pass := valuesPass{holder: h, snapshot: values, delim: delim}
+	if _, err := pass.renderMap(values, ""); err != nil {
+		return nil, err
+	}
+
+	return nil, fmt.Errorf(
+		"%w: %s still changing after %d passes",
+		ErrValuesCycle,
+		joinPaths(pass.changed),
+		maxValuesPasses,
+	)
+}
+
+func (p *valuesPass) renderMap(values map[string]any, path string) (map[string]any, error) {
+	result := make(map[string]any, len(values))
+
+	for key, value := range values {
+		itemPath := key
+		if path != "" {
+			itemPath = path + "." + key
+		}
+
+		rendered, err := p.render(value, itemPath)
+		if err != nil {
+			return nil, err
+		}
+
+		result[key] = rendered
+	}
+
+	return result, nil
+}
+
+func (p *valuesPass) render(value any, path string) (any, error) {
+	switch v := value.(type) {
+	case map[string]any:
+		return p.renderMap(v, path)
+	case []any:
+		result := make([]any, len(v))
+
+		for i, item := range v {
+			rendered, err := p.render(item, path+"["+strconv.Itoa(i)+"]")
+			if err != nil {
+				return nil, err
+			}
+
+			result[i] = rendered
+		}
+
+		return result, nil
+	case string:
+		if !strings.Contains(v, p.delim) {
+			return v, nil
+		}
+
+		rendered, err := p.holder.renderValue(path, v, p.snapshot)
+		if err != nil {
+			return nil, err
+		}
+
+		if rendered != v {
+			p.changed = append(p.changed, path)
+		}
+
+		if strings.Contains(rendered, p.delim) {
+			p.templated = append(p.templated, path)
+		}
+
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
+
+// renderValue executes the string value at path as a template against values, with the
+// delimiters, missing key mode and functions of the Source templates (except include and
+// tpl, which need a template set).
+func (h *sourceHolder) renderValue(path string, text string, values map[string]any) (string, error) {
+	t, err := template.New(path).
+		Delims(h.leftDelim, h.rightDelim).
+		Funcs(filesFuncMap(h.FS)).
+		Funcs(h.executionFuncs(path)).
+		Option("missingkey=" + string(h.missingKey)).
+		Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("failed to parse value %s: %w", path, err)
+	}
+
+	var buf strings.Builder
+	if err := t.Execute(&buf, values); err != nil {
+		return "", fmt.Errorf("failed to render value %s: %w", path, err)
+	}
+
+	return buf.String(), nil
+}
+
+// joinPaths returns the value paths sorted and comma separated, for deterministic errors.
+func joinPaths(paths []string) string {
+	slices.Sort(paths)
+
+	return strings.Join(paths, ", ")
+}
@@ -0,0 +1,97 @@
+package gotemplate
+
+import (
+	"io/fs"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// watcher polls the Sources of a renderer and invalidates the ones whose files changed.
+type watcher struct {
+	stop chan struct{}
+	done chan struct{}
+}
+
+// startWatch starts polling the renderer Sources every interval.
+func (r *Renderer) startWatch(interval time.Duration) {
+	w := &watcher{
+		stop: make(chan struct{}),
+		done: make(chan struct{}),
+	}
+
+	// Snapshot before returning from New, so changes made right after are detected
+	snapshots := make([]string, len(r.inputs))
+	for i, h := range r.inputs {
+		snapshots[i] = h.fingerprint()
+	}
+
+	go func() {
+		defer close(w.done)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-w.stop:
+				return
+			case <-ticker.C:
+				for i, h := range r.inputs {
+					if fp := h.fingerprint(); fp != snapshots[i] {
+						snapshots[i] = fp
+						r.invalidateSource(h)
+					}
+				}
+			}
+		}
+	}()
+
+	r.watcher = w
+}
+
+// Stop stops polling and waits for the polling goroutine to exit.
+func (w *watcher) Stop() {
+	close(w.stop)
+	<-w.done
+}
+
+// invalidateSource discards the cached render results and parsed templates of a Source.
+func (r *Renderer) invalidateSource(h *sourceHolder) {
+	if r.cache != nil {
+		r.cache.DeletePath(h.pathPattern())
+	}
+
+	h.Reset()
+}
+
+// fingerprint summarizes the files matched by the Source patterns (names, sizes and
+// modification times), so that any added, removed or modified template changes it.
+func (h *sourceHolder) fingerprint() string {
+	var b strings.Builder
+
+	for _, pattern := range h.patterns() {
+		files, err := globFiles(h.FS, pattern)
+		if err != nil {
+			b.WriteString("error:" + err.Error() + "\n")
+
+			continue
+		}
+
+		for _, file := range files {
+			b.WriteString(file)
+
+			info, err := fs.Stat(h.FS, file)
+			if err != nil {
+				b.WriteString(":error:" + err.Error() + "\n")
+
+				continue
+			}
+
+			b.WriteString(":" + strconv.FormatInt(info.Size(), 10))
+			b.WriteString(":" + strconv.FormatInt(info.ModTime().UnixNano(), 10) + "\n")
+		}
+	}
+
+	return b.String()
+}
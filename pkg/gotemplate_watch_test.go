@@ -0,0 +1,122 @@
+package gotemplate_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	gotemplate "github.com/k8s-manifest-kit/renderer-gotemplate/pkg"
+
+	. "github.com/onsi/gomega"
+)
+
+func TestWatch(t *testing.T) {
+
+	writeTemplate := func(t *testing.T, dir string, file string, name string, modTime time.Time) {
+		t.Helper()
+
+		target := filepath.Join(dir, file)
+		data := []byte("apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: " + name + "\n")
+
+		NewWithT(t).Expect(os.WriteFile(target, data, 0o600)).To(Succeed())
+
+		// Set the modification time explicitly, filesystems may have a coarse timestamp resolution
+		NewWithT(t).Expect(os.Chtimes(target, modTime, modTime)).To(Succeed())
+	}
+
+	newRenderer := func(t *testing.T, dir string, opts ...gotemplate.RendererOption) *gotemplate.Renderer {
+		t.Helper()
+
+		renderer, err := gotemplate.New(
+			[]gotemplate.Source{
+				{
+					FS:   os.DirFS(dir),
+					Path: "*.yaml",
+				},
+			},
+			opts...,
+		)
+		NewWithT(t).Expect(err).ToNot(HaveOccurred())
+
+		t.Cleanup(func() { _ = renderer.Close() })
+
+		return renderer
+	}
+
+	names := func(g Gomega, renderer *gotemplate.Renderer) []string {
+		objects, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).ToNot(HaveOccurred())
+
+		result := make([]string, 0, len(objects))
+		for _, obj := range objects {
+			result = append(result, obj.GetName())
+		}
+
+		return result
+	}
+
+	start := time.Now().Add(-time.Hour)
+
+	t.Run("should pick up modified templates", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+		writeTemplate(t, dir, "configmap.yaml", "before", start)
+
+		renderer := newRenderer(t, dir, gotemplate.WithWatch(10*time.Millisecond), gotemplate.WithCache())
+		g.Expect(names(g, renderer)).To(Equal([]string{"before"}))
+
+		writeTemplate(t, dir, "configmap.yaml", "after", start.Add(time.Minute))
+
+		g.Eventually(func(g Gomega) []string {
+			return names(g, renderer)
+		}).WithTimeout(5 * time.Second).WithPolling(10 * time.Millisecond).Should(Equal([]string{"after"}))
+	})
+
+	t.Run("should pick up added templates", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+		writeTemplate(t, dir, "a.yaml", "a", start)
+
+		renderer := newRenderer(t, dir, gotemplate.WithWatch(10*time.Millisecond))
+		g.Expect(names(g, renderer)).To(Equal([]string{"a"}))
+
+		writeTemplate(t, dir, "b.yaml", "b", start)
+
+		g.Eventually(func(g Gomega) []string {
+			return names(g, renderer)
+		}).WithTimeout(5 * time.Second).WithPolling(10 * time.Millisecond).Should(Equal([]string{"a", "b"}))
+	})
+
+	t.Run("should keep parsed templates without watching", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+		writeTemplate(t, dir, "configmap.yaml", "before", start)
+
+		renderer := newRenderer(t, dir)
+		g.Expect(names(g, renderer)).To(Equal([]string{"before"}))
+
+		writeTemplate(t, dir, "configmap.yaml", "after", start.Add(time.Minute))
+
+		g.Consistently(func(g Gomega) []string {
+			return names(g, renderer)
+		}).WithTimeout(50 * time.Millisecond).WithPolling(10 * time.Millisecond).Should(Equal([]string{"before"}))
+	})
+
+	t.Run("should stop watching on Close", func(t *testing.T) {
+		g := NewWithT(t)
+		dir := t.TempDir()
+		writeTemplate(t, dir, "configmap.yaml", "before", start)
+
+		renderer := newRenderer(t, dir, gotemplate.WithWatch(10*time.Millisecond))
+		g.Expect(names(g, renderer)).To(Equal([]string{"before"}))
+
+		g.Expect(renderer.Close()).To(Succeed())
+
+		// Close waits for the polling goroutine, so the change can no longer race with it
+		writeTemplate(t, dir, "configmap.yaml", "after", start.Add(time.Minute))
+
+		_, err := renderer.Process(t.Context(), nil)
+		g.Expect(err).To(MatchError(gotemplate.ErrRendererClosed))
+	})
+}
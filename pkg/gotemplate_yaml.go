@@ -0,0 +1,347 @@
+package gotemplate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"text/template"
+
+	"sigs.k8s.io/yaml"
+)
+
+// documentSeparator is written between documents streamed by RenderTo.
+const documentSeparator = "---\n"
+
+// splitDocuments splits rendered YAML into individual documents.
+//
+// Only "---" (optionally followed by whitespace, a comment or inline content) and "..."
+// at the very start of a line are treated as markers. Block scalar content is always
+// indented, so a "---" line inside a literal or folded scalar is never mistaken for a
+// separator. Documents containing only whitespace or comments, including the empty ones
+// around a leading or trailing separator, are dropped unless keepEmpty is set.
+func splitDocuments(data []byte, keepEmpty bool) [][]byte {
+	segments := make([][]byte, 0)
+
+	var current bytes.Buffer
+
+	flush := func() {
+		segments = append(segments, trimDocument(current.Bytes()))
+		current.Reset()
+	}
+
+	for _, line := range bytes.SplitAfter(data, []byte("\n")) {
+		marker, rest := documentMarker(line)
+		if !marker {
+			current.Write(line)
+
+			continue
+		}
+
+		flush()
+		current.Write(rest)
+	}
+
+	flush()
+
+	documents := make([][]byte, 0, len(segments))
+
+	for _, doc := range segments {
+		switch {
+		case !isEmptyDocument(doc):
+			documents = append(documents, doc)
+		case keepEmpty && doc == nil:
+			documents = append(documents, []byte{})
+		case keepEmpty:
+			documents = append(documents, doc)
+		}
+	}
+
+	return documents
+}
+
+// documentMarker reports whether line is a document start or end marker. For a start
+// marker carrying inline content (e.g. "--- |"), the content is returned so it can be kept
+// as the first line of the next document.
+func documentMarker(line []byte) (bool, []byte) {
+	trimmed := bytes.TrimRight(line, "\r\n")
+
+	if bytes.Equal(trimmed, []byte("...")) {
+		return true, nil
+	}
+
+	if !bytes.HasPrefix(trimmed, []byte("---")) {
+		return false, nil
+	}
+
+	if len(trimmed) > 3 && trimmed[3] != ' ' && trimmed[3] != '\t' {
+		return false, nil
+	}
+
+	rest := bytes.TrimSpace(trimmed[3:])
+	if len(rest) == 0 || rest[0] == '#' {
+		return true, nil
+	}
+
+	return true, append(bytes.Clone(rest), '\n')
+}
+
+// trimDocument returns a copy of doc without leading blank lines and trailing whitespace,
+// terminated by a single newline.
+func trimDocument(doc []byte) []byte {
+	for len(doc) > 0 {
+		end := bytes.IndexByte(doc, '\n')
+		if end < 0 || len(bytes.TrimSpace(doc[:end])) > 0 {
+			break
+		}
+
+		doc = doc[end+1:]
+	}
+
+	doc = bytes.TrimRight(doc, " \t\r\n")
+	if len(doc) == 0 {
+		return nil
+	}
+
+	result := make([]byte, 0, len(doc)+1)
+	result = append(result, doc...)
+
+	return append(result, '\n')
+}
+
+// isEmptyDocument reports whether doc contains only whitespace and comments.
+func isEmptyDocument(doc []byte) bool {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] != '#' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// documentWriter streams documents to an underlying writer, inserting a separator
+// (preceded by a newline if the previous document did not end with one) between them.
+// A custom separator (WithDocumentSeparator) is written as is.
+type documentWriter struct {
+	w       io.Writer
+	sep     *string
+	written bool
+	last    byte
+}
+
+// StartDocument writes the separator needed before the next document, if any.
+func (d *documentWriter) StartDocument() error {
+	if !d.written {
+		return nil
+	}
+
+	sep := documentSeparator
+	if d.sep != nil {
+		sep = *d.sep
+	} else if d.last != '\n' {
+		sep = "\n" + sep
+	}
+
+	if sep == "" {
+		return nil
+	}
+
+	_, err := io.WriteString(d, sep)
+
+	return err
+}
+
+func (d *documentWriter) Write(p []byte) (int, error) {
+	n, err := d.w.Write(p)
+	if n > 0 {
+		d.written = true
+		d.last = p[n-1]
+	}
+
+	if err != nil {
+		return n, fmt.Errorf("failed to write rendered output: %w", err)
+	}
+
+	return n, nil
+}
+
+// checksDocuments reports whether any per-document check is enabled, so that the
+// rendered output only has to be split when needed.
+func (r *Renderer) checksDocuments() bool {
+	return r.opts.StrictYAML || r.opts.RequireGVK || r.opts.KRMOutput
+}
+
+// checkDocument applies the opt-in checks to the document at index of the output of a Source,
+// rendered by the template called name. With WithStrictYAML the document must parse with a
+// strict YAML decoder, which also rejects duplicate keys. With WithRequireGVK it must have a
+// non-empty apiVersion and kind, unless it is empty or the Source is exempt; with WithKRMOutput
+// no Source is exempt.
+func (r *Renderer) checkDocument(holder *sourceHolder, name string, index int, doc []byte) error {
+	if r.opts.StrictYAML {
+		if _, err := yaml.YAMLToJSONStrict(doc); err != nil {
+			return documentError(ErrInvalidYAML, holder, name, index, err.Error())
+		}
+	}
+
+	if r.requiresGVK(holder) && !isEmptyDocument(doc) {
+		var manifest map[string]any
+		if err := yaml.Unmarshal(doc, &manifest); err != nil {
+			return documentError(ErrInvalidYAML, holder, name, index, err.Error())
+		}
+
+		for _, field := range []string{"apiVersion", "kind"} {
+			if value, _ := manifest[field].(string); value == "" {
+				return documentError(
+					ErrMissingGVK,
+					holder,
+					name,
+					index,
+					fmt.Sprintf("missing %s, starting with %q", field, firstLine(doc)),
+				)
+			}
+		}
+	}
+
+	return nil
+}
+
+// requiresGVK reports whether the documents of the Source must have an apiVersion and kind.
+func (r *Renderer) requiresGVK(holder *sourceHolder) bool {
+	return r.opts.KRMOutput || (r.opts.RequireGVK && !r.exemptFromGVK(holder))
+}
+
+// exemptFromGVK reports whether WithRequireGVK exempts the Source, by name or path pattern.
+func (r *Renderer) exemptFromGVK(holder *sourceHolder) bool {
+	for _, exempt := range r.opts.GVKExemptSources {
+		if exempt == holder.pathPattern() || (holder.Name != "" && exempt == holder.Name) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// documentError reports a failed check of the document at index of the output of a Source.
+func documentError(sentinel error, holder *sourceHolder, name string, index int, detail string) error {
+	return fmt.Errorf(
+		"%w: document %d of gotemplate pattern %s (template %s): %s",
+		sentinel,
+		index,
+		holder.describe(),
+		name,
+		detail,
+	)
+}
+
+// firstLine returns the first line of doc that is neither blank nor a comment.
+func firstLine(doc []byte) string {
+	for _, line := range bytes.Split(doc, []byte("\n")) {
+		if trimmed := bytes.TrimSpace(line); len(trimmed) > 0 && trimmed[0] != '#' {
+			return string(trimmed)
+		}
+	}
+
+	return ""
+}
+
+// encodeDocument encodes the document at index of the output of a Source in the configured
+// OutputFormat, canonicalized with WithCanonicalize, reporting whether it is kept. With
+// FormatJSON, empty documents are dropped and documents that are not YAML mappings fail with
+// ErrInvalidYAML.
+func (r *Renderer) encodeDocument(holder *sourceHolder, name string, index int, doc []byte) ([]byte, bool, error) {
+	if r.opts.OutputFormat != FormatJSON {
+		if r.opts.Canonicalize {
+			return r.canonicalDocument(holder, name, index, doc)
+		}
+
+		return doc, true, nil
+	}
+
+	if isEmptyDocument(doc) {
+		return nil, false, nil
+	}
+
+	data, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		return nil, false, documentError(ErrInvalidYAML, holder, name, index, "cannot convert to JSON: "+err.Error())
+	}
+
+	if len(data) == 0 || data[0] != '{' {
+		return nil, false, documentError(
+			ErrInvalidYAML,
+			holder,
+			name,
+			index,
+			fmt.Sprintf("cannot convert to a JSON object, starting with %q", firstLine(doc)),
+		)
+	}
+
+	return append(data, '\n'), true, nil
+}
+
+// canonicalDocument re-emits the document at index of the output of a Source with sorted keys,
+// two-space indentation and normalized quoting. Documents that are not YAML mappings or
+// sequences, e.g. plain text, and empty ones are kept as rendered; documents failing to parse
+// are too, unless WithStrictYAML makes them fail with ErrInvalidYAML.
+func (r *Renderer) canonicalDocument(
+	holder *sourceHolder,
+	name string,
+	index int,
+	doc []byte,
+) ([]byte, bool, error) {
+	data, err := yaml.YAMLToJSON(doc)
+	if err != nil {
+		if r.opts.StrictYAML {
+			return nil, false, documentError(ErrInvalidYAML, holder, name, index, err.Error())
+		}
+
+		return doc, true, nil
+	}
+
+	if len(data) == 0 || (data[0] != '{' && data[0] != '[') {
+		return doc, true, nil
+	}
+
+	canonical, err := yaml.JSONToYAML(data)
+	if err != nil {
+		return nil, false, documentError(ErrInvalidYAML, holder, name, index, "cannot canonicalize: "+err.Error())
+	}
+
+	return canonical, true, nil
+}
+
+// writeDocuments executes t, splits its output into documents starting at index and writes
+// each encoded by encodeDocument to out, returning the index of the next document.
+func (r *Renderer) writeDocuments(
+	ctx context.Context,
+	out *documentWriter,
+	holder *sourceHolder,
+	t *template.Template,
+	values map[string]any,
+	index int,
+) (int, error) {
+	var buf bytes.Buffer
+	if err := r.executeTemplate(ctx, holder, t, &buf, values); err != nil {
+		return index, err
+	}
+
+	for _, doc := range splitDocuments(buf.Bytes(), false) {
+		encoded, _, err := r.encodeDocument(holder, t.Name(), index, doc)
+		if err != nil {
+			return index, err
+		}
+
+		if err := out.StartDocument(); err != nil {
+			return index, fmt.Errorf("failed to write document separator: %w", err)
+		}
+
+		if _, err := out.Write(encoded); err != nil {
+			return index, err
+		}
+
+		index++
+	}
+
+	return index, nil
+}